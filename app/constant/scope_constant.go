@@ -0,0 +1,75 @@
+package constant
+
+import "slices"
+
+const (
+	// ScopeAll grants every scope, it's only given to the admin users
+	ScopeAll = "*"
+
+	// Scopes of the own user account
+	ScopeAccountRead  = "account:read"
+	ScopeAccountWrite = "account:write"
+
+	// Scopes of the own personal access tokens
+	ScopeTokensRead  = "tokens:read"
+	ScopeTokensWrite = "tokens:write"
+
+	// Scopes of the service accounts management
+	ScopeServiceAccountsRead  = "service_accounts:read"
+	ScopeServiceAccountsWrite = "service_accounts:write"
+
+	// Scopes of the users management
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+
+	// ScopeUsersImpersonate allows acting as another user, it's not grantable into the
+	// API keys and the personal access tokens
+	ScopeUsersImpersonate = "users:impersonate"
+
+	// Scopes of the security audit trail
+	ScopeAuditRead = "audit:read"
+	// .. specify other scopes here
+)
+
+// userScopes are the scopes of the users logged in with a session
+var userScopes = []string{
+	ScopeAccountRead,
+	ScopeAccountWrite,
+	ScopeTokensRead,
+	ScopeTokensWrite,
+}
+
+// grantableScopes are the scopes allowed to be granted into the API keys
+var grantableScopes = []string{
+	ScopeServiceAccountsRead,
+	ScopeServiceAccountsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeAuditRead,
+}
+
+// personalScopes are the scopes allowed to be granted into the personal access tokens,
+// the tokens scopes are excluded so a token can't be used to mint other tokens
+var personalScopes = []string{
+	ScopeAccountRead,
+	ScopeAccountWrite,
+}
+
+// GetRoleScopes get the scopes granted to the users of the role
+func GetRoleScopes(roleID uint) []string {
+	if roleID == AdminRoleID {
+		return []string{ScopeAll}
+	}
+	return userScopes
+}
+
+// IsScopeGrantable checks the scope is allowed to be granted into the API keys
+func IsScopeGrantable(scope string) bool {
+	return slices.Contains(grantableScopes, scope)
+}
+
+// IsPersonalScopeGrantable checks the scope is allowed to be granted into the personal
+// access tokens, the admin users may also grant the scopes of the API keys
+func IsPersonalScopeGrantable(scope string) bool {
+	return slices.Contains(personalScopes, scope) || IsScopeGrantable(scope)
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultAccountDeletionGracePeriod is the duration the account is kept after the
+	// deletion is requested, logging in within the period cancels the deletion
+	DefaultAccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+	// AccountPurgeBatchSize limits the accounts deleted by a single run
+	AccountPurgeBatchSize = 100
+)
+
+// Actions of the account deletion recorded in the audit trail
+const (
+	deletionRequested = "requested"
+	deletionCancelled = "cancelled"
+	deletionCompleted = "completed"
+)
+
+type AccountDeletionController interface {
+	RequestAccountDeletion(ctx context.Context, userID uint) (time.Time, error)
+	PurgeDeletedAccounts(ctx context.Context) (int64, error)
+}
+
+type AccountDeletionControllerImpl struct {
+	accountDeletionRepository repository.AccountDeletionRepository
+	loginInfoRepository       repository.LoginInfoRepository
+	sessionRepository         repository.SessionRepository
+	auditRepository           repository.AuditRepository
+}
+
+func NewAccountDeletionController(
+	accountDeletionRepository repository.AccountDeletionRepository,
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+) *AccountDeletionControllerImpl {
+	return &AccountDeletionControllerImpl{
+		accountDeletionRepository: accountDeletionRepository,
+		loginInfoRepository:       loginInfoRepository,
+		sessionRepository:         sessionRepository,
+		auditRepository:           auditRepository,
+	}
+}
+
+// RequestAccountDeletion schedules the deletion of the user's account after the grace
+// period and logs the user out of every device, it returns the time the account is
+// deleted at. The user confirms the deletion by the email and cancels it by logging in.
+func (c AccountDeletionControllerImpl) RequestAccountDeletion(ctx context.Context, userID uint) (scheduledAt time.Time, err error) {
+	ctx, span := tracer.Start(ctx, "AccountDeletionController.RequestAccountDeletion", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	scheduledAt = time.Now().Add(env.GetDurationOrDefault("ACCOUNT_DELETION_GRACE_PERIOD", DefaultAccountDeletionGracePeriod))
+	err = c.accountDeletionRepository.ScheduleAccountDeletion(ctx, userID, scheduledAt)
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+		EventType: model.AuditAccountDeletion,
+		UserID:    &userID,
+		Metadata:  map[string]string{"action": deletionRequested, "scheduled_at": scheduledAt.UTC().Format(time.RFC3339)},
+	}, err)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if _, err := revokeUserSessions(ctx, c.sessionRepository, c.auditRepository, userID, "account_deletion"); err != nil {
+		return time.Time{}, err
+	}
+
+	// Only the user with the password login has the email address to be confirmed to
+	credential := &model.LoginInfo{ID: userID}
+	if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Errorf("error find credential of the deleted account: %v", err)
+		}
+		return scheduledAt, nil
+	}
+
+	// Send the deletion confirmation email asyncronously
+	go func(ctx context.Context) {
+		if err := mailer.SendAccountDeletionScheduled(ctx, credential.Email, credential.Username, scheduledAt); err != nil {
+			log.Errorf("error send account deletion email: %v", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return scheduledAt, nil
+}
+
+// PurgeDeletedAccounts deletes the accounts whose grace period is over along with the
+// user's data, the 'user.deleted' event is published so the other services purge their
+// data of the user. It returns the number of the deleted accounts.
+func (c AccountDeletionControllerImpl) PurgeDeletedAccounts(ctx context.Context) (deleted int64, err error) {
+	ctx, span := tracer.Start(ctx, "AccountDeletionController.PurgeDeletedAccounts")
+	defer func() { tracer.End(span, err) }()
+
+	now := time.Now()
+	accounts, err := c.accountDeletionRepository.FindAccountsDueForDeletion(ctx, now, AccountPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, account := range accounts {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		userID := account.ID
+		event := &model.OutboxEvent{
+			EventKey:  uuid.New().String(),
+			EventType: model.EventUserDeleted,
+			Payload:   map[string]string{"user_id": strconv.FormatUint(uint64(userID), 10)},
+		}
+		err := c.accountDeletionRepository.PurgeAccount(ctx, userID, now, event)
+		if errors.Is(err, apperror.ErrNotFound) {
+			// The user logged in meanwhile and cancelled the deletion
+			continue
+		}
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditAccountDeletion,
+			UserID:    &userID,
+			Metadata:  map[string]string{"action": deletionCompleted},
+		}, err)
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	if deleted > 0 {
+		refreshActiveSessions(ctx, c.sessionRepository)
+	}
+	return deleted, nil
+}
+
+// cancelAccountDeletion cancels the scheduled deletion of the user logging in, failing
+// to cancel is logged and doesn't fail the login
+func cancelAccountDeletion(
+	ctx context.Context,
+	accountDeletionRepository repository.AccountDeletionRepository,
+	auditRepository repository.AuditRepository,
+	userID uint,
+) {
+	cancelled, err := accountDeletionRepository.CancelAccountDeletion(ctx, userID)
+	if err != nil {
+		log.Errorf("error cancel account deletion: %v", err)
+		return
+	}
+	if cancelled {
+		log.WithField("user_id", userID).Info("Account deletion cancelled by login")
+		recordAuditEvent(ctx, auditRepository, model.AuditEvent{
+			EventType: model.AuditAccountDeletion,
+			UserID:    &userID,
+			Metadata:  map[string]string{"action": deletionCancelled},
+		}, nil)
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// accountStatusTransitions are the statuses the account is allowed to be changed from
+// by an admin, the account pending for deletion is reinstated back to the pending status
+var accountStatusTransitions = map[string][]string{
+	model.AccountSuspended:   {model.AccountActive, model.AccountPendingDeletion},
+	model.AccountDeactivated: {model.AccountActive, model.AccountPendingDeletion, model.AccountSuspended},
+	model.AccountActive:      {model.AccountSuspended, model.AccountDeactivated},
+	// The reinstated account keeps its scheduled deletion
+	model.AccountPendingDeletion: {model.AccountSuspended, model.AccountDeactivated},
+}
+
+type AccountStatusController interface {
+	SuspendAccount(ctx context.Context, actorID uint, userID uint, reason string) (*model.Account, error)
+	DeactivateAccount(ctx context.Context, actorID uint, userID uint, reason string) (*model.Account, error)
+	ReinstateAccount(ctx context.Context, actorID uint, userID uint, reason string) (*model.Account, error)
+}
+
+type AccountStatusControllerImpl struct {
+	accountRepository repository.AccountRepository
+	sessionRepository repository.SessionRepository
+	auditRepository   repository.AuditRepository
+}
+
+func NewAccountStatusController(
+	accountRepository repository.AccountRepository,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+) *AccountStatusControllerImpl {
+	return &AccountStatusControllerImpl{
+		accountRepository: accountRepository,
+		sessionRepository: sessionRepository,
+		auditRepository:   auditRepository,
+	}
+}
+
+// SuspendAccount temporarily blocks the user from logging in, the live sessions of the
+// user are revoked immediately
+func (c AccountStatusControllerImpl) SuspendAccount(ctx context.Context, actorID uint, userID uint, reason string) (account *model.Account, err error) {
+	ctx, span := tracer.Start(ctx, "AccountStatusController.SuspendAccount", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.changeAccountStatus(ctx, actorID, userID, model.AccountSuspended, reason)
+}
+
+// DeactivateAccount blocks the user from logging in until an admin reinstates the
+// account, the live sessions of the user are revoked immediately
+func (c AccountStatusControllerImpl) DeactivateAccount(ctx context.Context, actorID uint, userID uint, reason string) (account *model.Account, err error) {
+	ctx, span := tracer.Start(ctx, "AccountStatusController.DeactivateAccount", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.changeAccountStatus(ctx, actorID, userID, model.AccountDeactivated, reason)
+}
+
+// ReinstateAccount allows the suspended or deactivated user to login again
+func (c AccountStatusControllerImpl) ReinstateAccount(ctx context.Context, actorID uint, userID uint, reason string) (account *model.Account, err error) {
+	ctx, span := tracer.Start(ctx, "AccountStatusController.ReinstateAccount", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.changeAccountStatus(ctx, actorID, userID, model.AccountActive, reason)
+}
+
+func (c AccountStatusControllerImpl) changeAccountStatus(ctx context.Context, actorID uint, userID uint, status string, reason string) (*model.Account, error) {
+	// The admin shouldn't lock themselves out
+	if actorID == userID {
+		return nil, apperror.ErrOwnStatusChange
+	}
+
+	account, err := c.accountRepository.FindAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CreatedAt.IsZero() {
+		return nil, apperror.ErrUserNotFound
+	}
+
+	if status == model.AccountActive && account.DeletionScheduledAt != nil {
+		status = model.AccountPendingDeletion
+	}
+
+	previousStatus := account.Status
+	now := time.Now()
+	account.Status = status
+	account.StatusReason = truncate(reason, 250)
+	account.StatusChangedBy = &actorID
+	account.StatusChangedAt = &now
+	changed, err := c.accountRepository.UpdateAccountStatus(ctx, &account, accountStatusTransitions[status])
+	if err == nil && !changed {
+		err = apperror.ErrInvalidStatusTransition
+	}
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+		EventType: model.AuditStatusChange,
+		UserID:    &userID,
+		Metadata: map[string]string{
+			"from":     previousStatus,
+			"to":       status,
+			"reason":   account.StatusReason,
+			"actor_id": strconv.FormatUint(uint64(actorID), 10),
+		},
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the user out of every device
+	if !account.CanLogin() {
+		if _, err := revokeUserSessions(ctx, c.sessionRepository, c.auditRepository, userID, "account_"+status); err != nil {
+			return nil, err
+		}
+	}
+
+	return &account, nil
+}
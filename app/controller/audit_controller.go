@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultAuditPageSize is the number of events listed when the page size isn't specified
+	DefaultAuditPageSize = 50
+
+	// MaxAuditPageSize is the maximum number of events listed in a page
+	MaxAuditPageSize = 200
+)
+
+type AuditController interface {
+	GetLoginHistory(ctx context.Context, userID uint, pageSize int, pageToken string) ([]model.AuditEvent, string, error)
+	ListAuditEvents(ctx context.Context, filter repository.AuditEventFilter, pageSize int, pageToken string) ([]model.AuditEvent, string, error)
+}
+
+type AuditControllerImpl struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditController(auditRepository repository.AuditRepository) *AuditControllerImpl {
+	return &AuditControllerImpl{auditRepository: auditRepository}
+}
+
+func (c AuditControllerImpl) GetLoginHistory(ctx context.Context, userID uint, pageSize int, pageToken string) (events []model.AuditEvent, nextPageToken string, err error) {
+	ctx, span := tracer.Start(ctx, "AuditController.GetLoginHistory", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.findEvents(ctx, repository.AuditEventFilter{
+		UserID:     &userID,
+		EventTypes: model.LoginEventTypes,
+	}, pageSize, pageToken)
+}
+
+func (c AuditControllerImpl) ListAuditEvents(ctx context.Context, filter repository.AuditEventFilter, pageSize int, pageToken string) (events []model.AuditEvent, nextPageToken string, err error) {
+	ctx, span := tracer.Start(ctx, "AuditController.ListAuditEvents")
+	defer func() { tracer.End(span, err) }()
+
+	return c.findEvents(ctx, filter, pageSize, pageToken)
+}
+
+// findEvents lists a page of the filtered events, the page token is the id of the last
+// event of the previous page. No next page token is returned for the last page.
+func (c AuditControllerImpl) findEvents(ctx context.Context, filter repository.AuditEventFilter, pageSize int, pageToken string) ([]model.AuditEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultAuditPageSize
+	}
+	pageSize = min(pageSize, MaxAuditPageSize)
+
+	if pageToken != "" {
+		afterID, err := strconv.ParseUint(pageToken, 10, 64)
+		if err != nil || afterID == 0 {
+			return nil, "", apperror.ErrInvalidPageToken
+		}
+		filter.AfterID = uint(afterID)
+	}
+
+	// Find one more event to know whether there is a next page
+	filter.Limit = pageSize + 1
+	events, err := c.auditRepository.FindAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(events) <= pageSize {
+		return events, "", nil
+	}
+	events = events[:pageSize]
+	return events, strconv.FormatUint(uint64(events[pageSize-1].ID), 10), nil
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordAuditEvent appends the security event along with the client and the actor
+// of the request, the outcome is resolved from the error of the audited operation.
+// Failing to record the event is logged and doesn't fail the audited operation.
+func recordAuditEvent(ctx context.Context, auditRepository repository.AuditRepository, event model.AuditEvent, err error) {
+	event.Outcome = model.AuditSuccess
+	if err != nil {
+		event.Outcome = model.AuditFailure
+		if event.Reason == "" {
+			event.Reason = auditReason(err)
+		}
+	}
+
+	info := client.FromContext(ctx)
+	event.IPAddress, event.UserAgent = info.IP, truncate(info.UserAgent, 250)
+
+	// The caller is the actor, the unauthenticated caller succeeding to login is the user itself
+	switch caller, ok := principal.FromContext(ctx); {
+	case ok && caller.IsImpersonated():
+		// The admin impersonating the user acts on behalf of the user
+		event.ActorType, event.ActorID = model.AuditActorUser, &caller.ImpersonatorID
+		if event.Metadata == nil {
+			event.Metadata = map[string]string{}
+		}
+		event.Metadata["impersonated_user_id"] = strconv.FormatUint(uint64(caller.ID), 10)
+	case ok:
+		event.ActorType, event.ActorID = string(caller.Type), &caller.ID
+	case err == nil && event.UserID != nil:
+		event.ActorType, event.ActorID = model.AuditActorUser, event.UserID
+	default:
+		event.ActorType = model.AuditActorAnonymous
+	}
+
+	// The event is recorded even when the request is cancelled after the operation
+	if err := auditRepository.CreateAuditEvent(context.WithoutCancel(ctx), &event); err != nil {
+		log.WithField("event_type", event.EventType).Errorf("error record audit event: %v", err)
+	}
+}
+
+// auditReason get the machine readable reason of the failure
+func auditReason(err error) string {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Reason()
+	}
+	return "INTERNAL"
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
+
+// recordLoginEvent records the login attempt of the login method, the user is unknown
+// when the attempt fails before the user is identified
+func recordLoginEvent(ctx context.Context, auditRepository repository.AuditRepository, method string, userID *uint, err error) {
+	eventType := model.AuditLoginSuccess
+	if err != nil {
+		eventType = model.AuditLoginFailure
+	}
+	recordAuditEvent(ctx, auditRepository, model.AuditEvent{
+		EventType: eventType,
+		UserID:    userID,
+		Metadata:  map[string]string{"method": method},
+	}, err)
+}
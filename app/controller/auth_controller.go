@@ -1,26 +1,47 @@
 package controller
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/client"
 	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
 	"github.com/budgetin-app/user-service/app/pkg/hasher"
 	"github.com/budgetin-app/user-service/app/pkg/helper/token"
 	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
 	"github.com/budgetin-app/user-service/app/repository"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// LoginResult is the result of the first factor login, the session is only created
+// when the user has no passkey, otherwise the passkey assertion options of the second
+// factor are returned and the session is created once the assertion is verified
+type LoginResult struct {
+	Session    *model.Session
+	MFAOptions []byte
+}
+
 type AuthController interface {
-	Register(username string, email string, password string) (*model.LoginInfo, error)
-	Login(isEmail bool, identifier string, password string) (*model.Session, error)
-	Logout(authToken string) (bool, error)
-	VerifyEmail(email string) (bool, error)
+	Register(ctx context.Context, username string, email string, password string, invitationCode string) (*model.LoginInfo, error)
+	Login(ctx context.Context, isEmail bool, identifier string, password string, options model.SessionOptions) (*LoginResult, error)
+	Logout(ctx context.Context, authToken string) (bool, error)
+	VerifyEmail(ctx context.Context, email string) (bool, error)
+	ConfirmEmail(ctx context.Context, verificationToken string) (bool, error)
+	Authenticate(ctx context.Context, authToken string) (*principal.Principal, error)
+	Reauthenticate(ctx context.Context, userID uint, sessionID uint, password string, passkeyResponse string) (*model.Session, error)
 }
 
 type AuthControllerImpl struct {
@@ -29,6 +50,11 @@ type AuthControllerImpl struct {
 	roleRepository              repository.RoleRepository
 	sessionRepository           repository.SessionRepository
 	emailVerificationRepository repository.EmailVerificationRepository
+	invitationRepository        repository.InvitationRepository
+	passkeyController           PasskeyController
+	deviceController            DeviceController
+	accountDeletionRepository   repository.AccountDeletionRepository
+	auditRepository             repository.AuditRepository
 }
 
 func NewAuthController(
@@ -37,6 +63,11 @@ func NewAuthController(
 	roleRepository repository.RoleRepository,
 	sessionRepository repository.SessionRepository,
 	emailVerificationRepository repository.EmailVerificationRepository,
+	invitationRepository repository.InvitationRepository,
+	passkeyController PasskeyController,
+	deviceController DeviceController,
+	accountDeletionRepository repository.AccountDeletionRepository,
+	auditRepository repository.AuditRepository,
 ) *AuthControllerImpl {
 	return &AuthControllerImpl{
 		accountRepository:           accountRepository,
@@ -44,44 +75,68 @@ func NewAuthController(
 		roleRepository:              roleRepository,
 		sessionRepository:           sessionRepository,
 		emailVerificationRepository: emailVerificationRepository,
+		invitationRepository:        invitationRepository,
+		passkeyController:           passkeyController,
+		deviceController:            deviceController,
+		accountDeletionRepository:   accountDeletionRepository,
+		auditRepository:             auditRepository,
 	}
 }
 
-func (c AuthControllerImpl) Register(username string, email string, password string) (*model.LoginInfo, error) {
+// Register creates the user with the password login. The invitation code is required
+// while the registration is invite-only, the registered user is given the role of the
+// invitation.
+func (c AuthControllerImpl) Register(ctx context.Context, username string, email string, password string, invitationCode string) (info *model.LoginInfo, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.Register", attribute.Bool("register.invited", invitationCode != ""))
+	defer func() { tracer.End(span, err) }()
+
+	// Record the registration result
+	defer func() { metrics.ObserveResult(metrics.RegistrationsTotal, err) }()
+
+	if err := checkRegistrationMode(invitationCode); err != nil {
+		return nil, err
+	}
+
 	// Begin a transaction
-	tx := c.accountRepository.BeginTransaction()
+	tx := c.accountRepository.BeginTransaction(ctx)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
 	defer func() {
+		// Rollback the transaction and propagate the panic to the recovery interceptor
 		if r := recover(); r != nil {
 			tx.Rollback()
+			panic(r)
 		}
 	}()
 
 	// Generate hashed password with random salt
-	hashAlgorithm := getHashAlgorithm()
-	hash := hasher.New(hashAlgorithm)
-	passwordSalt := hasher.GenerateRandomSalt()
-	hashedPassword, err := hash.GenerateHashPassword([]byte(password), passwordSalt)
+	hashAlgorithm, hashedPassword, passwordSalt, err := hashPassword(ctx, password)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	// TODO: Later the roleID should be retrieved from the request parameter
-	// Required because the 'Register' method can be used to register user dynamically.
-	//
-	// There will be, role level check (may need to add new field or just use the id
-	// to define the role level)
-	//
-	// For example, to create user 'Admin'
+	// The invited user is given the role of the invitation, the invitation is used
+	// within the transaction so it's not used up by the failed registration
 	roleID := constant.UserRoleID
+	var invitation *model.Invitation
+	if invitationCode != "" {
+		invitation, err = c.invitationRepository.ConsumeInvitation(ctx, tx, token.HashToken(invitationCode), email)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if invitation.RoleID != nil {
+			roleID = *invitation.RoleID
+		}
+	}
 
 	// Store the user account
 	account := model.Account{RoleID: roleID}
 	if err := tx.Create(&account).Error; err != nil {
 		tx.Rollback()
-		return nil, err
+		return nil, database.HandleErrorDB(err)
 	}
 
 	// Store the user credentials with the created account
@@ -99,21 +154,50 @@ func (c AuthControllerImpl) Register(username string, email string, password str
 	}
 	if err := tx.Create(&credential).Error; err != nil {
 		tx.Rollback()
-		return nil, err
+		return nil, database.HandleErrorDB(err)
 	}
 
 	// Commit the transaction if everything is successful
 	if err := tx.Commit().Error; err != nil {
-		return nil, err
+		return nil, database.HandleErrorDB(err)
+	}
+
+	// The role other than the default one is granted by the creator of the invitation
+	if roleID != constant.UserRoleID {
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditRoleChange,
+			UserID:    &account.ID,
+			Metadata: map[string]string{
+				"role_id":       strconv.FormatUint(uint64(roleID), 10),
+				"invitation_id": strconv.FormatUint(uint64(invitation.ID), 10),
+				"granted_by":    strconv.FormatUint(uint64(invitation.CreatedBy), 10),
+			},
+		}, nil)
 	}
 
 	// Send email verification email asyncronously
-	go c.sendVerificationEmail(&credential)
+	go c.sendVerificationEmail(context.WithoutCancel(ctx), &credential)
 
 	return &credential, nil
 }
 
-func (c AuthControllerImpl) Login(isEmail bool, identifier string, password string) (*model.Session, error) {
+func (c AuthControllerImpl) Login(ctx context.Context, isEmail bool, identifier string, password string, options model.SessionOptions) (result *LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.Login", attribute.Bool("login.is_email", isEmail), attribute.Bool("login.remember_me", options.RememberMe))
+	defer func() { tracer.End(span, err) }()
+
+	// Record the login attempt with the failure reason
+	reason := metrics.ReasonInternal
+	defer func() { metrics.ObserveLogin(err == nil, reason) }()
+
+	// Record the login attempt into the audit trail, the login pending for the second
+	// factor is recorded once the passkey is asserted
+	var userID *uint
+	defer func() {
+		if err != nil || result.Session != nil {
+			recordLoginEvent(ctx, c.auditRepository, model.LoginMethodPassword, userID, err)
+		}
+	}()
+
 	// Verify user's credential
 	credential := &model.LoginInfo{}
 	if isEmail {
@@ -121,80 +205,107 @@ func (c AuthControllerImpl) Login(isEmail bool, identifier string, password stri
 	} else {
 		credential.Username = identifier
 	}
-	if err := c.loginInfoRepository.FindLoginInfo(credential); err != nil {
+	if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
 		log.WithError(err).Error("failed to find credential")
+		if errors.Is(err, apperror.ErrNotFound) {
+			// Don't reveal whether the user exists or the password is wrong
+			reason = metrics.ReasonNotFound
+			return nil, apperror.ErrCredentialsMismatch
+		}
 		return nil, err
 	}
+	userID = &credential.ID
 
 	// Validates user's password
-	hash := hasher.New(hasher.HashAlgorithm(credential.HashAlgorithm.Name))
-	salt, err := hex.DecodeString(credential.PasswordSalt)
-	if err != nil {
-		return nil, err
-	}
-	validPassword, err := hash.VerifyPassword(
-		[]byte(credential.PasswordHash),
-		[]byte(password),
-		salt,
-	)
+	validPassword, err := verifyPassword(ctx, credential, password)
 	if err != nil {
 		return nil, err
 	} else if !validPassword {
-		return nil, errors.New("password mismatched")
+		reason = metrics.ReasonInvalidPassword
+		return nil, apperror.ErrCredentialsMismatch
 	}
 
-	// Check for existing session
-	oldSession, err := c.sessionRepository.FindActiveSession(credential.ID)
+	// The suspended or deactivated account can't login, even with the right password
+	account, err := c.accountRepository.FindAccountByUserID(ctx, credential.ID)
 	if err != nil {
-		log.Error(err)
+		return nil, err
 	}
-	if oldSession != nil {
-		log.Debugf("found active session, id: %d", oldSession.ID)
-		return nil, errors.New("user already logged on")
+	if err := checkAccountStatus(&account); err != nil {
+		reason = metrics.ReasonAccountDisabled
+		return nil, err
 	}
 
-	// Generate session token
-	token, err := token.GenerateSessionToken()
+	// The registered passkey is required as the second factor
+	mfaOptions, err := c.passkeyController.BeginMFA(ctx, credential.ID, options, model.LoginMethodPassword)
 	if err != nil {
 		return nil, err
+	} else if mfaOptions != nil {
+		return &LoginResult{MFAOptions: mfaOptions}, nil
 	}
 
 	// Create new session for the user
-	session, err := c.sessionRepository.CreateSession(credential.ID, token)
+	session, err := startSession(ctx, c.sessionRepository, c.accountRepository, credential.ID, options, model.LoginMethodPassword)
 	if err != nil {
+		if errors.Is(err, apperror.ErrAlreadyLoggedOn) {
+			reason = metrics.ReasonSessionConflict
+		}
 		return nil, err
 	}
+	cancelAccountDeletion(ctx, c.accountDeletionRepository, c.auditRepository, credential.ID)
+
+	// Notify the user when the login comes from an unknown device, it doesn't fail the login
+	if err := c.deviceController.RecognizeDevice(ctx, credential); err != nil {
+		log.Errorf("error recognize login device: %v", err)
+	}
 
-	// Return user session
-	return &session, nil
+	return &LoginResult{Session: session}, nil
 }
 
-func (c AuthControllerImpl) Logout(authToken string) (bool, error) {
+func (c AuthControllerImpl) Logout(ctx context.Context, authToken string) (success bool, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.Logout")
+	defer func() { tracer.End(span, err) }()
+
+	// Find the user of the session for the audit trail, the expired session is still deleted
+	var userID *uint
+	session, findErr := c.sessionRepository.FindActiveSessionByToken(ctx, authToken)
+	if findErr == nil {
+		userID = &session.UserID
+	}
+
 	// Delete the session
-	if err := c.sessionRepository.DeleteSessionByToken(authToken); err != nil {
+	err = c.sessionRepository.DeleteSessionByToken(ctx, authToken)
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{EventType: model.AuditLogout, UserID: userID}, err)
+	if findErr == nil && session.ImpersonatorID != nil {
+		recordImpersonationStop(ctx, c.auditRepository, session, err)
+	}
+	if err != nil {
 		return false, err
 	}
+	refreshActiveSessions(ctx, c.sessionRepository)
 	return true, nil
 }
 
-func (c AuthControllerImpl) VerifyEmail(email string) (bool, error) {
+func (c AuthControllerImpl) VerifyEmail(ctx context.Context, email string) (verified bool, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.VerifyEmail")
+	defer func() { tracer.End(span, err) }()
+
 	// Find the email verification status
 	credential := &model.LoginInfo{Email: email}
-	err := c.loginInfoRepository.FindLoginInfo(credential)
+	err = c.loginInfoRepository.FindLoginInfo(ctx, credential)
 	if err != nil {
 		log.WithError(err).Error("failed to find credential")
 		return false, err
 	}
 
 	// Return email verification status
-	verified := credential.EmailVerification.Status == model.EmailVerified
+	verified = credential.EmailVerification.Status == model.EmailVerified
 
 	// Send an email verification request to the target user when not yet verified, only sent
 	// the email with a certain interval (minutes).
 	resendInterval := 15 // TODO: Move the interval into service configuration
 	if !verified && credential.EmailVerification.UpdatedAt.Add(time.Duration(resendInterval)*time.Minute).Before(time.Now()) {
 		log.Debug("Send email")
-		go c.sendVerificationEmail(credential)
+		go c.sendVerificationEmail(context.WithoutCancel(ctx), credential)
 	} else {
 		log.Debugf("Email already sent. Wait for %d minutes to resend", resendInterval)
 	}
@@ -202,6 +313,182 @@ func (c AuthControllerImpl) VerifyEmail(email string) (bool, error) {
 	return verified, nil
 }
 
+func (c AuthControllerImpl) ConfirmEmail(ctx context.Context, verificationToken string) (verified bool, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.ConfirmEmail")
+	defer func() { tracer.End(span, err) }()
+
+	// Find the email verification of the token
+	verification, err := c.emailVerificationRepository.FindEmailVerificationByToken(ctx, verificationToken)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return false, apperror.ErrInvalidVerification
+		}
+		return false, err
+	}
+
+	// The link may be opened more than once, the email already verified
+	if verification.Status == model.EmailVerified {
+		return true, nil
+	}
+	if verification.ExpiredAt.Before(time.Now()) {
+		return false, apperror.ErrExpiredVerification
+	}
+
+	// Mark the email address as verified
+	verification.Status = model.EmailVerified
+	if _, err := c.emailVerificationRepository.UpdateEmailVerification(ctx, &verification); err != nil {
+		return false, err
+	}
+
+	// Record the verification of the user owning the email address
+	credential := &model.LoginInfo{EmailVerificationID: verification.ID}
+	if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+		log.Errorf("error find credential of the email verification: %v", err)
+	} else {
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditEmailVerification,
+			UserID:    &credential.ID,
+			Metadata:  map[string]string{"email": credential.Email},
+		}, nil)
+	}
+
+	return true, nil
+}
+
+func (c AuthControllerImpl) Authenticate(ctx context.Context, authToken string) (p *principal.Principal, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.Authenticate")
+	defer func() { tracer.End(span, err) }()
+
+	// Find the unexpired session of the token
+	session, err := c.sessionRepository.FindActiveSessionByToken(ctx, authToken)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidAuthToken
+		}
+		return nil, err
+	}
+
+	// The "remember me" session is only valid along with the secret of its device
+	if session.RememberMe {
+		deviceSecretHash := token.HashToken(client.FromContext(ctx).DeviceSecret)
+		if subtle.ConstantTimeCompare([]byte(deviceSecretHash), []byte(session.DeviceSecretHash)) != 1 {
+			return nil, apperror.ErrDeviceSecretMismatch
+		}
+	}
+
+	// The session outliving the suspension of its account is no longer valid
+	if err := checkAccountStatus(&session.User); err != nil {
+		return nil, err
+	}
+
+	// Every validated use keeps the session alive
+	extendSession(ctx, c.sessionRepository, session)
+
+	p = &principal.Principal{
+		Type:            principal.TypeUser,
+		ID:              session.UserID,
+		Scopes:          constant.GetRoleScopes(session.User.RoleID),
+		SessionID:       session.ID,
+		AuthenticatedAt: session.AuthenticatedAt,
+		AuthMethods:     session.AuthMethods,
+	}
+	if session.ImpersonatorID != nil {
+		p.ImpersonatorID = *session.ImpersonatorID
+	}
+	return p, nil
+}
+
+// Reauthenticate proves the identity of the session's user again with either the
+// password or the passkey assertion, so the session is allowed to perform the
+// sensitive operations requiring the recent authentication
+func (c AuthControllerImpl) Reauthenticate(ctx context.Context, userID uint, sessionID uint, password string, passkeyResponse string) (session *model.Session, err error) {
+	ctx, span := tracer.Start(ctx, "AuthController.Reauthenticate", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	method := model.LoginMethodPassword
+	if passkeyResponse != "" {
+		method = model.LoginMethodPasskey
+	}
+	span.SetAttributes(attribute.String("reauth.method", method))
+
+	// Record the reauthentication attempt into the audit trail
+	defer func() {
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditReauthentication,
+			UserID:    &userID,
+			Metadata:  map[string]string{"method": method},
+		}, err)
+	}()
+
+	session, err = c.sessionRepository.FindUserSession(ctx, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	// Verify either the passkey assertion or the user's password
+	if method == model.LoginMethodPasskey {
+		if err := c.passkeyController.VerifyReauthentication(ctx, userID, passkeyResponse); err != nil {
+			return nil, err
+		}
+	} else {
+		credential := &model.LoginInfo{ID: userID}
+		if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				// The user without password reauthenticates with the passkey
+				return nil, apperror.ErrCredentialsMismatch
+			}
+			return nil, err
+		}
+		validPassword, err := verifyPassword(ctx, credential, password)
+		if err != nil {
+			return nil, err
+		} else if !validPassword {
+			return nil, apperror.ErrCredentialsMismatch
+		}
+	}
+
+	session.Reauthenticate(time.Now(), method)
+	if err := c.sessionRepository.UpdateSessionAuthentication(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// verifyPassword verifies the password against the hashed password of the credential
+func verifyPassword(ctx context.Context, credential *model.LoginInfo, password string) (bool, error) {
+	hash := hasher.New(hasher.HashAlgorithm(credential.HashAlgorithm.Name))
+	salt, err := hex.DecodeString(credential.PasswordSalt)
+	if err != nil {
+		return false, err
+	}
+	_, hashSpan := tracer.Start(ctx, "hasher.VerifyPassword", attribute.String("hash.algorithm", credential.HashAlgorithm.Name))
+	validPassword, err := withContext(ctx, func() (bool, error) {
+		return hash.VerifyPassword(
+			[]byte(credential.PasswordHash),
+			[]byte(password),
+			salt,
+		)
+	})
+	tracer.End(hashSpan, err)
+	return validPassword, err
+}
+
+// hashPassword hashes the password with the configured algorithm and a random salt
+func hashPassword(ctx context.Context, password string) (hasher.HashAlgorithm, []byte, []byte, error) {
+	hashAlgorithm := getHashAlgorithm()
+	hash := hasher.New(hashAlgorithm)
+	passwordSalt := hasher.GenerateRandomSalt()
+	_, hashSpan := tracer.Start(ctx, "hasher.GenerateHashPassword", attribute.String("hash.algorithm", string(hashAlgorithm)))
+	hashedPassword, err := withContext(ctx, func() ([]byte, error) {
+		return hash.GenerateHashPassword([]byte(password), passwordSalt)
+	})
+	tracer.End(hashSpan, err)
+	return hashAlgorithm, hashedPassword, passwordSalt, err
+}
+
 func getHashAlgorithm() hasher.HashAlgorithm {
 	// Use 'bcrypt' as the default hashing algorithm
 	algorithm := hasher.BCrypt
@@ -214,19 +501,31 @@ func getHashAlgorithm() hasher.HashAlgorithm {
 	return algorithm
 }
 
-func (c *AuthControllerImpl) sendVerificationEmail(credential *model.LoginInfo) {
-	if err := mailer.SendEmailVerification(
+func (c *AuthControllerImpl) sendVerificationEmail(ctx context.Context, credential *model.LoginInfo) {
+	sendVerificationEmail(ctx, c.emailVerificationRepository, credential)
+}
+
+// sendVerificationEmail sends the verification email and updates the verification status
+// to 'sent', or to 'error' so the delivery is retried by the maintenance scheduler
+func sendVerificationEmail(ctx context.Context, emailVerificationRepository repository.EmailVerificationRepository, credential *model.LoginInfo) error {
+	err := mailer.SendEmailVerification(
+		ctx,
 		credential.Email,
 		credential.Username,
 		credential.EmailVerification.Token,
 		credential.EmailVerification.ExpiredAt,
-	); err != nil {
-		// Log error
+	)
+	metrics.ObserveResult(metrics.VerificationEmailsTotal, err)
+
+	// Update the email verification status according to the delivery
+	credential.EmailVerification.ID = credential.EmailVerificationID
+	credential.EmailVerification.Status = model.VerificationSent
+	if err != nil {
 		log.Errorf("error sending verification email: %v", err)
-	} else {
-		// Update the email verification status to 'sent'
-		credential.EmailVerification.ID = credential.EmailVerificationID
-		credential.EmailVerification.Status = model.VerificationSent
-		c.emailVerificationRepository.UpdateEmailVerification(&credential.EmailVerification)
+		credential.EmailVerification.Status = model.VerificationError
+	}
+	if _, updateErr := emailVerificationRepository.UpdateEmailVerification(ctx, &credential.EmailVerification); updateErr != nil {
+		log.Errorf("error update email verification status: %v", updateErr)
 	}
+	return err
 }
@@ -0,0 +1,32 @@
+package controller
+
+import "context"
+
+// withContext runs the blocking function (e.g. password hashing) and returns as soon
+// as the context is done, so the caller doesn't wait for the result when the client
+// already gave up. The function keeps running in the background until it finishes.
+func withContext[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	// Don't start the function when the context is already done
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-done:
+		return r.value, r.err
+	}
+}
@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/dataexport"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultDataExportTTL is the validity of the download token of the data export
+	DefaultDataExportTTL = 24 * time.Hour
+
+	// DataExportBatchSize limits the exports generated by a single run
+	DataExportBatchSize = 10
+
+	// auditEventsPageSize is the page size of reading the user's audit trail
+	auditEventsPageSize = 500
+)
+
+// Actions of the data export recorded in the audit trail
+const (
+	exportRequested  = "requested"
+	exportGenerated  = "generated"
+	exportDownloaded = "downloaded"
+)
+
+type DataExportController interface {
+	RequestDataExport(ctx context.Context, userID uint, format string) (*model.DataExport, error)
+	GenerateDataExports(ctx context.Context) (int64, error)
+	DownloadDataExport(ctx context.Context, downloadToken string) (*model.DataExport, error)
+}
+
+type DataExportControllerImpl struct {
+	dataExportRepository    repository.DataExportRepository
+	accountRepository       repository.AccountRepository
+	loginInfoRepository     repository.LoginInfoRepository
+	sessionRepository       repository.SessionRepository
+	externalLoginRepository repository.ExternalLoginRepository
+	webAuthnRepository      repository.WebAuthnRepository
+	auditRepository         repository.AuditRepository
+}
+
+func NewDataExportController(
+	dataExportRepository repository.DataExportRepository,
+	accountRepository repository.AccountRepository,
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	externalLoginRepository repository.ExternalLoginRepository,
+	webAuthnRepository repository.WebAuthnRepository,
+	auditRepository repository.AuditRepository,
+) *DataExportControllerImpl {
+	return &DataExportControllerImpl{
+		dataExportRepository:    dataExportRepository,
+		accountRepository:       accountRepository,
+		loginInfoRepository:     loginInfoRepository,
+		sessionRepository:       sessionRepository,
+		externalLoginRepository: externalLoginRepository,
+		webAuthnRepository:      webAuthnRepository,
+		auditRepository:         auditRepository,
+	}
+}
+
+// RequestDataExport requests the export of the user's personal data, the export is
+// generated in the background and the download link is emailed once it's ready. The
+// pending export of the user is returned instead of requesting another one.
+func (c DataExportControllerImpl) RequestDataExport(ctx context.Context, userID uint, format string) (export *model.DataExport, err error) {
+	ctx, span := tracer.Start(ctx, "DataExportController.RequestDataExport",
+		attribute.Int("user.id", int(userID)), attribute.String("export.format", format))
+	defer func() { tracer.End(span, err) }()
+
+	if !dataexport.IsFormatSupported(format) {
+		return nil, apperror.ErrUnsupportedExportFormat
+	}
+
+	export, err = c.dataExportRepository.FindPendingDataExport(ctx, userID)
+	if err == nil {
+		return export, nil
+	} else if !errors.Is(err, apperror.ErrNotFound) {
+		return nil, err
+	}
+
+	export = &model.DataExport{UserID: userID, Format: format, Status: model.DataExportPending}
+	err = c.dataExportRepository.CreateDataExport(ctx, export)
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+		EventType: model.AuditDataExport,
+		UserID:    &userID,
+		Metadata:  map[string]string{"action": exportRequested, "format": format},
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// GenerateDataExports generates the pending exports and emails their download link, the
+// export failing to be generated is marked as failed so the user requests another one.
+// It returns the number of the generated exports.
+func (c DataExportControllerImpl) GenerateDataExports(ctx context.Context) (generated int64, err error) {
+	ctx, span := tracer.Start(ctx, "DataExportController.GenerateDataExports")
+	defer func() { tracer.End(span, err) }()
+
+	exports, err := c.dataExportRepository.FindPendingDataExports(ctx, DataExportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range exports {
+		if err := ctx.Err(); err != nil {
+			return generated, err
+		}
+
+		export := &exports[i]
+		err := c.generateDataExport(ctx, export)
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditDataExport,
+			UserID:    &export.UserID,
+			Metadata:  map[string]string{"action": exportGenerated, "format": export.Format},
+		}, err)
+		if err != nil {
+			log.WithField("data_export_id", export.ID).Errorf("error generate data export: %v", err)
+			if err := c.dataExportRepository.UpdateDataExportStatus(ctx, export.ID, model.DataExportFailed); err != nil {
+				return generated, err
+			}
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+// DownloadDataExport get the ready export of the download token, the token can be used
+// more than once until it expires
+func (c DataExportControllerImpl) DownloadDataExport(ctx context.Context, downloadToken string) (export *model.DataExport, err error) {
+	ctx, span := tracer.Start(ctx, "DataExportController.DownloadDataExport")
+	defer func() { tracer.End(span, err) }()
+
+	export, err = c.dataExportRepository.FindDataExportByToken(ctx, token.HashToken(downloadToken))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidDataExport
+		}
+		return nil, err
+	}
+
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+		EventType: model.AuditDataExport,
+		UserID:    &export.UserID,
+		Metadata:  map[string]string{"action": exportDownloaded, "format": export.Format},
+	}, nil)
+	return export, nil
+}
+
+// generateDataExport builds the document of the user's personal data and stores it as
+// the file of the export, then emails the download link to the user
+func (c DataExportControllerImpl) generateDataExport(ctx context.Context, export *model.DataExport) error {
+	document, recipient, err := c.buildDocument(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil {
+		return fmt.Errorf("user %d has no email address to send the export to", export.UserID)
+	}
+
+	content, fileName, contentType, err := dataexport.Encode(document, export.Format)
+	if err != nil {
+		return err
+	}
+	downloadToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+	ttl := env.GetDurationOrDefault("DATA_EXPORT_TTL", DefaultDataExportTTL)
+	tokenHash, expiredAt := token.HashToken(downloadToken), time.Now().Add(ttl)
+
+	export.Status = model.DataExportReady
+	export.FileName, export.ContentType, export.Content = fileName, contentType, content
+	export.TokenHash, export.ExpiredAt = &tokenHash, &expiredAt
+	if err := c.dataExportRepository.CompleteDataExport(ctx, export); err != nil {
+		return err
+	}
+
+	return mailer.SendDataExportReady(ctx, recipient.Email, recipient.Name, downloadToken, ttl)
+}
+
+// exportRecipient is the email address the download link of the export is sent to
+type exportRecipient struct {
+	Email string
+	Name  string
+}
+
+// buildDocument gathers the personal data of the user, the email address of either the
+// password login or the first linked external account receives the export
+func (c DataExportControllerImpl) buildDocument(ctx context.Context, userID uint) (*dataexport.Document, *exportRecipient, error) {
+	account, err := c.accountRepository.FindAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	document := &dataexport.Document{
+		SchemaVersion: dataexport.SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Account: dataexport.Account{
+			UserID:              account.ID,
+			UserName:            account.UserName,
+			Gender:              account.Gender,
+			DateOfBirth:         account.DateOfBirth,
+			RoleID:              account.RoleID,
+			CreatedAt:           account.CreatedAt,
+			DeletionScheduledAt: account.DeletionScheduledAt,
+		},
+		Sessions:         []dataexport.Session{},
+		ExternalAccounts: []dataexport.ExternalAccount{},
+		Passkeys:         []dataexport.Passkey{},
+		AuditEvents:      []dataexport.AuditEvent{},
+	}
+
+	var recipient *exportRecipient
+	credential := &model.LoginInfo{ID: userID}
+	err = c.loginInfoRepository.FindLoginInfo(ctx, credential)
+	switch {
+	case err == nil:
+		document.Login = &dataexport.Login{
+			Username:          credential.Username,
+			Email:             credential.Email,
+			EmailVerification: credential.EmailVerification.Status,
+			CreatedAt:         credential.CreatedAt,
+			UpdatedAt:         credential.UpdatedAt,
+		}
+		if credential.EmailVerification.Status == model.EmailVerified {
+			document.Login.EmailVerifiedAt = &credential.EmailVerification.UpdatedAt
+		}
+		recipient = &exportRecipient{Email: credential.Email, Name: credential.Username}
+	case !errors.Is(err, apperror.ErrNotFound):
+		return nil, nil, err
+	}
+
+	sessions, err := c.sessionRepository.FindActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, session := range sessions {
+		document.Sessions = append(document.Sessions, dataexport.Session{
+			DeviceName:      session.DeviceName,
+			UserAgent:       session.UserAgent,
+			IPAddress:       session.IPAddress,
+			RememberMe:      session.RememberMe,
+			AuthMethods:     session.AuthMethods,
+			AuthenticatedAt: session.AuthenticatedAt,
+			LastSeenAt:      session.LastSeenAt,
+			ExpiresAt:       session.ExpiredAt,
+			CreatedAt:       session.CreatedAt,
+		})
+	}
+
+	logins, err := c.externalLoginRepository.FindLoginExternalsByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, login := range logins {
+		document.ExternalAccounts = append(document.ExternalAccounts, dataexport.ExternalAccount{
+			Provider: login.Provider.Name,
+			Subject:  login.Subject,
+			Email:    login.Email,
+			LinkedAt: login.CreatedAt,
+		})
+		if recipient == nil && login.Email != "" {
+			recipient = &exportRecipient{Email: login.Email, Name: login.Email}
+		}
+	}
+
+	credentials, err := c.webAuthnRepository.FindCredentials(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, credential := range credentials {
+		document.Passkeys = append(document.Passkeys, dataexport.Passkey{
+			Name:       credential.Name,
+			CreatedAt:  credential.CreatedAt,
+			LastUsedAt: credential.LastUsedAt,
+		})
+	}
+
+	// Read the whole audit trail of the user page by page
+	filter := repository.AuditEventFilter{UserID: &userID, Limit: auditEventsPageSize}
+	for {
+		events, err := c.auditRepository.FindAuditEvents(ctx, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, event := range events {
+			document.AuditEvents = append(document.AuditEvents, dataexport.AuditEvent{
+				EventType: event.EventType,
+				Outcome:   event.Outcome,
+				Reason:    event.Reason,
+				ActorType: event.ActorType,
+				IPAddress: event.IPAddress,
+				UserAgent: event.UserAgent,
+				Metadata:  event.Metadata,
+				CreatedAt: event.CreatedAt,
+			})
+		}
+		if len(events) < filter.Limit {
+			break
+		}
+		filter.AfterID = events[len(events)-1].ID
+	}
+
+	return document, recipient, nil
+}
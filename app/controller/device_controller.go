@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/device"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultUnrecognizedLoginTTL is the validity of the "this wasn't me" link of the
+// new device notification
+const DefaultUnrecognizedLoginTTL = 7 * 24 * time.Hour
+
+type DeviceController interface {
+	RecognizeDevice(ctx context.Context, credential *model.LoginInfo) error
+	ReportUnrecognizedLogin(ctx context.Context, reportToken string) error
+}
+
+type DeviceControllerImpl struct {
+	knownDeviceRepository      repository.KnownDeviceRepository
+	loginInfoRepository        repository.LoginInfoRepository
+	sessionRepository          repository.SessionRepository
+	auditRepository            repository.AuditRepository
+	passwordRecoveryController PasswordRecoveryController
+}
+
+func NewDeviceController(
+	knownDeviceRepository repository.KnownDeviceRepository,
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+	passwordRecoveryController PasswordRecoveryController,
+) *DeviceControllerImpl {
+	return &DeviceControllerImpl{
+		knownDeviceRepository:      knownDeviceRepository,
+		loginInfoRepository:        loginInfoRepository,
+		sessionRepository:          sessionRepository,
+		auditRepository:            auditRepository,
+		passwordRecoveryController: passwordRecoveryController,
+	}
+}
+
+// RecognizeDevice fingerprints the client of the login and adds it to the user's known
+// devices. The user is notified when the device is new, except on the first recorded
+// device since there is no other device the login could be compared with.
+func (c DeviceControllerImpl) RecognizeDevice(ctx context.Context, credential *model.LoginInfo) (err error) {
+	ctx, span := tracer.Start(ctx, "DeviceController.RecognizeDevice")
+	defer func() { tracer.End(span, err) }()
+
+	info := client.FromContext(ctx)
+	fingerprint := device.Fingerprint(info.UserAgent, info.IP)
+	now := time.Now()
+
+	known, err := c.knownDeviceRepository.FindKnownDevice(ctx, credential.ID, fingerprint)
+	if err == nil {
+		return c.knownDeviceRepository.UpdateKnownDeviceLastSeen(ctx, known.ID, now)
+	} else if !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+
+	count, err := c.knownDeviceRepository.CountKnownDevices(ctx, credential.ID)
+	if err != nil {
+		return err
+	}
+
+	reportToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+	ttl := env.GetDurationOrDefault("UNRECOGNIZED_LOGIN_TTL", DefaultUnrecognizedLoginTTL)
+	newDevice, err := c.knownDeviceRepository.CreateKnownDevice(ctx, &model.KnownDevice{
+		UserID:          credential.ID,
+		Fingerprint:     fingerprint,
+		UserAgent:       truncate(info.UserAgent, 250),
+		IPPrefix:        device.IPPrefix(info.IP),
+		LastSeenAt:      now,
+		ReportTokenHash: token.HashToken(reportToken),
+		ReportExpiredAt: now.Add(ttl),
+	})
+	if err != nil {
+		// The concurrent login from the same device already added it
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return nil
+		}
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	// Send the new device notification asyncronously
+	go func(ctx context.Context) {
+		if err := mailer.SendNewDeviceNotification(ctx, credential.Email, credential.Username, now,
+			newDevice.UserAgent, newDevice.IPPrefix, reportToken, ttl); err != nil {
+			log.Errorf("error sending new device notification email: %v", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// ReportUnrecognizedLogin handles the "this wasn't me" link of the new device notification,
+// every session of the user is revoked and the password reset is started since the
+// password may have been compromised
+func (c DeviceControllerImpl) ReportUnrecognizedLogin(ctx context.Context, reportToken string) (err error) {
+	ctx, span := tracer.Start(ctx, "DeviceController.ReportUnrecognizedLogin")
+	defer func() { tracer.End(span, err) }()
+
+	reported, err := c.knownDeviceRepository.ConsumeDeviceReport(ctx, token.HashToken(reportToken))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.ErrInvalidDeviceReport
+		}
+		return err
+	}
+	log.WithField("user_id", reported.UserID).Warn("login from unrecognized device reported")
+
+	if _, err := revokeUserSessions(ctx, c.sessionRepository, c.auditRepository, reported.UserID, "unrecognized_login"); err != nil {
+		return err
+	}
+
+	credential := &model.LoginInfo{ID: reported.UserID}
+	if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+		return err
+	}
+	return c.passwordRecoveryController.StartPasswordRecovery(ctx, credential)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/events"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// EventPublishBatchSize limits the events published by a single run
+const EventPublishBatchSize = 100
+
+// EventController publishes the events of the outbox to the other services
+type EventController interface {
+	PublishPendingEvents(ctx context.Context) (int64, error)
+}
+
+type EventControllerImpl struct {
+	eventRepository repository.EventRepository
+	publisher       events.Publisher
+}
+
+func NewEventController(eventRepository repository.EventRepository, publisher events.Publisher) *EventControllerImpl {
+	return &EventControllerImpl{eventRepository: eventRepository, publisher: publisher}
+}
+
+// PublishPendingEvents publishes the unpublished events in the order they happened,
+// it stops at the first failure so the order is kept and the event is retried on the
+// next run. The events are delivered at least once, they're kept pending while no
+// publisher is configured. It returns the number of the published events.
+func (c EventControllerImpl) PublishPendingEvents(ctx context.Context) (published int64, err error) {
+	ctx, span := tracer.Start(ctx, "EventController.PublishPendingEvents")
+	defer func() { tracer.End(span, err) }()
+
+	if c.publisher == nil {
+		return 0, nil
+	}
+
+	pending, err := c.eventRepository.FindPendingEvents(ctx, EventPublishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range pending {
+		if err := c.publisher.Publish(ctx, events.Event{
+			ID:         event.EventKey,
+			Type:       event.EventType,
+			OccurredAt: event.CreatedAt,
+			Data:       event.Payload,
+		}); err != nil {
+			log.WithField("event_id", event.EventKey).Warnf("error publish event: %v", err)
+			if err := c.eventRepository.MarkEventFailed(ctx, event.ID, truncate(err.Error(), 250)); err != nil {
+				return published, err
+			}
+			return published, err
+		}
+
+		if err := c.eventRepository.MarkEventPublished(ctx, event.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
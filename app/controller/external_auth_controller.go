@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/identity"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type ExternalAuthController interface {
+	StartExternalLogin(ctx context.Context, providerName string, linkUserID *uint) (*identity.Authorization, error)
+	CompleteExternalLogin(ctx context.Context, providerName string, code string, state string) (*LoginResult, error)
+	ListExternalAccounts(ctx context.Context, userID uint) ([]model.LoginExternal, error)
+	UnlinkExternalAccount(ctx context.Context, userID uint, providerName string) error
+}
+
+type ExternalAuthControllerImpl struct {
+	registry                  *identity.Registry
+	externalLoginRepository   repository.ExternalLoginRepository
+	loginInfoRepository       repository.LoginInfoRepository
+	sessionRepository         repository.SessionRepository
+	accountRepository         repository.AccountRepository
+	accountDeletionRepository repository.AccountDeletionRepository
+	auditRepository           repository.AuditRepository
+	passkeyController         PasskeyController
+	deviceController          DeviceController
+}
+
+func NewExternalAuthController(
+	registry *identity.Registry,
+	externalLoginRepository repository.ExternalLoginRepository,
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	accountRepository repository.AccountRepository,
+	accountDeletionRepository repository.AccountDeletionRepository,
+	auditRepository repository.AuditRepository,
+	passkeyController PasskeyController,
+	deviceController DeviceController,
+) *ExternalAuthControllerImpl {
+	return &ExternalAuthControllerImpl{
+		registry:                  registry,
+		externalLoginRepository:   externalLoginRepository,
+		loginInfoRepository:       loginInfoRepository,
+		sessionRepository:         sessionRepository,
+		accountRepository:         accountRepository,
+		accountDeletionRepository: accountDeletionRepository,
+		auditRepository:           auditRepository,
+		passkeyController:         passkeyController,
+		deviceController:          deviceController,
+	}
+}
+
+func (c ExternalAuthControllerImpl) StartExternalLogin(ctx context.Context, providerName string, linkUserID *uint) (authorization *identity.Authorization, err error) {
+	ctx, span := tracer.Start(ctx, "ExternalAuthController.StartExternalLogin", attribute.String("provider.name", providerName))
+	defer func() { tracer.End(span, err) }()
+
+	provider, ok := c.registry.Provider(providerName)
+	if !ok {
+		return nil, apperror.ErrExternalProviderNotFound
+	}
+
+	// Create the authorization request with PKCE
+	authorization, err = provider.Authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the verifier and nonce until the provider redirects back with the code
+	if err := c.externalLoginRepository.CreateLoginState(ctx, &model.ExternalLoginState{
+		State:        authorization.State,
+		ProviderName: providerName,
+		CodeVerifier: authorization.Verifier,
+		Nonce:        authorization.Nonce,
+		UserID:       linkUserID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return authorization, nil
+}
+
+// CompleteExternalLogin verifies the identity returned by the provider, then either
+// links the external account or logs the user in the same way as the password login,
+// including the passkey of the second factor. No result is returned for the link.
+func (c ExternalAuthControllerImpl) CompleteExternalLogin(ctx context.Context, providerName string, code string, state string) (result *LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "ExternalAuthController.CompleteExternalLogin", attribute.String("provider.name", providerName))
+	defer func() { tracer.End(span, err) }()
+
+	provider, ok := c.registry.Provider(providerName)
+	if !ok {
+		return nil, apperror.ErrExternalProviderNotFound
+	}
+
+	// The state should be issued for the same provider, it can only be used once
+	loginState, err := c.externalLoginRepository.ConsumeLoginState(ctx, state)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidExternalState
+		}
+		return nil, err
+	}
+	if loginState.ProviderName != providerName {
+		return nil, apperror.ErrInvalidExternalState
+	}
+
+	// Redeem the code and verify the ID token of the provider
+	externalIdentity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.WithError(err).Warnf("external authentication failed with provider '%s'", providerName)
+		return nil, apperror.ErrExternalAuthFailed.WithCause(err)
+	}
+
+	storedProvider, err := c.externalLoginRepository.FindOrCreateProvider(ctx, providerName, provider.Issuer())
+	if err != nil {
+		return nil, err
+	}
+
+	// Link the external account into the user who started the authorization
+	if loginState.UserID != nil {
+		return nil, c.linkExternalAccount(ctx, *loginState.UserID, storedProvider.ID, externalIdentity)
+	}
+
+	// Login the user linked with the external account
+	login, err := c.externalLoginRepository.FindLoginExternal(ctx, storedProvider.ID, externalIdentity.Subject)
+	if errors.Is(err, apperror.ErrNotFound) {
+		login, err = c.registerExternalUser(ctx, storedProvider.ID, externalIdentity)
+	}
+	if err != nil {
+		recordLoginEvent(ctx, c.auditRepository, model.LoginMethodExternal, nil, err)
+		return nil, err
+	}
+
+	// The login pending for the second factor is recorded once the passkey is asserted
+	result, err = completeFirstFactor(ctx, c.passkeyController, c.deviceController, c.loginInfoRepository, c.sessionRepository, c.accountRepository, login.UserID, model.SessionOptions{}, model.LoginMethodExternal)
+	if err != nil {
+		recordLoginEvent(ctx, c.auditRepository, model.LoginMethodExternal, &login.UserID, err)
+		return nil, err
+	}
+	if result.Session != nil {
+		recordLoginEvent(ctx, c.auditRepository, model.LoginMethodExternal, &login.UserID, nil)
+		cancelAccountDeletion(ctx, c.accountDeletionRepository, c.auditRepository, login.UserID)
+	}
+	return result, nil
+}
+
+func (c ExternalAuthControllerImpl) ListExternalAccounts(ctx context.Context, userID uint) (logins []model.LoginExternal, err error) {
+	ctx, span := tracer.Start(ctx, "ExternalAuthController.ListExternalAccounts", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.externalLoginRepository.FindLoginExternalsByUser(ctx, userID)
+}
+
+func (c ExternalAuthControllerImpl) UnlinkExternalAccount(ctx context.Context, userID uint, providerName string) (err error) {
+	ctx, span := tracer.Start(ctx, "ExternalAuthController.UnlinkExternalAccount", attribute.String("provider.name", providerName))
+	defer func() { tracer.End(span, err) }()
+
+	logins, err := c.externalLoginRepository.FindLoginExternalsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var providerID uint
+	for _, login := range logins {
+		if login.Provider.Name == providerName {
+			providerID = login.ProviderID
+		}
+	}
+	if providerID == 0 {
+		return apperror.ErrExternalAccountNotFound
+	}
+
+	// The user without password should keep at least one external account to login
+	if len(logins) == 1 {
+		err := c.loginInfoRepository.FindLoginInfo(ctx, &model.LoginInfo{ID: userID})
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.ErrLastLoginMethod
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return c.externalLoginRepository.DeleteLoginExternal(ctx, userID, providerID)
+}
+
+// linkExternalAccount links the external account into the user, the external account
+// can only be linked into one user
+func (c ExternalAuthControllerImpl) linkExternalAccount(ctx context.Context, userID uint, providerID uint, externalIdentity *identity.Identity) error {
+	existing, err := c.externalLoginRepository.FindLoginExternal(ctx, providerID, externalIdentity.Subject)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		return apperror.ErrExternalAccountLinked
+	} else if !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+
+	_, err = c.externalLoginRepository.CreateLoginExternal(ctx, &model.LoginExternal{
+		UserID:     userID,
+		ProviderID: providerID,
+		Subject:    externalIdentity.Subject,
+		Email:      externalIdentity.Email,
+	})
+	if errors.Is(err, apperror.ErrAlreadyExists) {
+		// The user already has another account of the same provider linked
+		return apperror.ErrExternalAccountLinked
+	}
+	return err
+}
+
+// registerExternalUser creates the new user of the external account. The account is
+// never linked automatically by the email, because the email ownership on the provider
+// doesn't prove the ownership of the existing account.
+func (c ExternalAuthControllerImpl) registerExternalUser(ctx context.Context, providerID uint, externalIdentity *identity.Identity) (model.LoginExternal, error) {
+	// The external login carries no invitation code, so it only registers while the
+	// registration is open
+	if err := checkRegistrationMode(""); err != nil {
+		return model.LoginExternal{}, err
+	}
+
+	if externalIdentity.Email != "" {
+		err := c.loginInfoRepository.FindLoginInfo(ctx, &model.LoginInfo{Email: externalIdentity.Email})
+		if err == nil {
+			return model.LoginExternal{}, apperror.ErrExternalAccountNotLinked
+		} else if !errors.Is(err, apperror.ErrNotFound) {
+			return model.LoginExternal{}, err
+		}
+	}
+
+	account := &model.Account{RoleID: constant.UserRoleID}
+	if externalIdentity.Name != "" {
+		account.UserName = &externalIdentity.Name
+	}
+	return c.externalLoginRepository.CreateExternalUser(ctx, account, &model.LoginExternal{
+		ProviderID: providerID,
+		Subject:    externalIdentity.Subject,
+		Email:      externalIdentity.Email,
+	})
+}
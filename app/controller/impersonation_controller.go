@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultImpersonationTTL is the absolute lifetime of the impersonation session
+const DefaultImpersonationTTL = 30 * time.Minute
+
+// Actions of the impersonation recorded in the audit trail
+const (
+	impersonationStarted = "started"
+	impersonationStopped = "stopped"
+)
+
+type ImpersonationController interface {
+	StartImpersonation(ctx context.Context, impersonatorID uint, userID uint, reason string) (*model.Session, error)
+	StopImpersonation(ctx context.Context, userID uint, sessionID uint) error
+}
+
+type ImpersonationControllerImpl struct {
+	accountRepository repository.AccountRepository
+	sessionRepository repository.SessionRepository
+	auditRepository   repository.AuditRepository
+}
+
+func NewImpersonationController(
+	accountRepository repository.AccountRepository,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+) *ImpersonationControllerImpl {
+	return &ImpersonationControllerImpl{
+		accountRepository: accountRepository,
+		sessionRepository: sessionRepository,
+		auditRepository:   auditRepository,
+	}
+}
+
+// StartImpersonation issues the short-lived session of the user to the admin, the
+// session is flagged with the admin so the sensitive actions are blocked and every
+// action is audited as the admin's. The admins can't be impersonated.
+func (c ImpersonationControllerImpl) StartImpersonation(ctx context.Context, impersonatorID uint, userID uint, reason string) (session *model.Session, err error) {
+	ctx, span := tracer.Start(ctx, "ImpersonationController.StartImpersonation", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	reason = truncate(reason, 250)
+	defer func() {
+		metadata := map[string]string{"action": impersonationStarted, "reason": reason}
+		if session != nil {
+			metadata["session_id"] = strconv.FormatUint(uint64(session.ID), 10)
+		}
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditImpersonation,
+			UserID:    &userID,
+			Metadata:  metadata,
+		}, err)
+	}()
+
+	if impersonatorID == userID {
+		return nil, apperror.ErrImpersonationNotAllowed
+	}
+
+	// The impersonated account should exist and be allowed to login
+	account, err := c.accountRepository.FindAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CreatedAt.IsZero() {
+		return nil, apperror.ErrUserNotFound
+	}
+	if account.RoleID == constant.AdminRoleID {
+		return nil, apperror.ErrImpersonationNotAllowed
+	}
+	if err := checkAccountStatus(&account); err != nil {
+		return nil, err
+	}
+
+	sessionToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// The session doesn't conflict with the user's own session and isn't extended
+	// beyond its lifetime
+	info := client.FromContext(ctx)
+	now := time.Now()
+	expiredAt := now.Add(env.GetDurationOrDefault("IMPERSONATION_TTL", DefaultImpersonationTTL))
+	newSession, err := c.sessionRepository.CreateSession(ctx, &model.Session{
+		UserID:          userID,
+		Token:           sessionToken,
+		ExpiredAt:       expiredAt,
+		MaxExpiredAt:    expiredAt,
+		LastSeenAt:      now,
+		AuthenticatedAt: now,
+		AuthMethods:     []string{model.LoginMethodImpersonation},
+		SessionOptions:  model.SessionOptions{DeviceName: "impersonation"},
+		UserAgent:       truncate(info.UserAgent, 250),
+		IPAddress:       info.IP,
+		ImpersonatorID:  &impersonatorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshActiveSessions(ctx, c.sessionRepository)
+
+	return &newSession, nil
+}
+
+// StopImpersonation ends the impersonation session of the caller
+func (c ImpersonationControllerImpl) StopImpersonation(ctx context.Context, userID uint, sessionID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "ImpersonationController.StopImpersonation", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	session, err := c.sessionRepository.FindUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.ImpersonatorID == nil {
+		return apperror.ErrNotImpersonating
+	}
+
+	err = c.sessionRepository.DeleteSessionByToken(ctx, session.Token)
+	recordImpersonationStop(ctx, c.auditRepository, session, err)
+	if err != nil {
+		return err
+	}
+	refreshActiveSessions(ctx, c.sessionRepository)
+	return nil
+}
+
+// recordImpersonationStop records the end of the impersonation session into the audit trail
+func recordImpersonationStop(ctx context.Context, auditRepository repository.AuditRepository, session *model.Session, err error) {
+	recordAuditEvent(ctx, auditRepository, model.AuditEvent{
+		EventType: model.AuditImpersonation,
+		UserID:    &session.UserID,
+		Metadata: map[string]string{
+			"action":     impersonationStopped,
+			"session_id": strconv.FormatUint(uint64(session.ID), 10),
+		},
+	}, err)
+}
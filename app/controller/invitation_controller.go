@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Registration modes chosen by the REGISTRATION_MODE, the invite-only registration
+// requires the invitation code and the closed registration doesn't allow new users
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite_only"
+	RegistrationClosed     = "closed"
+)
+
+// DefaultInvitationTTL is the validity of the invitation without its own validity
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// Actions of the invitation recorded in the audit trail
+const (
+	invitationCreated = "created"
+	invitationRevoked = "revoked"
+)
+
+type InvitationController interface {
+	CreateInvitation(ctx context.Context, creator *principal.Principal, email string, roleID uint, maxUses int, ttl time.Duration) (*model.Invitation, string, error)
+	ListInvitations(ctx context.Context) ([]model.Invitation, error)
+	RevokeInvitation(ctx context.Context, invitationID uint) error
+}
+
+type InvitationControllerImpl struct {
+	invitationRepository repository.InvitationRepository
+	roleRepository       repository.RoleRepository
+	auditRepository      repository.AuditRepository
+}
+
+func NewInvitationController(
+	invitationRepository repository.InvitationRepository,
+	roleRepository repository.RoleRepository,
+	auditRepository repository.AuditRepository,
+) *InvitationControllerImpl {
+	return &InvitationControllerImpl{
+		invitationRepository: invitationRepository,
+		roleRepository:       roleRepository,
+		auditRepository:      auditRepository,
+	}
+}
+
+// CreateInvitation creates the invitation and returns its plain code, the code is only
+// returned once. The invitation of an email is sent to the email. Only the admins may
+// pre-assign a role other than the user role, the role should exist.
+func (c InvitationControllerImpl) CreateInvitation(
+	ctx context.Context,
+	creator *principal.Principal,
+	email string,
+	roleID uint,
+	maxUses int,
+	ttl time.Duration,
+) (invitation *model.Invitation, code string, err error) {
+	ctx, span := tracer.Start(ctx, "InvitationController.CreateInvitation", attribute.Int("invitation.role_id", int(roleID)))
+	defer func() { tracer.End(span, err) }()
+
+	if roleID != 0 && roleID != constant.UserRoleID && !creator.HasScope(constant.ScopeAll) {
+		return nil, "", apperror.ErrRoleNotInvitable
+	}
+	if roleID != 0 {
+		if _, err := c.roleRepository.FindRoleByID(ctx, roleID); errors.Is(err, apperror.ErrNotFound) {
+			return nil, "", apperror.ErrRoleNotFound
+		} else if err != nil {
+			return nil, "", err
+		}
+	}
+
+	code, err = token.GenerateSessionToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if ttl <= 0 {
+		ttl = env.GetDurationOrDefault("INVITATION_TTL", DefaultInvitationTTL)
+	}
+	invitation = &model.Invitation{
+		CodeHash:  token.HashToken(code),
+		Email:     email,
+		MaxUses:   max(maxUses, 1),
+		CreatedBy: creator.ID,
+		ExpiredAt: time.Now().Add(ttl),
+	}
+	if roleID != 0 {
+		invitation.RoleID = &roleID
+	}
+	err = c.invitationRepository.CreateInvitation(ctx, invitation)
+	recordInvitationEvent(ctx, c.auditRepository, invitation.ID, invitationCreated, err)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Send the invitation email asyncronously
+	if email != "" {
+		go func(ctx context.Context) {
+			if err := mailer.SendInvitation(ctx, email, code, ttl); err != nil {
+				log.Errorf("error send invitation email: %v", err)
+			}
+		}(context.WithoutCancel(ctx))
+	}
+
+	return invitation, code, nil
+}
+
+func (c InvitationControllerImpl) ListInvitations(ctx context.Context) (invitations []model.Invitation, err error) {
+	ctx, span := tracer.Start(ctx, "InvitationController.ListInvitations")
+	defer func() { tracer.End(span, err) }()
+
+	return c.invitationRepository.FindInvitations(ctx)
+}
+
+// RevokeInvitation prevents the invitation from being used again, the users already
+// registered with it are kept
+func (c InvitationControllerImpl) RevokeInvitation(ctx context.Context, invitationID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "InvitationController.RevokeInvitation", attribute.Int("invitation.id", int(invitationID)))
+	defer func() { tracer.End(span, err) }()
+
+	err = c.invitationRepository.RevokeInvitation(ctx, invitationID)
+	recordInvitationEvent(ctx, c.auditRepository, invitationID, invitationRevoked, err)
+	return err
+}
+
+// recordInvitationEvent records the change of the invitation into the audit trail
+func recordInvitationEvent(ctx context.Context, auditRepository repository.AuditRepository, invitationID uint, action string, err error) {
+	recordAuditEvent(ctx, auditRepository, model.AuditEvent{
+		EventType: model.AuditInvitation,
+		Metadata:  map[string]string{"action": action, "invitation_id": strconv.FormatUint(uint64(invitationID), 10)},
+	}, err)
+}
+
+// checkRegistrationMode checks the registration is allowed by the REGISTRATION_MODE, the
+// unknown mode closes the registration rather than opening it by mistake
+func checkRegistrationMode(invitationCode string) error {
+	switch mode := env.GetenvOrDefault("REGISTRATION_MODE", RegistrationOpen); mode {
+	case RegistrationOpen:
+		return nil
+	case RegistrationInviteOnly:
+		if invitationCode == "" {
+			return apperror.ErrInvitationRequired
+		}
+		return nil
+	case RegistrationClosed:
+		return apperror.ErrRegistrationClosed
+	default:
+		log.Errorf("unknown registration mode: %s", mode)
+		return apperror.ErrRegistrationClosed
+	}
+}
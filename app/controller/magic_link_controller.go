@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMagicLinkTTL is the validity of the magic link
+	DefaultMagicLinkTTL = 15 * time.Minute
+
+	// MaxMagicLinksPerTTL limits the links sent to the user within the validity
+	// window, so the endpoint can't be used to flood the user inbox
+	MaxMagicLinksPerTTL = 3
+)
+
+type MagicLinkController interface {
+	RequestMagicLink(ctx context.Context, email string) (string, error)
+	ConsumeMagicLink(ctx context.Context, loginToken string, nonce string) (*LoginResult, error)
+}
+
+type MagicLinkControllerImpl struct {
+	loginInfoRepository       repository.LoginInfoRepository
+	magicLinkRepository       repository.MagicLinkRepository
+	sessionRepository         repository.SessionRepository
+	accountRepository         repository.AccountRepository
+	accountDeletionRepository repository.AccountDeletionRepository
+	auditRepository           repository.AuditRepository
+	passkeyController         PasskeyController
+	deviceController          DeviceController
+}
+
+func NewMagicLinkController(
+	loginInfoRepository repository.LoginInfoRepository,
+	magicLinkRepository repository.MagicLinkRepository,
+	sessionRepository repository.SessionRepository,
+	accountRepository repository.AccountRepository,
+	accountDeletionRepository repository.AccountDeletionRepository,
+	auditRepository repository.AuditRepository,
+	passkeyController PasskeyController,
+	deviceController DeviceController,
+) *MagicLinkControllerImpl {
+	return &MagicLinkControllerImpl{
+		loginInfoRepository:       loginInfoRepository,
+		magicLinkRepository:       magicLinkRepository,
+		sessionRepository:         sessionRepository,
+		accountRepository:         accountRepository,
+		accountDeletionRepository: accountDeletionRepository,
+		auditRepository:           auditRepository,
+		passkeyController:         passkeyController,
+		deviceController:          deviceController,
+	}
+}
+
+// RequestMagicLink sends the login link to the email and returns the nonce that should
+// be kept by the requesting device. The nonce is returned even when the email is not
+// registered, so the response doesn't reveal whether the user exists.
+func (c MagicLinkControllerImpl) RequestMagicLink(ctx context.Context, email string) (nonce string, err error) {
+	ctx, span := tracer.Start(ctx, "MagicLinkController.RequestMagicLink")
+	defer func() { tracer.End(span, err) }()
+
+	if !isMagicLinkEnabled() {
+		return "", apperror.ErrMagicLinkDisabled
+	}
+
+	// The nonce binds the link to the device requesting it
+	nonce, err = token.GenerateSessionToken()
+	if err != nil {
+		return "", err
+	}
+
+	credential := &model.LoginInfo{Email: email}
+	if err := c.loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			log.Debug("magic link requested for unregistered email")
+			return nonce, nil
+		}
+		return "", err
+	}
+
+	ttl := env.GetDurationOrDefault("MAGIC_LINK_TTL", DefaultMagicLinkTTL)
+	count, err := c.magicLinkRepository.CountRecentMagicLinks(ctx, credential.ID, time.Now().Add(-ttl))
+	if err != nil {
+		return "", err
+	}
+	if count >= MaxMagicLinksPerTTL {
+		log.WithField("user_id", credential.ID).Warn("magic link request limit reached")
+		return nonce, nil
+	}
+
+	loginToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.magicLinkRepository.CreateMagicLink(ctx, &model.MagicLink{
+		UserID:    credential.ID,
+		TokenHash: token.HashToken(loginToken),
+		NonceHash: token.HashToken(nonce),
+		ExpiredAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	// Send the magic link email asyncronously
+	go func(ctx context.Context) {
+		if err := mailer.SendMagicLink(ctx, credential.Email, credential.Username, loginToken, ttl); err != nil {
+			log.Errorf("error sending magic link email: %v", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nonce, nil
+}
+
+// ConsumeMagicLink verifies the login token along with the nonce of the requesting
+// device, then logs the user in the same way as the password login, including the
+// passkey of the second factor
+func (c MagicLinkControllerImpl) ConsumeMagicLink(ctx context.Context, loginToken string, nonce string) (result *LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "MagicLinkController.ConsumeMagicLink")
+	defer func() { tracer.End(span, err) }()
+
+	if !isMagicLinkEnabled() {
+		return nil, apperror.ErrMagicLinkDisabled
+	}
+
+	// Record the login attempt into the audit trail, the login pending for the second
+	// factor is recorded once the passkey is asserted
+	var userID *uint
+	defer func() {
+		if err != nil || result.Session != nil {
+			recordLoginEvent(ctx, c.auditRepository, model.LoginMethodMagicLink, userID, err)
+		}
+	}()
+
+	link, err := c.magicLinkRepository.ConsumeMagicLink(ctx, token.HashToken(loginToken), token.HashToken(nonce))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	userID = &link.UserID
+
+	result, err = completeFirstFactor(ctx, c.passkeyController, c.deviceController, c.loginInfoRepository, c.sessionRepository, c.accountRepository, link.UserID, model.SessionOptions{}, model.LoginMethodMagicLink)
+	if err != nil {
+		return nil, err
+	}
+	if result.Session != nil {
+		cancelAccountDeletion(ctx, c.accountDeletionRepository, c.auditRepository, link.UserID)
+	}
+	return result, nil
+}
+
+// isMagicLinkEnabled checks the magic link login is turned on for the deployment
+func isMagicLinkEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("MAGIC_LINK_ENABLED"))
+	return enabled
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+)
+
+const (
+	// DefaultSoftDeleteRetention is the duration the soft deleted rows are kept before
+	// they're hard deleted
+	DefaultSoftDeleteRetention = 30 * 24 * time.Hour
+
+	// DefaultVerificationResendAfter is the duration the verification email is left
+	// unsent before it's sent again
+	DefaultVerificationResendAfter = 15 * time.Minute
+
+	// VerificationResendBatchSize limits the verification emails sent by a single run
+	VerificationResendBatchSize = 100
+)
+
+// MaintenanceController runs the maintenance jobs of the scheduler, each job returns
+// the number of the affected rows
+type MaintenanceController interface {
+	PurgeExpiredSessions(ctx context.Context) (int64, error)
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+	PurgeSoftDeleted(ctx context.Context) (int64, error)
+	ResendStuckVerifications(ctx context.Context) (int64, error)
+}
+
+type MaintenanceControllerImpl struct {
+	maintenanceRepository       repository.MaintenanceRepository
+	sessionRepository           repository.SessionRepository
+	emailVerificationRepository repository.EmailVerificationRepository
+}
+
+func NewMaintenanceController(
+	maintenanceRepository repository.MaintenanceRepository,
+	sessionRepository repository.SessionRepository,
+	emailVerificationRepository repository.EmailVerificationRepository,
+) *MaintenanceControllerImpl {
+	return &MaintenanceControllerImpl{
+		maintenanceRepository:       maintenanceRepository,
+		sessionRepository:           sessionRepository,
+		emailVerificationRepository: emailVerificationRepository,
+	}
+}
+
+func (c MaintenanceControllerImpl) PurgeExpiredSessions(ctx context.Context) (purged int64, err error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceController.PurgeExpiredSessions")
+	defer func() { tracer.End(span, err) }()
+
+	purged, err = c.maintenanceRepository.PurgeExpiredSessions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	refreshActiveSessions(ctx, c.sessionRepository)
+	return purged, nil
+}
+
+func (c MaintenanceControllerImpl) PurgeExpiredTokens(ctx context.Context) (purged int64, err error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceController.PurgeExpiredTokens")
+	defer func() { tracer.End(span, err) }()
+
+	return c.maintenanceRepository.PurgeExpiredTokens(ctx, time.Now())
+}
+
+func (c MaintenanceControllerImpl) PurgeSoftDeleted(ctx context.Context) (purged int64, err error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceController.PurgeSoftDeleted")
+	defer func() { tracer.End(span, err) }()
+
+	retention := env.GetDurationOrDefault("SOFT_DELETE_RETENTION", DefaultSoftDeleteRetention)
+	return c.maintenanceRepository.PurgeSoftDeleted(ctx, time.Now().Add(-retention))
+}
+
+// ResendStuckVerifications sends the verification emails again when the delivery right
+// after the registration never happened or failed, it returns the number of the sent emails
+func (c MaintenanceControllerImpl) ResendStuckVerifications(ctx context.Context) (sent int64, err error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceController.ResendStuckVerifications")
+	defer func() { tracer.End(span, err) }()
+
+	// The token of the older registration is expired, the user requests a new one
+	now := time.Now()
+	resendAfter := env.GetDurationOrDefault("VERIFICATION_RESEND_AFTER", DefaultVerificationResendAfter)
+	credentials, err := c.maintenanceRepository.FindStuckVerifications(ctx,
+		now.Add(-resendAfter), now.Add(-time.Hour*model.TokenExpDuration), VerificationResendBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range credentials {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		if err := sendVerificationEmail(ctx, c.emailVerificationRepository, &credentials[i]); err == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,462 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/passkey"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type PasskeyController interface {
+	BeginRegistration(ctx context.Context, userID uint) ([]byte, error)
+	FinishRegistration(ctx context.Context, userID uint, name string, response string) (*model.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context) ([]byte, error)
+	BeginMFA(ctx context.Context, userID uint, options model.SessionOptions, firstFactor string) ([]byte, error)
+	FinishLogin(ctx context.Context, response string) (*model.Session, error)
+	BeginReauthentication(ctx context.Context, userID uint) ([]byte, error)
+	VerifyReauthentication(ctx context.Context, userID uint, response string) error
+	ListPasskeys(ctx context.Context, userID uint) ([]model.WebAuthnCredential, error)
+	DeletePasskey(ctx context.Context, userID uint, credentialID string) error
+}
+
+type PasskeyControllerImpl struct {
+	relyingParty              *passkey.RelyingParty
+	webAuthnRepository        repository.WebAuthnRepository
+	accountRepository         repository.AccountRepository
+	loginInfoRepository       repository.LoginInfoRepository
+	externalLoginRepository   repository.ExternalLoginRepository
+	sessionRepository         repository.SessionRepository
+	accountDeletionRepository repository.AccountDeletionRepository
+	auditRepository           repository.AuditRepository
+	deviceController          DeviceController
+}
+
+func NewPasskeyController(
+	relyingParty *passkey.RelyingParty,
+	webAuthnRepository repository.WebAuthnRepository,
+	accountRepository repository.AccountRepository,
+	loginInfoRepository repository.LoginInfoRepository,
+	externalLoginRepository repository.ExternalLoginRepository,
+	sessionRepository repository.SessionRepository,
+	accountDeletionRepository repository.AccountDeletionRepository,
+	auditRepository repository.AuditRepository,
+	deviceController DeviceController,
+) *PasskeyControllerImpl {
+	return &PasskeyControllerImpl{
+		relyingParty:              relyingParty,
+		webAuthnRepository:        webAuthnRepository,
+		accountRepository:         accountRepository,
+		loginInfoRepository:       loginInfoRepository,
+		externalLoginRepository:   externalLoginRepository,
+		sessionRepository:         sessionRepository,
+		accountDeletionRepository: accountDeletionRepository,
+		auditRepository:           auditRepository,
+		deviceController:          deviceController,
+	}
+}
+
+func (c PasskeyControllerImpl) BeginRegistration(ctx context.Context, userID uint) (options []byte, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.BeginRegistration", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	user, _, err := c.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := c.relyingParty.BeginRegistration(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.storeCeremony(ctx, model.WebAuthnCeremony{Type: model.WebAuthnRegistration, UserID: &userID}, ceremony); err != nil {
+		return nil, err
+	}
+
+	return ceremony.Options, nil
+}
+
+func (c PasskeyControllerImpl) FinishRegistration(ctx context.Context, userID uint, name string, response string) (credential *model.WebAuthnCredential, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.FinishRegistration", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	parsed, err := passkey.ParseCreationResponse(response)
+	if err != nil {
+		return nil, apperror.ErrInvalidPasskeyResponse.WithCause(err)
+	}
+
+	// The ceremony should be started by the same user for the registration
+	ceremony, err := c.consumeCeremony(ctx, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.Type != model.WebAuthnRegistration || ceremony.UserID == nil || *ceremony.UserID != userID {
+		return nil, apperror.ErrInvalidPasskeyCeremony
+	}
+
+	user, _, err := c.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the attestation of the new credential
+	created, err := c.relyingParty.FinishRegistration(user, ceremony.Session, parsed)
+	if err != nil {
+		log.WithError(err).Warn("passkey registration failed")
+		return nil, apperror.ErrPasskeyAuthFailed.WithCause(err)
+	}
+
+	transports := make([]string, 0, len(created.Transport))
+	for _, transport := range created.Transport {
+		transports = append(transports, string(transport))
+	}
+	stored, err := c.webAuthnRepository.CreateCredential(ctx, &model.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    encodeCredentialID(created.ID),
+		PublicKey:       created.PublicKey,
+		AttestationType: created.AttestationType,
+		AAGUID:          created.Authenticator.AAGUID,
+		SignCount:       created.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  created.Flags.BackupEligible,
+		BackupState:     created.Flags.BackupState,
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return nil, apperror.ErrPasskeyExists
+		}
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func (c PasskeyControllerImpl) BeginLogin(ctx context.Context) (options []byte, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.BeginLogin")
+	defer func() { tracer.End(span, err) }()
+
+	// The user is identified by the discoverable credential chosen on the authenticator
+	ceremony, err := c.relyingParty.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.storeCeremony(ctx, model.WebAuthnCeremony{Type: model.WebAuthnLogin}, ceremony); err != nil {
+		return nil, err
+	}
+
+	return ceremony.Options, nil
+}
+
+// BeginMFA starts the assertion of the user's passkeys as the second factor of the
+// user identified by the first factor login method, no options are returned when the
+// user has no passkey registered. The session options are kept until the session is
+// created by the assertion.
+func (c PasskeyControllerImpl) BeginMFA(ctx context.Context, userID uint, sessionOptions model.SessionOptions, firstFactor string) (options []byte, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.BeginMFA", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	user, _, err := c.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Credentials) == 0 {
+		return nil, nil
+	}
+
+	ceremony, err := c.relyingParty.BeginLogin(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.storeCeremony(ctx, model.WebAuthnCeremony{
+		Type:           model.WebAuthnMFA,
+		UserID:         &userID,
+		SessionOptions: sessionOptions,
+		FirstFactor:    firstFactor,
+	}, ceremony); err != nil {
+		return nil, err
+	}
+
+	return ceremony.Options, nil
+}
+
+// FinishLogin verifies the assertion of either the passkey login or the second factor
+// ceremony, the session of the asserted user is created when it's valid
+func (c PasskeyControllerImpl) FinishLogin(ctx context.Context, response string) (session *model.Session, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.FinishLogin")
+	defer func() { tracer.End(span, err) }()
+
+	// Record the login attempt into the audit trail
+	var userID *uint
+	defer func() { recordLoginEvent(ctx, c.auditRepository, model.LoginMethodPasskey, userID, err) }()
+
+	parsed, err := passkey.ParseAssertionResponse(response)
+	if err != nil {
+		return nil, apperror.ErrInvalidPasskeyResponse.WithCause(err)
+	}
+
+	ceremony, err := c.consumeCeremony(ctx, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("webauthn.ceremony", ceremony.Type))
+
+	var user *passkey.User
+	var credentials []model.WebAuthnCredential
+	var asserted *webauthn.Credential
+	switch {
+	case ceremony.Type == model.WebAuthnMFA && ceremony.UserID != nil:
+		userID = ceremony.UserID
+		user, credentials, err = c.findUser(ctx, *ceremony.UserID)
+		if err != nil {
+			return nil, err
+		}
+		asserted, err = c.relyingParty.FinishLogin(user, ceremony.Session, parsed)
+	case ceremony.Type == model.WebAuthnLogin:
+		user, asserted, err = c.relyingParty.FinishDiscoverableLogin(func(userID uint) (*passkey.User, error) {
+			found, stored, err := c.findUser(ctx, userID)
+			credentials = stored
+			return found, err
+		}, ceremony.Session, parsed)
+	default:
+		return nil, apperror.ErrInvalidPasskeyCeremony
+	}
+	if err != nil {
+		log.WithError(err).Warn("passkey authentication failed")
+		return nil, apperror.ErrPasskeyAuthFailed.WithCause(err)
+	}
+	userID = &user.ID
+
+	if err := c.updateCredentialUsage(ctx, credentials, asserted); err != nil {
+		return nil, err
+	}
+
+	// The second factor follows the login method of the first factor
+	authMethods := []string{model.LoginMethodPasskey}
+	if ceremony.Type == model.WebAuthnMFA {
+		authMethods = []string{ceremony.FirstFactor, model.LoginMethodPasskey}
+	}
+	session, err = startSession(ctx, c.sessionRepository, c.accountRepository, user.ID, ceremony.SessionOptions, authMethods...)
+	if err != nil {
+		return nil, err
+	}
+	cancelAccountDeletion(ctx, c.accountDeletionRepository, c.auditRepository, user.ID)
+
+	// Notify the user when the login comes from an unknown device
+	recognizeDevice(ctx, c.loginInfoRepository, c.deviceController, user.ID)
+	return session, nil
+}
+
+// BeginReauthentication starts the assertion of the user's passkeys to prove the
+// identity again, the user without passkey reauthenticates with the password
+func (c PasskeyControllerImpl) BeginReauthentication(ctx context.Context, userID uint) (options []byte, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.BeginReauthentication", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	user, _, err := c.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Credentials) == 0 {
+		return nil, apperror.ErrPasskeyNotFound
+	}
+
+	ceremony, err := c.relyingParty.BeginLogin(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.storeCeremony(ctx, model.WebAuthnCeremony{Type: model.WebAuthnReauth, UserID: &userID}, ceremony); err != nil {
+		return nil, err
+	}
+
+	return ceremony.Options, nil
+}
+
+// VerifyReauthentication verifies the assertion of the reauthentication ceremony
+// started by the same user
+func (c PasskeyControllerImpl) VerifyReauthentication(ctx context.Context, userID uint, response string) (err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.VerifyReauthentication", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	parsed, err := passkey.ParseAssertionResponse(response)
+	if err != nil {
+		return apperror.ErrInvalidPasskeyResponse.WithCause(err)
+	}
+
+	ceremony, err := c.consumeCeremony(ctx, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return err
+	}
+	if ceremony.Type != model.WebAuthnReauth || ceremony.UserID == nil || *ceremony.UserID != userID {
+		return apperror.ErrInvalidPasskeyCeremony
+	}
+
+	user, credentials, err := c.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	asserted, err := c.relyingParty.FinishLogin(user, ceremony.Session, parsed)
+	if err != nil {
+		log.WithError(err).Warn("passkey reauthentication failed")
+		return apperror.ErrPasskeyAuthFailed.WithCause(err)
+	}
+
+	return c.updateCredentialUsage(ctx, credentials, asserted)
+}
+
+func (c PasskeyControllerImpl) ListPasskeys(ctx context.Context, userID uint) (credentials []model.WebAuthnCredential, err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.ListPasskeys", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.webAuthnRepository.FindCredentials(ctx, userID)
+}
+
+func (c PasskeyControllerImpl) DeletePasskey(ctx context.Context, userID uint, credentialID string) (err error) {
+	ctx, span := tracer.Start(ctx, "PasskeyController.DeletePasskey", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	credentials, err := c.webAuthnRepository.FindCredentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The user without password nor external account should keep a passkey to login
+	if len(credentials) == 1 && credentials[0].CredentialID == credentialID {
+		hasOther, err := c.hasOtherLoginMethod(ctx, userID)
+		if err != nil {
+			return err
+		} else if !hasOther {
+			return apperror.ErrLastLoginMethod
+		}
+	}
+
+	return c.webAuthnRepository.DeleteCredential(ctx, userID, credentialID)
+}
+
+// findUser loads the WebAuthn user with the registered credentials, the user name is
+// the email when the user has the password login or the account name otherwise
+func (c PasskeyControllerImpl) findUser(ctx context.Context, userID uint) (*passkey.User, []model.WebAuthnCredential, error) {
+	credentials, err := c.webAuthnRepository.FindCredentials(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := &passkey.User{ID: userID, Credentials: make([]webauthn.Credential, 0, len(credentials))}
+	for _, credential := range credentials {
+		user.Credentials = append(user.Credentials, toWebAuthnCredential(credential))
+	}
+
+	info := &model.LoginInfo{ID: userID}
+	err = c.loginInfoRepository.FindLoginInfo(ctx, info)
+	switch {
+	case err == nil:
+		user.Name, user.DisplayName = info.Email, info.Username
+	case errors.Is(err, apperror.ErrNotFound):
+		account, err := c.accountRepository.FindAccountByUserID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				return nil, nil, apperror.ErrUserNotFound
+			}
+			return nil, nil, err
+		}
+		user.Name = fmt.Sprintf("user-%d", userID)
+		if account.UserName != nil {
+			user.DisplayName = *account.UserName
+		}
+	default:
+		return nil, nil, err
+	}
+
+	return user, credentials, nil
+}
+
+// updateCredentialUsage keeps the signature counter of the asserted credential to
+// detect the cloned authenticators on the next assertion
+func (c PasskeyControllerImpl) updateCredentialUsage(ctx context.Context, credentials []model.WebAuthnCredential, asserted *webauthn.Credential) error {
+	credentialID := encodeCredentialID(asserted.ID)
+	for i := range credentials {
+		if credentials[i].CredentialID == credentialID {
+			credentials[i].SignCount = asserted.Authenticator.SignCount
+			credentials[i].BackupState = asserted.Flags.BackupState
+			if err := c.webAuthnRepository.UpdateCredentialUsage(ctx, &credentials[i], time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasOtherLoginMethod checks the user can login with either the password or an external account
+func (c PasskeyControllerImpl) hasOtherLoginMethod(ctx context.Context, userID uint) (bool, error) {
+	err := c.loginInfoRepository.FindLoginInfo(ctx, &model.LoginInfo{ID: userID})
+	if err == nil {
+		return true, nil
+	} else if !errors.Is(err, apperror.ErrNotFound) {
+		return false, err
+	}
+
+	logins, err := c.externalLoginRepository.FindLoginExternalsByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(logins) > 0, nil
+}
+
+// storeCeremony keeps the pending ceremony along with the session data of the relying party
+func (c PasskeyControllerImpl) storeCeremony(ctx context.Context, pending model.WebAuthnCeremony, ceremony *passkey.Ceremony) error {
+	pending.Challenge, pending.Session = ceremony.Challenge(), ceremony.Session
+	return c.webAuthnRepository.CreateCeremony(ctx, &pending)
+}
+
+func (c PasskeyControllerImpl) consumeCeremony(ctx context.Context, challenge string) (model.WebAuthnCeremony, error) {
+	ceremony, err := c.webAuthnRepository.ConsumeCeremony(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.WebAuthnCeremony{}, apperror.ErrInvalidPasskeyCeremony
+		}
+		return model.WebAuthnCeremony{}, err
+	}
+	return ceremony, nil
+}
+
+// toWebAuthnCredential converts the stored credential into the relying party credential
+func toWebAuthnCredential(credential model.WebAuthnCredential) webauthn.Credential {
+	id, err := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+	if err != nil {
+		log.Errorf("error decode webauthn credential id: %v", err)
+	}
+
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}
+}
+
+func encodeCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
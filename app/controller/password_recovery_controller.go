@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPasswordResetTTL is the validity of the password reset link
+const DefaultPasswordResetTTL = time.Hour
+
+type PasswordRecoveryController interface {
+	StartPasswordRecovery(ctx context.Context, credential *model.LoginInfo) error
+	ResetPassword(ctx context.Context, recoveryToken string, password string) error
+}
+
+type PasswordRecoveryControllerImpl struct {
+	loginInfoRepository repository.LoginInfoRepository
+	sessionRepository   repository.SessionRepository
+	auditRepository     repository.AuditRepository
+}
+
+func NewPasswordRecoveryController(
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+) *PasswordRecoveryControllerImpl {
+	return &PasswordRecoveryControllerImpl{
+		loginInfoRepository: loginInfoRepository,
+		sessionRepository:   sessionRepository,
+		auditRepository:     auditRepository,
+	}
+}
+
+// StartPasswordRecovery creates the recovery token of the user and sends the password
+// reset link to the user's email, only the hash of the token is stored
+func (c PasswordRecoveryControllerImpl) StartPasswordRecovery(ctx context.Context, credential *model.LoginInfo) (err error) {
+	ctx, span := tracer.Start(ctx, "PasswordRecoveryController.StartPasswordRecovery")
+	defer func() { tracer.End(span, err) }()
+
+	recoveryToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+	ttl := env.GetDurationOrDefault("PASSWORD_RESET_TTL", DefaultPasswordResetTTL)
+	if err := c.loginInfoRepository.CreatePasswordRecovery(ctx, credential.ID, &model.PasswordRecovery{
+		Token:     token.HashToken(recoveryToken),
+		ExpiredAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	// Send the password reset email asyncronously
+	go func(ctx context.Context) {
+		if err := mailer.SendPasswordReset(ctx, credential.Email, credential.Username, recoveryToken, ttl); err != nil {
+			log.Errorf("error sending password reset email: %v", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// ResetPassword replaces the password of the recovery token's user, the sessions of
+// the user are revoked so the old password can't keep anyone logged in
+func (c PasswordRecoveryControllerImpl) ResetPassword(ctx context.Context, recoveryToken string, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "PasswordRecoveryController.ResetPassword")
+	defer func() { tracer.End(span, err) }()
+
+	// Record the password change into the audit trail
+	var userID *uint
+	defer func() {
+		recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+			EventType: model.AuditPasswordChange,
+			UserID:    userID,
+			Metadata:  map[string]string{"method": "recovery"},
+		}, err)
+	}()
+
+	credential, err := c.loginInfoRepository.FindLoginInfoByRecoveryToken(ctx, token.HashToken(recoveryToken))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.ErrInvalidPasswordRecovery
+		}
+		return err
+	}
+	userID = &credential.ID
+
+	hashAlgorithm, hashedPassword, passwordSalt, err := hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+	credential.PasswordHash = string(hashedPassword)
+	credential.PasswordSalt = hex.EncodeToString(passwordSalt)
+	credential.HashAlgorithm = model.HashAlgorithm{Name: string(hashAlgorithm)}
+	if err := c.loginInfoRepository.UpdatePassword(ctx, &credential); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.ErrInvalidPasswordRecovery
+		}
+		return err
+	}
+
+	if _, err := revokeUserSessions(ctx, c.sessionRepository, c.auditRepository, credential.ID, "password_reset"); err != nil {
+		log.Errorf("error revoke sessions after password reset: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultPersonalAccessTokenTTL is the expiry of the token when it's not specified
+	DefaultPersonalAccessTokenTTL = 30 * 24 * time.Hour
+
+	// MaxPersonalAccessTokenTTL is the longest allowed expiry of the token
+	MaxPersonalAccessTokenTTL = 365 * 24 * time.Hour
+)
+
+type PersonalAccessTokenController interface {
+	CreatePersonalAccessToken(ctx context.Context, owner *principal.Principal, name string, scopes []string, ttl time.Duration) (*model.PersonalAccessToken, string, error)
+	ListPersonalAccessTokens(ctx context.Context, userID uint) ([]model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, userID uint, prefix string) error
+	Authenticate(ctx context.Context, personalAccessToken string) (*principal.Principal, error)
+}
+
+type PersonalAccessTokenControllerImpl struct {
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+}
+
+func NewPersonalAccessTokenController(personalAccessTokenRepository repository.PersonalAccessTokenRepository) *PersonalAccessTokenControllerImpl {
+	return &PersonalAccessTokenControllerImpl{personalAccessTokenRepository: personalAccessTokenRepository}
+}
+
+func (c PersonalAccessTokenControllerImpl) CreatePersonalAccessToken(
+	ctx context.Context,
+	owner *principal.Principal,
+	name string,
+	scopes []string,
+	ttl time.Duration,
+) (created *model.PersonalAccessToken, plainToken string, err error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenController.CreatePersonalAccessToken", attribute.Int("user.id", int(owner.ID)))
+	defer func() { tracer.End(span, err) }()
+
+	// The token is only granted the scopes its owner already has
+	for _, scope := range scopes {
+		if !constant.IsPersonalScopeGrantable(scope) || !owner.HasScope(scope) {
+			return nil, "", apperror.ErrScopeNotGrantable
+		}
+	}
+
+	// Every token should expire, the default is used when not specified
+	maxTTL := env.GetDurationOrDefault("PERSONAL_ACCESS_TOKEN_MAX_TTL", MaxPersonalAccessTokenTTL)
+	if ttl <= 0 {
+		ttl = min(DefaultPersonalAccessTokenTTL, maxTTL)
+	} else if ttl > maxTTL {
+		return nil, "", apperror.ErrTokenExpiryTooLong
+	}
+
+	// Generate the token, only the hash of its secret is stored
+	plainToken, prefix, secret, err := token.GeneratePersonalAccessToken()
+	if err != nil {
+		return nil, "", err
+	}
+	hash, salt, algorithm, err := hashSecret(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	personalAccessToken, err := c.personalAccessTokenRepository.CreatePersonalAccessToken(ctx, &model.PersonalAccessToken{
+		UserID:        owner.ID,
+		Name:          name,
+		Prefix:        prefix,
+		TokenHash:     hash,
+		TokenSalt:     salt,
+		HashAlgorithm: string(algorithm),
+		Scopes:        scopes,
+		ExpiredAt:     expirationOf(ttl),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &personalAccessToken, plainToken, nil
+}
+
+func (c PersonalAccessTokenControllerImpl) ListPersonalAccessTokens(ctx context.Context, userID uint) (tokens []model.PersonalAccessToken, err error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenController.ListPersonalAccessTokens", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.personalAccessTokenRepository.FindPersonalAccessTokens(ctx, userID)
+}
+
+func (c PersonalAccessTokenControllerImpl) RevokePersonalAccessToken(ctx context.Context, userID uint, prefix string) (err error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenController.RevokePersonalAccessToken", attribute.String("token.prefix", prefix))
+	defer func() { tracer.End(span, err) }()
+
+	return c.personalAccessTokenRepository.RevokePersonalAccessToken(ctx, userID, prefix)
+}
+
+func (c PersonalAccessTokenControllerImpl) Authenticate(ctx context.Context, personalAccessToken string) (p *principal.Principal, err error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenController.Authenticate")
+	defer func() { tracer.End(span, err) }()
+
+	prefix, secret, ok := token.ParsePersonalAccessToken(personalAccessToken)
+	if !ok {
+		return nil, apperror.ErrInvalidPersonalAccessToken
+	}
+	span.SetAttributes(attribute.String("token.prefix", prefix))
+
+	// Find the token identified by the prefix along with its owner
+	stored, err := c.personalAccessTokenRepository.FindPersonalAccessTokenByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidPersonalAccessToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !stored.IsActive(now) {
+		return nil, apperror.ErrInvalidPersonalAccessToken
+	}
+
+	// Validates the secret of the token
+	valid, err := verifySecret(ctx, stored.TokenHash, stored.TokenSalt, stored.HashAlgorithm, secret)
+	if err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, apperror.ErrInvalidPersonalAccessToken
+	}
+
+	// The token of the suspended or deactivated account is no longer valid
+	if err := checkAccountStatus(&stored.User); err != nil {
+		return nil, err
+	}
+
+	// Track the token usage, a failure shouldn't reject the authenticated request
+	if err := c.personalAccessTokenRepository.UpdatePersonalAccessTokenLastUsed(ctx, stored.ID, now); err != nil {
+		log.Errorf("error track personal access token usage: %v", err)
+	}
+
+	// Limit the token to the scopes its owner still has, e.g. the owner may no longer be an admin
+	owner := &principal.Principal{Scopes: constant.GetRoleScopes(stored.User.RoleID)}
+	scopes := make([]string, 0, len(stored.Scopes))
+	for _, scope := range stored.Scopes {
+		if owner.HasScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &principal.Principal{
+		Type:   principal.TypeUser,
+		ID:     stored.UserID,
+		Name:   stored.Name,
+		Scopes: scopes,
+	}, nil
+}
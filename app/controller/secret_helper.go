@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/hasher"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+)
+
+// hashSecret hashes the secret of the prefixed token (API key or personal access token)
+// and returns the hex encoded hash and salt along with the algorithm used
+func hashSecret(ctx context.Context, secret string) (hash string, salt string, algorithm hasher.HashAlgorithm, err error) {
+	// The secret is a random high entropy value, so the fast algorithm is used by default
+	algorithm = getSecretHashAlgorithm()
+	saltBytes := hasher.GenerateRandomSalt()
+	hashBytes, err := withContext(ctx, func() ([]byte, error) {
+		return hasher.New(algorithm).GenerateHashPassword([]byte(secret), saltBytes)
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+	return hex.EncodeToString(hashBytes), hex.EncodeToString(saltBytes), algorithm, nil
+}
+
+// verifySecret compares the secret with the hex encoded hash and salt stored by 'hashSecret'
+func verifySecret(ctx context.Context, hash string, salt string, algorithm string, secret string) (bool, error) {
+	saltBytes, err := hex.DecodeString(salt)
+	if err != nil {
+		return false, err
+	}
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil {
+		return false, err
+	}
+	return withContext(ctx, func() (bool, error) {
+		return hasher.New(hasher.HashAlgorithm(algorithm)).VerifyPassword(hashBytes, []byte(secret), saltBytes)
+	})
+}
+
+// expirationOf get the expiration time of the given ttl, the nil is returned
+// when the ttl is zero which means it never expires
+func expirationOf(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	expiredAt := time.Now().Add(ttl)
+	return &expiredAt
+}
+
+func getSecretHashAlgorithm() hasher.HashAlgorithm {
+	algorithm := hasher.HashAlgorithm(env.GetenvOrDefault("API_KEY_HASH_ALGORITHM", string(hasher.SHA256)))
+	if !hasher.IsAlgorithmAllowed(algorithm) {
+		return hasher.SHA256
+	}
+	return algorithm
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultRotationOverlap is the duration the previous API keys stay valid after rotation
+const DefaultRotationOverlap = 24 * time.Hour
+
+type ServiceAccountController interface {
+	CreateServiceAccount(ctx context.Context, caller *principal.Principal, name string, description string, scopes []string, ttl time.Duration) (*model.ServiceAccount, string, error)
+	ListServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error)
+	RotateAPIKey(ctx context.Context, caller *principal.Principal, serviceAccountID uint, scopes []string, ttl time.Duration) (*model.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, prefix string) error
+	Authenticate(ctx context.Context, apiKey string) (*principal.Principal, error)
+}
+
+type ServiceAccountControllerImpl struct {
+	serviceAccountRepository repository.ServiceAccountRepository
+}
+
+func NewServiceAccountController(serviceAccountRepository repository.ServiceAccountRepository) *ServiceAccountControllerImpl {
+	return &ServiceAccountControllerImpl{serviceAccountRepository: serviceAccountRepository}
+}
+
+func (c ServiceAccountControllerImpl) CreateServiceAccount(
+	ctx context.Context,
+	caller *principal.Principal,
+	name string,
+	description string,
+	scopes []string,
+	ttl time.Duration,
+) (account *model.ServiceAccount, apiKey string, err error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountController.CreateServiceAccount", attribute.String("service_account.name", name))
+	defer func() { tracer.End(span, err) }()
+
+	// The service account is only granted the grantable scopes the caller already has
+	if err := checkGrantableScopes(caller, scopes); err != nil {
+		return nil, "", err
+	}
+
+	// Issue the first API key of the service account
+	key, apiKey, err := newAPIKey(ctx, scopes, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Store the service account along with the API key
+	created, err := c.serviceAccountRepository.CreateServiceAccount(ctx, &model.ServiceAccount{
+		Name:        name,
+		Description: description,
+		APIKeys:     []model.APIKey{*key},
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return nil, "", apperror.ErrServiceAccountExists
+		}
+		return nil, "", err
+	}
+
+	return &created, apiKey, nil
+}
+
+func (c ServiceAccountControllerImpl) ListServiceAccounts(ctx context.Context) (accounts []model.ServiceAccount, err error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountController.ListServiceAccounts")
+	defer func() { tracer.End(span, err) }()
+
+	return c.serviceAccountRepository.FindServiceAccounts(ctx)
+}
+
+func (c ServiceAccountControllerImpl) RotateAPIKey(ctx context.Context, caller *principal.Principal, serviceAccountID uint, scopes []string, ttl time.Duration) (key *model.APIKey, apiKey string, err error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountController.RotateAPIKey", attribute.Int("service_account.id", int(serviceAccountID)))
+	defer func() { tracer.End(span, err) }()
+
+	// Find the service account of the rotated keys
+	account, err := c.serviceAccountRepository.FindServiceAccountByID(ctx, serviceAccountID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, "", apperror.ErrServiceAccountNotFound
+		}
+		return nil, "", err
+	}
+
+	// The caller can't take over the service account having the scopes it doesn't have
+	now := time.Now()
+	for _, current := range account.APIKeys {
+		if !current.IsActive(now) {
+			continue
+		}
+		for _, scope := range current.Scopes {
+			if !caller.HasScope(scope) {
+				return nil, "", apperror.ErrInsufficientScope
+			}
+		}
+	}
+
+	// Without the requested scopes, the new key inherits the scopes of the newest active key,
+	// the keys are preloaded from the newest one
+	if len(scopes) == 0 {
+		for _, current := range account.APIKeys {
+			if current.IsActive(now) {
+				scopes = current.Scopes
+				break
+			}
+		}
+		if len(scopes) == 0 {
+			return nil, "", apperror.ErrNoActiveAPIKey
+		}
+	}
+	if err := checkGrantableScopes(caller, scopes); err != nil {
+		return nil, "", err
+	}
+
+	key, apiKey, err = newAPIKey(ctx, scopes, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+	key.ServiceAccountID = account.ID
+
+	// Store the new key, the previous keys keep working until the overlap ends
+	overlap := env.GetDurationOrDefault("API_KEY_ROTATION_OVERLAP", DefaultRotationOverlap)
+	rotated, err := c.serviceAccountRepository.RotateAPIKey(ctx, key, now.Add(overlap))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &rotated, apiKey, nil
+}
+
+func (c ServiceAccountControllerImpl) RevokeAPIKey(ctx context.Context, prefix string) (err error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountController.RevokeAPIKey", attribute.String("api_key.prefix", prefix))
+	defer func() { tracer.End(span, err) }()
+
+	return c.serviceAccountRepository.RevokeAPIKey(ctx, prefix)
+}
+
+func (c ServiceAccountControllerImpl) Authenticate(ctx context.Context, apiKey string) (p *principal.Principal, err error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountController.Authenticate")
+	defer func() { tracer.End(span, err) }()
+
+	prefix, secret, ok := token.ParseAPIKey(apiKey)
+	if !ok {
+		return nil, apperror.ErrInvalidAPIKey
+	}
+	span.SetAttributes(attribute.String("api_key.prefix", prefix))
+
+	// Find the key identified by the prefix
+	key, err := c.serviceAccountRepository.FindAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, apperror.ErrInvalidAPIKey
+	}
+
+	// Validates the secret of the key
+	valid, err := verifySecret(ctx, key.KeyHash, key.KeySalt, key.HashAlgorithm, secret)
+	if err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, apperror.ErrInvalidAPIKey
+	}
+
+	// Track the key usage, a failure shouldn't reject the authenticated request
+	if err := c.serviceAccountRepository.UpdateAPIKeyLastUsed(ctx, key.ID, now); err != nil {
+		log.Errorf("error track api key usage: %v", err)
+	}
+
+	return &principal.Principal{
+		Type:   principal.TypeServiceAccount,
+		ID:     key.ServiceAccountID,
+		Name:   key.ServiceAccount.Name,
+		Scopes: key.Scopes,
+	}, nil
+}
+
+// checkGrantableScopes checks the scopes are allowed to be granted into the API keys and
+// the caller already has them, so the caller can't grant more than it's granted
+func checkGrantableScopes(caller *principal.Principal, scopes []string) error {
+	for _, scope := range scopes {
+		if !constant.IsScopeGrantable(scope) || !caller.HasScope(scope) {
+			return apperror.ErrScopeNotGrantable
+		}
+	}
+	return nil
+}
+
+// newAPIKey generates the API key and returns the key model with the hashed secret
+// along with the plain key, the key never expires when the ttl is zero
+func newAPIKey(ctx context.Context, scopes []string, ttl time.Duration) (*model.APIKey, string, error) {
+	apiKey, prefix, secret, err := token.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash, salt, algorithm, err := hashSecret(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &model.APIKey{
+		Prefix:        prefix,
+		KeyHash:       hash,
+		KeySalt:       salt,
+		HashAlgorithm: string(algorithm),
+		Scopes:        scopes,
+		ExpiredAt:     expirationOf(ttl),
+	}, apiKey, nil
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SessionController manages the active sessions of the user, each session is the
+// device the user is logged in from
+type SessionController interface {
+	ListSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID uint) error
+}
+
+type SessionControllerImpl struct {
+	sessionRepository repository.SessionRepository
+	auditRepository   repository.AuditRepository
+}
+
+func NewSessionController(
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+) *SessionControllerImpl {
+	return &SessionControllerImpl{
+		sessionRepository: sessionRepository,
+		auditRepository:   auditRepository,
+	}
+}
+
+func (c SessionControllerImpl) ListSessions(ctx context.Context, userID uint) (sessions []model.Session, err error) {
+	ctx, span := tracer.Start(ctx, "SessionController.ListSessions", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.sessionRepository.FindActiveSessionsByUser(ctx, userID)
+}
+
+// RevokeSession logs the device of the user's session out, the session of another
+// user is not found
+func (c SessionControllerImpl) RevokeSession(ctx context.Context, userID uint, sessionID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "SessionController.RevokeSession", attribute.Int("user.id", int(userID)))
+	defer func() { tracer.End(span, err) }()
+
+	err = c.sessionRepository.DeleteUserSession(ctx, userID, sessionID)
+	recordAuditEvent(ctx, c.auditRepository, model.AuditEvent{
+		EventType: model.AuditSessionRevoke,
+		UserID:    &userID,
+		Metadata:  map[string]string{"reason": "user_revoked", "session_id": strconv.FormatUint(uint64(sessionID), 10)},
+	}, err)
+	if err != nil {
+		return err
+	}
+	refreshActiveSessions(ctx, c.sessionRepository)
+	return nil
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	"github.com/budgetin-app/user-service/app/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSessionTouchInterval throttles the writes of the session use, the session is
+// only extended when it's last seen longer than the interval ago. It makes the idle
+// timeout up to the interval shorter.
+const DefaultSessionTouchInterval = time.Minute
+
+// sessionPolicy is the expiration policy of the sessions
+type sessionPolicy struct {
+	IdleTimeout   time.Duration
+	MaxLifetime   time.Duration
+	TouchInterval time.Duration
+}
+
+// getSessionPolicy reads the expiration policy of either the regular or the "remember
+// me" sessions from the environment variables
+func getSessionPolicy(rememberMe bool) sessionPolicy {
+	policy := sessionPolicy{
+		IdleTimeout:   env.GetDurationOrDefault("SESSION_IDLE_TIMEOUT", model.DefaultSessionIdleTimeout),
+		MaxLifetime:   env.GetDurationOrDefault("SESSION_MAX_LIFETIME", model.DefaultSessionMaxLifetime),
+		TouchInterval: env.GetDurationOrDefault("SESSION_TOUCH_INTERVAL", DefaultSessionTouchInterval),
+	}
+	if rememberMe {
+		policy.IdleTimeout = env.GetDurationOrDefault("REMEMBER_ME_IDLE_TIMEOUT", model.DefaultRememberMeIdleTimeout)
+		policy.MaxLifetime = env.GetDurationOrDefault("REMEMBER_ME_MAX_LIFETIME", model.DefaultRememberMeMaxLifetime)
+	}
+	return policy
+}
+
+// startSession creates the new session of the authenticated user, only one active
+// regular session is allowed per user. The "remember me" session is bound to its own
+// device, so it doesn't conflict with the other sessions. The methods are the ones the
+// user authenticated with, e.g. the password and the passkey of the second factor. The
+// session is only created for the account allowed to login.
+func startSession(
+	ctx context.Context,
+	sessionRepository repository.SessionRepository,
+	accountRepository repository.AccountRepository,
+	userID uint,
+	options model.SessionOptions,
+	authMethods ...string,
+) (*model.Session, error) {
+	account, err := accountRepository.FindAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(&account); err != nil {
+		return nil, err
+	}
+
+	// Check for existing session
+	if !options.RememberMe {
+		// The user without an active session has no conflicting session
+		oldSession, err := sessionRepository.FindActiveSession(ctx, userID)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return nil, err
+		}
+		if oldSession != nil {
+			log.Debugf("found active session, id: %d", oldSession.ID)
+			return nil, apperror.ErrAlreadyLoggedOn
+		}
+	}
+
+	// Generate session token
+	sessionToken, err := token.GenerateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create new session for the user along with the device it's created from
+	info := client.FromContext(ctx)
+	policy := getSessionPolicy(options.RememberMe)
+	now := time.Now()
+	session := &model.Session{
+		UserID:          userID,
+		Token:           sessionToken,
+		MaxExpiredAt:    now.Add(policy.MaxLifetime),
+		AuthenticatedAt: now,
+		AuthMethods:     authMethods,
+		SessionOptions:  model.SessionOptions{RememberMe: options.RememberMe, DeviceName: truncate(options.DeviceName, 100)},
+		UserAgent:       truncate(info.UserAgent, 250),
+		IPAddress:       info.IP,
+	}
+	session.Extend(now, policy.IdleTimeout)
+
+	// The "remember me" session is bound to the secret kept by the device
+	if options.RememberMe {
+		if session.DeviceSecret, err = token.GenerateSessionToken(); err != nil {
+			return nil, err
+		}
+		session.DeviceSecretHash = token.HashToken(session.DeviceSecret)
+	}
+
+	newSession, err := sessionRepository.CreateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	refreshActiveSessions(ctx, sessionRepository)
+
+	return &newSession, nil
+}
+
+// completeFirstFactor continues the login of the user identified by the first factor
+// login method, the passkey assertion of the second factor is started when the user has
+// a passkey registered, otherwise the session is created right away
+func completeFirstFactor(
+	ctx context.Context,
+	passkeyController PasskeyController,
+	deviceController DeviceController,
+	loginInfoRepository repository.LoginInfoRepository,
+	sessionRepository repository.SessionRepository,
+	accountRepository repository.AccountRepository,
+	userID uint,
+	options model.SessionOptions,
+	firstFactor string,
+) (*LoginResult, error) {
+	// The second factor isn't started for the account not allowed to login
+	account, err := accountRepository.FindAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(&account); err != nil {
+		return nil, err
+	}
+
+	mfaOptions, err := passkeyController.BeginMFA(ctx, userID, options, firstFactor)
+	if err != nil {
+		return nil, err
+	} else if mfaOptions != nil {
+		return &LoginResult{MFAOptions: mfaOptions}, nil
+	}
+
+	session, err := startSession(ctx, sessionRepository, accountRepository, userID, options, firstFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Notify the user when the login comes from an unknown device
+	recognizeDevice(ctx, loginInfoRepository, deviceController, userID)
+	return &LoginResult{Session: session}, nil
+}
+
+// recognizeDevice adds the device of the login into the user's known devices the same way
+// as the password login, it's skipped for the user without the password login. Failing
+// to recognize the device doesn't fail the login.
+func recognizeDevice(
+	ctx context.Context,
+	loginInfoRepository repository.LoginInfoRepository,
+	deviceController DeviceController,
+	userID uint,
+) {
+	credential := &model.LoginInfo{ID: userID}
+	if err := loginInfoRepository.FindLoginInfo(ctx, credential); err != nil {
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Errorf("error recognize login device: %v", err)
+		}
+		return
+	}
+	if err := deviceController.RecognizeDevice(ctx, credential); err != nil {
+		log.Errorf("error recognize login device: %v", err)
+	}
+}
+
+// extendSession slides the expiration of the validated session, failing to extend it
+// is logged and doesn't fail the request since the session is still valid
+func extendSession(ctx context.Context, sessionRepository repository.SessionRepository, session *model.Session) {
+	// The sessions created before the sliding expiration keep their fixed expiration
+	if session.MaxExpiredAt.IsZero() {
+		return
+	}
+
+	policy := getSessionPolicy(session.RememberMe)
+	now := time.Now()
+	seenBefore := now.Add(-policy.TouchInterval)
+	if !session.LastSeenAt.Before(seenBefore) {
+		return
+	}
+
+	session.Extend(now, policy.IdleTimeout)
+	if _, err := sessionRepository.ExtendSession(ctx, session, seenBefore); err != nil {
+		log.WithField("session_id", session.ID).Errorf("error extend session: %v", err)
+	}
+}
+
+// refreshActiveSessions updates the active sessions gauge from the stored sessions
+func refreshActiveSessions(ctx context.Context, sessionRepository repository.SessionRepository) {
+	count, err := sessionRepository.CountActiveSessions(ctx)
+	if err != nil {
+		log.Errorf("error refresh active sessions metric: %v", err)
+		return
+	}
+	metrics.ActiveSessions.Set(float64(count))
+}
+
+// checkAccountStatus checks the status of the account allows the user to login
+func checkAccountStatus(account *model.Account) error {
+	switch {
+	case account.CanLogin():
+		return nil
+	case account.Status == model.AccountSuspended:
+		return apperror.ErrAccountSuspended
+	default:
+		return apperror.ErrAccountDeactivated
+	}
+}
+
+// revokeUserSessions deletes every session of the user and records the revocation
+// into the audit trail, the reason describes what triggered the revocation
+func revokeUserSessions(
+	ctx context.Context,
+	sessionRepository repository.SessionRepository,
+	auditRepository repository.AuditRepository,
+	userID uint,
+	reason string,
+) (int64, error) {
+	count, err := sessionRepository.DeleteSessionsByUser(ctx, userID)
+	recordAuditEvent(ctx, auditRepository, model.AuditEvent{
+		EventType: model.AuditSessionRevoke,
+		UserID:    &userID,
+		Metadata:  map[string]string{"reason": reason, "sessions": strconv.FormatInt(count, 10)},
+	}, err)
+	if err != nil {
+		return 0, err
+	}
+	refreshActiveSessions(ctx, sessionRepository)
+	return count, nil
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"github.com/budgetin-app/user-service/app/repository"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultUserPageSize is the number of users listed when the page size isn't specified
+	DefaultUserPageSize = 50
+
+	// MaxUserPageSize is the maximum number of users listed in a page
+	MaxUserPageSize = 200
+)
+
+type UserDirectoryController interface {
+	ListUsers(ctx context.Context, filter repository.UserFilter, pageSize int, pageToken string) ([]model.UserSummary, string, error)
+	SearchUsers(ctx context.Context, filter repository.UserFilter, pageSize int, pageToken string) ([]model.UserSummary, string, error)
+}
+
+type UserDirectoryControllerImpl struct {
+	userDirectoryRepository repository.UserDirectoryRepository
+}
+
+func NewUserDirectoryController(userDirectoryRepository repository.UserDirectoryRepository) *UserDirectoryControllerImpl {
+	return &UserDirectoryControllerImpl{userDirectoryRepository: userDirectoryRepository}
+}
+
+func (c UserDirectoryControllerImpl) ListUsers(ctx context.Context, filter repository.UserFilter, pageSize int, pageToken string) (users []model.UserSummary, nextPageToken string, err error) {
+	ctx, span := tracer.Start(ctx, "UserDirectoryController.ListUsers")
+	defer func() { tracer.End(span, err) }()
+
+	filter.Query = ""
+	return c.findUsers(ctx, filter, pageSize, pageToken)
+}
+
+// SearchUsers lists the filtered users whose username or email starts with the query
+func (c UserDirectoryControllerImpl) SearchUsers(ctx context.Context, filter repository.UserFilter, pageSize int, pageToken string) (users []model.UserSummary, nextPageToken string, err error) {
+	ctx, span := tracer.Start(ctx, "UserDirectoryController.SearchUsers", attribute.Int("search.query_length", len(filter.Query)))
+	defer func() { tracer.End(span, err) }()
+
+	return c.findUsers(ctx, filter, pageSize, pageToken)
+}
+
+// findUsers lists a page of the filtered users, the page token is the id of the last
+// user of the previous page. No next page token is returned for the last page.
+func (c UserDirectoryControllerImpl) findUsers(ctx context.Context, filter repository.UserFilter, pageSize int, pageToken string) ([]model.UserSummary, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultUserPageSize
+	}
+	pageSize = min(pageSize, MaxUserPageSize)
+
+	if pageToken != "" {
+		afterID, err := strconv.ParseUint(pageToken, 10, 64)
+		if err != nil || afterID == 0 {
+			return nil, "", apperror.ErrInvalidPageToken
+		}
+		filter.AfterID = uint(afterID)
+	}
+
+	// Find one more user to know whether there is a next page
+	filter.Limit = pageSize + 1
+	users, err := c.userDirectoryRepository.FindUsers(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(users) <= pageSize {
+		return users, "", nil
+	}
+	users = users[:pageSize]
+	return users, strconv.FormatUint(uint64(users[pageSize-1].ID), 10), nil
+}
@@ -0,0 +1,101 @@
+package apperror
+
+import "errors"
+
+// Kinds of the domain errors, use 'errors.Is' to check the kind of a returned error
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrSessionConflict    = errors.New("session conflict")
+	ErrFailedPrecondition = errors.New("failed precondition")
+	ErrInternal           = errors.New("internal error")
+)
+
+// Error is the domain error with a kind, a machine readable reason and a message
+// that is safe to be returned to the client. The cause is kept for logging only.
+type Error struct {
+	kind    error
+	reason  string
+	message string
+	cause   error
+}
+
+// New create a domain error of the given kind
+func New(kind error, reason string, message string) *Error {
+	return &Error{kind: kind, reason: reason, message: message}
+}
+
+// Wrap create a domain error of the given kind caused by the error, the reason
+// and message of the kind are used
+func Wrap(kind error, cause error) *Error {
+	return &Error{kind: kind, reason: reasonOf(kind), message: kind.Error(), cause: cause}
+}
+
+// WithCause returns a copy of the error with the cause attached
+func (e *Error) WithCause(cause error) *Error {
+	err := *e
+	err.cause = cause
+	return &err
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+// Is reports the error matches its kind, so 'errors.Is(err, ErrNotFound)' is satisfied
+func (e *Error) Is(target error) bool {
+	if other, ok := target.(*Error); ok {
+		return e.kind == other.kind && e.reason == other.reason
+	}
+	return e.kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Kind returns the kind of the error
+func (e *Error) Kind() error {
+	return e.kind
+}
+
+// Reason returns the machine readable reason of the error
+func (e *Error) Reason() string {
+	return e.reason
+}
+
+// Message returns the client safe message of the error
+func (e *Error) Message() string {
+	return e.message
+}
+
+// reasonOf get the default reason of the error kind
+func reasonOf(kind error) string {
+	switch kind {
+	case ErrNotFound:
+		return "NOT_FOUND"
+	case ErrAlreadyExists:
+		return "ALREADY_EXISTS"
+	case ErrInvalidArgument:
+		return "INVALID_ARGUMENT"
+	case ErrInvalidCredentials:
+		return "INVALID_CREDENTIALS"
+	case ErrUnauthenticated:
+		return "UNAUTHENTICATED"
+	case ErrPermissionDenied:
+		return "PERMISSION_DENIED"
+	case ErrSessionConflict:
+		return "SESSION_CONFLICT"
+	case ErrFailedPrecondition:
+		return "FAILED_PRECONDITION"
+	default:
+		return "INTERNAL"
+	}
+}
@@ -0,0 +1,107 @@
+package apperror
+
+// Domain errors of the authentication flows
+var (
+	ErrUsernameExists      = New(ErrAlreadyExists, "USERNAME_ALREADY_EXISTS", "username already exists")
+	ErrEmailExists         = New(ErrAlreadyExists, "EMAIL_ALREADY_EXISTS", "email already exists")
+	ErrUsernameImmutable   = New(ErrFailedPrecondition, "USERNAME_IMMUTABLE", "username not allowed to be changed")
+	ErrCredentialsMismatch = New(ErrInvalidCredentials, "INVALID_CREDENTIALS", "invalid username, email or password")
+	ErrAlreadyLoggedOn     = New(ErrSessionConflict, "ALREADY_LOGGED_ON", "user already logged on")
+	ErrSessionNotFound     = New(ErrNotFound, "SESSION_NOT_FOUND", "session not found")
+	ErrUserNotFound        = New(ErrNotFound, "USER_NOT_FOUND", "user not found")
+	ErrInvalidVerification = New(ErrNotFound, "VERIFICATION_TOKEN_INVALID", "verification token is invalid")
+	ErrExpiredVerification = New(ErrFailedPrecondition, "VERIFICATION_TOKEN_EXPIRED", "verification token is expired")
+)
+
+// Domain errors of the caller authentication and authorization
+var (
+	ErrMissingCredentials     = New(ErrUnauthenticated, "MISSING_CREDENTIALS", "authentication credentials must be provided")
+	ErrInvalidAuthToken       = New(ErrUnauthenticated, "INVALID_AUTH_TOKEN", "authentication token is invalid or expired")
+	ErrInvalidAPIKey          = New(ErrUnauthenticated, "INVALID_API_KEY", "API key is invalid, expired or revoked")
+	ErrInsufficientScope      = New(ErrPermissionDenied, "INSUFFICIENT_SCOPE", "caller is not granted the required scope")
+	ErrServiceAccountExists   = New(ErrAlreadyExists, "SERVICE_ACCOUNT_ALREADY_EXISTS", "service account already exists")
+	ErrServiceAccountNotFound = New(ErrNotFound, "SERVICE_ACCOUNT_NOT_FOUND", "service account not found")
+	ErrAPIKeyNotFound         = New(ErrNotFound, "API_KEY_NOT_FOUND", "API key not found")
+	ErrScopeNotGrantable      = New(ErrInvalidArgument, "SCOPE_NOT_GRANTABLE", "scope is not allowed to be granted")
+	ErrNoActiveAPIKey         = New(ErrFailedPrecondition, "NO_ACTIVE_API_KEY", "service account has no active API key to inherit the scopes from")
+
+	ErrInvalidPersonalAccessToken  = New(ErrUnauthenticated, "INVALID_PERSONAL_ACCESS_TOKEN", "personal access token is invalid, expired or revoked")
+	ErrPersonalAccessTokenNotFound = New(ErrNotFound, "PERSONAL_ACCESS_TOKEN_NOT_FOUND", "personal access token not found")
+	ErrTokenExpiryTooLong          = New(ErrInvalidArgument, "TOKEN_EXPIRY_TOO_LONG", "token expiry exceeds the maximum allowed")
+)
+
+// Domain errors of the external identity provider login
+var (
+	ErrExternalProviderNotFound = New(ErrNotFound, "EXTERNAL_PROVIDER_NOT_FOUND", "external provider not found")
+	ErrInvalidExternalState     = New(ErrUnauthenticated, "EXTERNAL_LOGIN_STATE_INVALID", "external login state is invalid or expired")
+	ErrExternalAuthFailed       = New(ErrUnauthenticated, "EXTERNAL_AUTHENTICATION_FAILED", "external provider authentication failed")
+	ErrExternalAccountLinked    = New(ErrAlreadyExists, "EXTERNAL_ACCOUNT_ALREADY_LINKED", "external account already linked")
+	ErrExternalAccountNotLinked = New(ErrFailedPrecondition, "EXTERNAL_ACCOUNT_NOT_LINKED", "email already registered, login and link the external account first")
+	ErrExternalAccountNotFound  = New(ErrNotFound, "EXTERNAL_ACCOUNT_NOT_FOUND", "external account not found")
+	ErrLastLoginMethod          = New(ErrFailedPrecondition, "LAST_LOGIN_METHOD", "the last login method can't be removed")
+)
+
+// Domain errors of the passwordless magic link login
+var (
+	ErrMagicLinkDisabled = New(ErrFailedPrecondition, "MAGIC_LINK_DISABLED", "magic link login is disabled")
+	ErrInvalidMagicLink  = New(ErrUnauthenticated, "MAGIC_LINK_INVALID", "magic link is invalid, expired or already used")
+)
+
+// Domain errors of the WebAuthn passkey login
+var (
+	ErrInvalidPasskeyCeremony = New(ErrUnauthenticated, "PASSKEY_CEREMONY_INVALID", "passkey challenge is invalid, expired or already used")
+	ErrPasskeyAuthFailed      = New(ErrUnauthenticated, "PASSKEY_AUTHENTICATION_FAILED", "passkey authentication failed")
+	ErrPasskeyExists          = New(ErrAlreadyExists, "PASSKEY_ALREADY_EXISTS", "passkey already registered")
+	ErrPasskeyNotFound        = New(ErrNotFound, "PASSKEY_NOT_FOUND", "passkey not found")
+	ErrInvalidPasskeyResponse = New(ErrInvalidArgument, "PASSKEY_RESPONSE_INVALID", "passkey response is malformed")
+)
+
+// Domain errors of the security audit trail
+var (
+	ErrAuditEventImmutable = New(ErrFailedPrecondition, "AUDIT_EVENT_IMMUTABLE", "audit events can't be changed or deleted")
+	ErrInvalidPageToken    = New(ErrInvalidArgument, "PAGE_TOKEN_INVALID", "page token is invalid")
+)
+
+// Domain errors of the unrecognized device report and the password recovery
+var (
+	ErrInvalidDeviceReport     = New(ErrUnauthenticated, "DEVICE_REPORT_INVALID", "report link is invalid, expired or already used")
+	ErrInvalidPasswordRecovery = New(ErrUnauthenticated, "PASSWORD_RECOVERY_INVALID", "password recovery token is invalid or expired")
+)
+
+// Domain errors of the "remember me" sessions and the fresh authentication requirement
+var (
+	ErrDeviceSecretMismatch = New(ErrUnauthenticated, "DEVICE_SECRET_MISMATCH", "session is bound to another device")
+	ErrStepUpRequired       = New(ErrUnauthenticated, "STEP_UP_REQUIRED", "recent authentication is required, reauthenticate to continue")
+	ErrReauthUnsupported    = New(ErrFailedPrecondition, "REAUTHENTICATION_UNSUPPORTED", "only the login session can be reauthenticated")
+)
+
+// Domain errors of the personal data export
+var (
+	ErrUnsupportedExportFormat = New(ErrInvalidArgument, "EXPORT_FORMAT_UNSUPPORTED", "export format is not supported")
+	ErrInvalidDataExport       = New(ErrUnauthenticated, "DATA_EXPORT_INVALID", "download link is invalid or expired")
+)
+
+// Domain errors of the account status lifecycle
+var (
+	ErrAccountSuspended        = New(ErrPermissionDenied, "ACCOUNT_SUSPENDED", "account is suspended")
+	ErrAccountDeactivated      = New(ErrPermissionDenied, "ACCOUNT_DEACTIVATED", "account is deactivated")
+	ErrInvalidStatusTransition = New(ErrFailedPrecondition, "ACCOUNT_STATUS_TRANSITION_INVALID", "account status can't be changed from its current status")
+	ErrOwnStatusChange         = New(ErrPermissionDenied, "ACCOUNT_STATUS_OWN", "own account status can't be changed")
+)
+
+// Domain errors of the admin impersonation
+var (
+	ErrImpersonationNotAllowed = New(ErrPermissionDenied, "IMPERSONATION_NOT_ALLOWED", "user can't be impersonated")
+	ErrImpersonationRestricted = New(ErrPermissionDenied, "IMPERSONATION_RESTRICTED", "action is not allowed while impersonating")
+	ErrNotImpersonating        = New(ErrFailedPrecondition, "NOT_IMPERSONATING", "session is not impersonating")
+)
+
+// Domain errors of the registration modes and the invitations
+var (
+	ErrRegistrationClosed = New(ErrPermissionDenied, "REGISTRATION_CLOSED", "registration is closed")
+	ErrInvitationRequired = New(ErrPermissionDenied, "INVITATION_REQUIRED", "registration requires an invitation code")
+	ErrInvalidInvitation  = New(ErrPermissionDenied, "INVITATION_INVALID", "invitation code is invalid, expired or used up")
+	ErrInvitationNotFound = New(ErrNotFound, "INVITATION_NOT_FOUND", "invitation not found")
+	ErrRoleNotInvitable   = New(ErrPermissionDenied, "ROLE_NOT_INVITABLE", "role can't be pre-assigned by the caller")
+	ErrRoleNotFound       = New(ErrInvalidArgument, "ROLE_NOT_FOUND", "role doesn't exist")
+)
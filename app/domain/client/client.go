@@ -0,0 +1,25 @@
+package client
+
+import "context"
+
+// Info is the client information of the request, the device secret is the secret
+// binding the "remember me" session to the device
+type Info struct {
+	IP           string
+	UserAgent    string
+	DeviceSecret string
+}
+
+type infoKey struct{}
+
+// NewContext returns a copy of the context carrying the client information
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// FromContext get the client information of the request, it's empty when the
+// request is not handled by the server (e.g. background jobs)
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(infoKey{}).(Info)
+	return info
+}
@@ -2,6 +2,19 @@ package model
 
 import "time"
 
+// Account statuses, only the active account and the account pending for deletion can
+// login. The suspended and the deactivated account are reinstated by an admin.
+const (
+	AccountActive          = "active"
+	AccountSuspended       = "suspended"
+	AccountDeactivated     = "deactivated"
+	AccountPendingDeletion = "pending_deletion"
+)
+
+// Account is the user of the service. The account scheduled for deletion is deleted
+// once the grace period is over, unless the user logs in before. The status is changed
+// along with the reason and the admin who changed it, the admin isn't set when the
+// user changed it.
 type Account struct {
 	ID          uint    `gorm:"column:user_id; primaryKey"`
 	UserName    *string `gorm:"size:100"`
@@ -9,9 +22,21 @@ type Account struct {
 	DateOfBirth time.Time
 	RoleID      uint
 	Role        Role
+	// The time the account is deleted at, it's not set unless the deletion is requested
+	DeletionScheduledAt *time.Time `gorm:"index"`
+	Status              string     `gorm:"size:20; default:active; index"`
+	StatusReason        string     `gorm:"size:250"`
+	StatusChangedBy     *uint
+	StatusChangedAt     *time.Time
 	BaseModel
 }
 
 func (Account) TableName() string {
 	return "user_accounts"
 }
+
+// CanLogin checks the status of the account allows the user to login, the account
+// created before the status is known is active
+func (a *Account) CanLogin() bool {
+	return a.Status == "" || a.Status == AccountActive || a.Status == AccountPendingDeletion
+}
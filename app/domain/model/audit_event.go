@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"gorm.io/gorm"
+)
+
+// Audit event types
+const (
+	AuditLoginSuccess      = "login_success"
+	AuditLoginFailure      = "login_failure"
+	AuditLogout            = "logout"
+	AuditPasswordChange    = "password_change"
+	AuditEmailVerification = "email_verification"
+	AuditRoleChange        = "role_change"
+	AuditSessionRevoke     = "session_revoke"
+	AuditReauthentication  = "reauthentication"
+	AuditAccountDeletion   = "account_deletion"
+	AuditDataExport        = "data_export"
+	AuditStatusChange      = "account_status_change"
+	AuditImpersonation     = "impersonation"
+	AuditInvitation        = "invitation"
+)
+
+// Audit event outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Audit event actor types, the anonymous actor is the unauthenticated caller
+const (
+	AuditActorUser           = "user"
+	AuditActorServiceAccount = "service_account"
+	AuditActorAnonymous      = "anonymous"
+)
+
+// Login methods recorded in the login events metadata, they're also the methods the
+// session is authenticated with
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodPasskey   = "passkey"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodExternal  = "external"
+
+	// LoginMethodImpersonation is the method of the session issued to the impersonator
+	LoginMethodImpersonation = "impersonation"
+)
+
+// LoginEventTypes are the event types of the user login history
+var LoginEventTypes = []string{AuditLoginSuccess, AuditLoginFailure, AuditLogout}
+
+// AuditEvent is the append-only security event. The user is the subject of the
+// event, the actor is the caller performing it which is the user itself unless
+// it's performed by an admin or a service account. The client address and user
+// agent are only erased when the account of the user is purged.
+type AuditEvent struct {
+	ID        uint   `gorm:"column:audit_event_id; primaryKey"`
+	EventType string `gorm:"size:50; index"`
+	Outcome   string `gorm:"size:20"`
+	Reason    string `gorm:"size:100"`
+	UserID    *uint  `gorm:"index"`
+	ActorType string `gorm:"size:20"`
+	ActorID   *uint
+	IPAddress string            `gorm:"size:50"`
+	UserAgent string            `gorm:"size:250"`
+	Metadata  map[string]string `gorm:"serializer:json"`
+	CreatedAt time.Time         `gorm:"index"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) (err error) {
+	return apperror.ErrAuditEventImmutable
+}
+
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) (err error) {
+	return apperror.ErrAuditEventImmutable
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Data export statuses
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is the export of the user's personal data, it's generated in the background
+// and downloaded with the emailed token until it expires. Only the hash of the download
+// token is stored, it's not set until the export is ready.
+type DataExport struct {
+	ID          uint   `gorm:"column:data_export_id; primaryKey"`
+	UserID      uint   `gorm:"index"`
+	Format      string `gorm:"size:10"`
+	Status      string `gorm:"size:20; index"`
+	FileName    string `gorm:"size:100"`
+	ContentType string `gorm:"size:50"`
+	Content     []byte
+	TokenHash   *string    `gorm:"column:download_token; size:64; unique"`
+	ExpiredAt   *time.Time `gorm:"column:download_expiration"`
+	BaseModel
+}
+
+func (DataExport) TableName() string {
+	return "user_data_exports"
+}
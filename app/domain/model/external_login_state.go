@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ExternalLoginStateExpDuration = 10 // minutes
+)
+
+// ExternalLoginState is the pending authorization request of the external provider,
+// the user is set when the external account is being linked to an existing user
+type ExternalLoginState struct {
+	ID           uint   `gorm:"column:state_id; primaryKey"`
+	State        string `gorm:"size:100; unique"`
+	ProviderName string `gorm:"size:50"`
+	CodeVerifier string `gorm:"size:100"`
+	Nonce        string `gorm:"size:100"`
+	UserID       *uint
+	ExpiredAt    time.Time `gorm:"column:state_expiration"`
+	BaseModel
+}
+
+func (ExternalLoginState) TableName() string {
+	return "external_login_states"
+}
+
+func (s *ExternalLoginState) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ExpiredAt = time.Now().Add(time.Minute * ExternalLoginStateExpDuration)
+	return
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Invitation statuses, they're resolved from the invitation rather than stored
+const (
+	InvitationActive  = "active"
+	InvitationExpired = "expired"
+	InvitationUsedUp  = "used_up"
+	InvitationRevoked = "revoked"
+)
+
+// Invitation is the code inviting the users to register while the registration is
+// invite-only. The code is used up to its max uses until it expires or it's revoked,
+// only its hash is stored. The registered user is given the role of the invitation,
+// or the user role when it's not set. The invitation sent to an email is only usable
+// with the email.
+type Invitation struct {
+	ID        uint   `gorm:"column:invitation_id; primaryKey"`
+	CodeHash  string `gorm:"column:invitation_code; size:64; unique"`
+	Email     string `gorm:"size:100"`
+	RoleID    *uint
+	MaxUses   int
+	Uses      int
+	CreatedBy uint      `gorm:"index"`
+	ExpiredAt time.Time `gorm:"column:invitation_expiration"`
+	RevokedAt *time.Time
+	BaseModel
+}
+
+func (Invitation) TableName() string {
+	return "user_invitations"
+}
+
+// Status resolves the status of the invitation at the given time
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case i.Uses >= i.MaxUses:
+		return InvitationUsedUp
+	case !now.Before(i.ExpiredAt):
+		return InvitationExpired
+	default:
+		return InvitationActive
+	}
+}
+
+// IsUsableBy checks the invitation is active at the given time and is usable with the
+// email of the registering user
+func (i *Invitation) IsUsableBy(email string, now time.Time) bool {
+	return i.Status(now) == InvitationActive && (i.Email == "" || strings.EqualFold(i.Email, email))
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// KnownDevice is the device the user has logged in from, identified by the fingerprint
+// of its user agent and IP prefix. The report token is the single use token of the
+// "this wasn't me" link sent when the device is new, only its hash is stored.
+type KnownDevice struct {
+	ID              uint      `gorm:"column:known_device_id; primaryKey"`
+	UserID          uint      `gorm:"uniqueIndex:idx_user_known_device"`
+	Fingerprint     string    `gorm:"size:64; uniqueIndex:idx_user_known_device"`
+	UserAgent       string    `gorm:"size:250"`
+	IPPrefix        string    `gorm:"size:50"`
+	LastSeenAt      time.Time `gorm:"column:last_seen_at"`
+	ReportTokenHash string    `gorm:"size:64; unique"`
+	ReportExpiredAt time.Time `gorm:"column:report_token_expiration"`
+	BaseModel
+}
+
+func (KnownDevice) TableName() string {
+	return "user_known_devices"
+}
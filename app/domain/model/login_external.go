@@ -1,9 +1,16 @@
 package model
 
 type LoginExternal struct {
-	ID         uint             `gorm:"column:user_id; primaryKey"`
-	ProviderID uint             `gorm:"column:external_provider_id"`
+	ID         uint             `gorm:"column:login_external_id; primaryKey"`
+	UserID     uint             `gorm:"uniqueIndex:idx_login_external_user_provider"`
+	User       Account          `gorm:"foreignKey:UserID; references:ID"`
+	ProviderID uint             `gorm:"column:external_provider_id; uniqueIndex:idx_login_external_user_provider; uniqueIndex:idx_login_external_subject"`
 	Provider   ExternalProvider `gorm:"foreignKey:ProviderID; references:ID"`
-	Token      string           `gorm:"column:provider_token; size:100"`
+	Subject    string           `gorm:"column:provider_subject; size:255; uniqueIndex:idx_login_external_subject"`
+	Email      string           `gorm:"column:provider_email; size:100"`
 	BaseModel
 }
+
+func (LoginExternal) TableName() string {
+	return "user_login_external"
+}
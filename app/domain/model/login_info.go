@@ -1,8 +1,7 @@
 package model
 
 import (
-	"errors"
-
+	"github.com/budgetin-app/user-service/app/domain/apperror"
 	"gorm.io/gorm"
 )
 
@@ -30,10 +29,10 @@ func (i *LoginInfo) BeforeCreate(tx *gorm.DB) (err error) {
 	var info LoginInfo
 	if res := tx.Where("username = ?", i.Username).Or("email = ?", i.Email).Find(&info); res.RowsAffected > 0 {
 		if i.Username == info.Username {
-			return errors.New("username already exists")
+			return apperror.ErrUsernameExists
 		}
 		if i.Email == info.Email {
-			return errors.New("email already exists")
+			return apperror.ErrEmailExists
 		}
 
 	}
@@ -44,7 +43,7 @@ func (i *LoginInfo) BeforeUpdate(tx *gorm.DB) (err error) {
 	// Username should not be changed at any circumstances, because it will be
 	// used in the password hashing process
 	if tx.Statement.Changed("Username") {
-		return errors.New("username not allowed to be changed")
+		return apperror.ErrUsernameImmutable
 	}
 	return nil
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// MagicLink is the single use passwordless login token sent by email, only the
+// hashes of the token and of the nonce kept by the requesting device are stored
+type MagicLink struct {
+	ID         uint `gorm:"column:magic_link_id; primaryKey"`
+	UserID     uint `gorm:"index"`
+	User       Account
+	TokenHash  string    `gorm:"size:64; unique"`
+	NonceHash  string    `gorm:"size:64"`
+	ExpiredAt  time.Time `gorm:"column:magic_link_expiration"`
+	ConsumedAt *time.Time
+	BaseModel
+}
+
+func (MagicLink) TableName() string {
+	return "user_magic_links"
+}
+
+// IsUsable checks the link is neither consumed nor expired at the given time and is
+// bound to the nonce of the device consuming it
+func (l *MagicLink) IsUsable(nonceHash string, at time.Time) bool {
+	if l.ConsumedAt != nil || !l.ExpiredAt.After(at) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(l.NonceHash), []byte(nonceHash)) == 1
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Event types published to the other services
+const (
+	EventUserDeleted = "user.deleted"
+)
+
+// OutboxEvent is the event stored along with the change it describes, so the event
+// is never lost nor published for the rolled back change. It's published by the
+// scheduler until it's delivered.
+type OutboxEvent struct {
+	ID          uint              `gorm:"column:event_id; primaryKey"`
+	EventKey    string            `gorm:"size:36; unique"`
+	EventType   string            `gorm:"size:50"`
+	Payload     map[string]string `gorm:"serializer:json"`
+	Attempts    int
+	LastError   string     `gorm:"size:250"`
+	PublishedAt *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
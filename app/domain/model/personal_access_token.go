@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type PersonalAccessToken struct {
+	ID            uint `gorm:"column:token_id; primaryKey"`
+	UserID        uint `gorm:"index"`
+	User          Account
+	Name          string     `gorm:"column:token_name; size:50"`
+	Prefix        string     `gorm:"column:token_prefix; size:20; unique"`
+	TokenHash     string     `gorm:"size:250"`
+	TokenSalt     string     `gorm:"size:100"`
+	HashAlgorithm string     `gorm:"size:20"`
+	Scopes        []string   `gorm:"serializer:json"`
+	ExpiredAt     *time.Time `gorm:"column:token_expiration"`
+	LastUsedAt    *time.Time
+	RevokedAt     *time.Time
+	BaseModel
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "user_personal_access_tokens"
+}
+
+// IsActive checks the token is neither revoked nor expired at the given time
+func (t *PersonalAccessToken) IsActive(at time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiredAt == nil || t.ExpiredAt.After(at)
+}
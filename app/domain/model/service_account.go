@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type ServiceAccount struct {
+	ID          uint     `gorm:"column:service_account_id; primaryKey"`
+	Name        string   `gorm:"size:50; unique"`
+	Description string   `gorm:"size:250"`
+	APIKeys     []APIKey `gorm:"foreignKey:ServiceAccountID; references:ID"`
+	BaseModel
+}
+
+func (ServiceAccount) TableName() string {
+	return "service_accounts"
+}
+
+type APIKey struct {
+	ID               uint `gorm:"column:api_key_id; primaryKey"`
+	ServiceAccountID uint
+	ServiceAccount   ServiceAccount
+	Prefix           string     `gorm:"column:key_prefix; size:20; unique"`
+	KeyHash          string     `gorm:"size:250"`
+	KeySalt          string     `gorm:"size:100"`
+	HashAlgorithm    string     `gorm:"size:20"`
+	Scopes           []string   `gorm:"serializer:json"`
+	ExpiredAt        *time.Time `gorm:"column:key_expiration"`
+	LastUsedAt       *time.Time
+	RevokedAt        *time.Time
+	BaseModel
+}
+
+func (APIKey) TableName() string {
+	return "service_account_api_keys"
+}
+
+// IsActive checks the key is neither revoked nor expired at the given time
+func (k *APIKey) IsActive(at time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiredAt == nil || k.ExpiredAt.After(at)
+}
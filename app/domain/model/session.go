@@ -1,21 +1,55 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 const (
-	SessionExpDuration = 1 // hours
+	// DefaultSessionIdleTimeout is the duration the session is kept without being used
+	DefaultSessionIdleTimeout = time.Hour
+
+	// DefaultSessionMaxLifetime is the absolute lifetime of the session regardless of its use
+	DefaultSessionMaxLifetime = 24 * time.Hour
+
+	// DefaultRememberMeIdleTimeout and DefaultRememberMeMaxLifetime are the expiration
+	// of the long-lived "remember me" session
+	DefaultRememberMeIdleTimeout = 7 * 24 * time.Hour
+	DefaultRememberMeMaxLifetime = 30 * 24 * time.Hour
 )
 
+// SessionOptions are the options of the session requested by the login. The
+// "remember me" session is long-lived and bound to the secret kept by the device.
+type SessionOptions struct {
+	RememberMe bool
+	DeviceName string `gorm:"size:100"`
+}
+
+// Session is the login session of the user. The expiration slides with the use of the
+// session up to the maximum expiration, so the active user isn't logged out while the
+// idle session still expires after the idle timeout. The device secret is only set
+// when the "remember me" session is created, only its hash is stored. The authentication
+// time and methods are refreshed by the re-authentication of the sensitive operations.
+// The impersonation session is the short-lived session of the user issued to the admin
+// impersonating the user.
 type Session struct {
-	ID        uint `gorm:"column:session_id; primaryKey"`
-	UserID    uint
-	User      Account
-	Token     string    `gorm:"column:session_token; size:100; unique"`
-	ExpiredAt time.Time `gorm:"column:session_expiration"`
+	ID               uint `gorm:"column:session_id; primaryKey"`
+	UserID           uint
+	User             Account
+	Token            string    `gorm:"column:session_token; size:100; unique"`
+	ExpiredAt        time.Time `gorm:"column:session_expiration"`
+	MaxExpiredAt     time.Time `gorm:"column:session_max_expiration"`
+	LastSeenAt       time.Time `gorm:"column:last_seen_at"`
+	AuthenticatedAt  time.Time
+	AuthMethods      []string `gorm:"serializer:json"`
+	SessionOptions   `gorm:"embedded"`
+	DeviceSecret     string `gorm:"-"`
+	DeviceSecretHash string `gorm:"size:64"`
+	UserAgent        string `gorm:"size:250"`
+	IPAddress        string `gorm:"size:50"`
+	ImpersonatorID   *uint  `gorm:"index"`
 	BaseModel
 }
 
@@ -24,6 +58,34 @@ func (Session) TableName() string {
 }
 
 func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
-	s.ExpiredAt = time.Now().Add(time.Hour * SessionExpDuration)
+	now := time.Now()
+	if s.AuthenticatedAt.IsZero() {
+		s.AuthenticatedAt = now
+	}
+	if s.MaxExpiredAt.IsZero() {
+		s.MaxExpiredAt = now.Add(DefaultSessionMaxLifetime)
+	}
+	if s.ExpiredAt.IsZero() {
+		s.Extend(now, DefaultSessionIdleTimeout)
+	}
 	return
 }
+
+// Extend marks the session as seen and slides the expiration to the idle timeout
+// since then, the expiration never exceeds the maximum expiration
+func (s *Session) Extend(seenAt time.Time, idleTimeout time.Duration) {
+	s.LastSeenAt = seenAt
+	s.ExpiredAt = seenAt.Add(idleTimeout)
+	if s.ExpiredAt.After(s.MaxExpiredAt) {
+		s.ExpiredAt = s.MaxExpiredAt
+	}
+}
+
+// Reauthenticate marks the session as authenticated again with the method, the method
+// is added to the methods the session is authenticated with
+func (s *Session) Reauthenticate(authenticatedAt time.Time, method string) {
+	s.AuthenticatedAt = authenticatedAt
+	if !slices.Contains(s.AuthMethods, method) {
+		s.AuthMethods = append(s.AuthMethods, method)
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// UserSummary is the account of the user joined with its login info, role and email
+// verification for the admins looking the users up. It isn't stored, the user without
+// the password login has no username, email or verification status.
+type UserSummary struct {
+	ID                 uint `gorm:"column:user_id"`
+	Username           string
+	Email              string
+	RoleID             uint
+	RoleName           string
+	Status             string
+	VerificationStatus string
+	CreatedAt          time.Time
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+// WebAuthn ceremony types
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+	WebAuthnMFA          = "mfa"
+	WebAuthnReauth       = "reauthentication"
+)
+
+// WebAuthnCredential is the passkey registered by the user, the credential ID is
+// stored base64url encoded
+type WebAuthnCredential struct {
+	ID              uint `gorm:"column:webauthn_credential_id; primaryKey"`
+	UserID          uint `gorm:"index"`
+	User            Account
+	Name            string   `gorm:"column:credential_name; size:50"`
+	CredentialID    string   `gorm:"size:1400; unique"`
+	PublicKey       []byte   `gorm:"column:credential_public_key"`
+	AttestationType string   `gorm:"size:50"`
+	AAGUID          []byte   `gorm:"column:aaguid"`
+	SignCount       uint32   `gorm:"column:sign_count"`
+	Transports      []string `gorm:"serializer:json"`
+	BackupEligible  bool
+	BackupState     bool
+	LastUsedAt      *time.Time
+	BaseModel
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "user_webauthn_credentials"
+}
+
+// WebAuthnCeremony is the pending registration or assertion ceremony identified by
+// its challenge, the user isn't set for the discoverable login. The second factor
+// ceremony keeps the login method of the first factor along with its session options.
+type WebAuthnCeremony struct {
+	ID             uint                 `gorm:"column:ceremony_id; primaryKey"`
+	Challenge      string               `gorm:"size:100; unique"`
+	Type           string               `gorm:"column:ceremony_type; size:20"`
+	UserID         *uint                `gorm:"index"`
+	Session        webauthn.SessionData `gorm:"serializer:json"`
+	SessionOptions SessionOptions       `gorm:"embedded"`
+	FirstFactor    string               `gorm:"size:20"`
+	ExpiredAt      time.Time            `gorm:"column:ceremony_expiration"`
+	BaseModel
+}
+
+func (WebAuthnCeremony) TableName() string {
+	return "webauthn_ceremonies"
+}
+
+func (c *WebAuthnCeremony) BeforeCreate(tx *gorm.DB) (err error) {
+	// The ceremony expires along with the session data given by the relying party
+	c.ExpiredAt = c.Session.Expires
+	return
+}
@@ -0,0 +1,62 @@
+package principal
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+)
+
+// Type is the kind of the authenticated caller
+type Type string
+
+const (
+	TypeUser           Type = "user"
+	TypeServiceAccount Type = "service_account"
+)
+
+// Principal is the authenticated caller of the request, either a user with a
+// session or a service account with an API key. The session and the time the user
+// authenticated with its methods are only known for the session callers. The impersonator
+// is the admin acting as the user with the impersonation session.
+type Principal struct {
+	Type            Type
+	ID              uint
+	Name            string
+	Scopes          []string
+	SessionID       uint
+	AuthenticatedAt time.Time
+	AuthMethods     []string
+	ImpersonatorID  uint
+}
+
+// HasScope checks the principal is granted the scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, constant.ScopeAll) || slices.Contains(p.Scopes, scope)
+}
+
+// IsImpersonated checks the principal is the user impersonated by an admin
+func (p *Principal) IsImpersonated() bool {
+	return p.ImpersonatorID != 0
+}
+
+// AuthenticatedWithin checks the principal authenticated within the max age, the
+// principal without the authentication time is never recently authenticated
+func (p *Principal) AuthenticatedWithin(maxAge time.Duration) bool {
+	return !p.AuthenticatedAt.IsZero() && time.Since(p.AuthenticatedAt) <= maxAge
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of the context carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext get the principal of the request, it's not found when the request
+// is not authenticated
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
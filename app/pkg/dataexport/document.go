@@ -0,0 +1,131 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the version of the document schema, it's increased whenever a field
+// is removed or changes its meaning so the consumers can tell the documents apart
+const SchemaVersion = "1"
+
+// Formats of the exported file
+const (
+	FormatJSON = "json"
+	FormatZip  = "zip"
+)
+
+// DocumentFileName is the name of the JSON document, it's also the name of the document
+// within the zip archive
+const DocumentFileName = "personal-data.json"
+
+// Document is the personal data of the user. The secrets (e.g. the password hash, the
+// tokens and the public keys) are never exported.
+type Document struct {
+	SchemaVersion    string            `json:"schema_version"`
+	GeneratedAt      time.Time         `json:"generated_at"`
+	Account          Account           `json:"account"`
+	Login            *Login            `json:"login,omitempty"`
+	Sessions         []Session         `json:"sessions"`
+	ExternalAccounts []ExternalAccount `json:"external_accounts"`
+	Passkeys         []Passkey         `json:"passkeys"`
+	AuditEvents      []AuditEvent      `json:"audit_events"`
+}
+
+type Account struct {
+	UserID              uint       `json:"user_id"`
+	UserName            *string    `json:"user_name"`
+	Gender              *string    `json:"gender"`
+	DateOfBirth         time.Time  `json:"date_of_birth"`
+	RoleID              uint       `json:"role_id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// Login is the password login of the user along with the verification of its email
+type Login struct {
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	EmailVerification string     `json:"email_verification"`
+	EmailVerifiedAt   *time.Time `json:"email_verified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type Session struct {
+	DeviceName      string    `json:"device_name"`
+	UserAgent       string    `json:"user_agent"`
+	IPAddress       string    `json:"ip_address"`
+	RememberMe      bool      `json:"remember_me"`
+	AuthMethods     []string  `json:"auth_methods"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ExternalAccount struct {
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+type Passkey struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type AuditEvent struct {
+	EventType string            `json:"event_type"`
+	Outcome   string            `json:"outcome"`
+	Reason    string            `json:"reason,omitempty"`
+	ActorType string            `json:"actor_type"`
+	IPAddress string            `json:"ip_address"`
+	UserAgent string            `json:"user_agent"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// IsFormatSupported checks the file format of the export is supported
+func IsFormatSupported(format string) bool {
+	return format == FormatJSON || format == FormatZip
+}
+
+// Encode encodes the document into the file of the format, it returns the content with
+// the file name and the content type of the file
+func Encode(document *Document, format string) (content []byte, fileName string, contentType string, err error) {
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	switch format {
+	case FormatJSON:
+		return data, DocumentFileName, "application/json", nil
+	case FormatZip:
+		var archive bytes.Buffer
+		writer := zip.NewWriter(&archive)
+		file, err := writer.CreateHeader(&zip.FileHeader{
+			Name:     DocumentFileName,
+			Method:   zip.Deflate,
+			Modified: document.GeneratedAt,
+		})
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to create archive file: %w", err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return nil, "", "", fmt.Errorf("failed to write archive file: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, "", "", fmt.Errorf("failed to close archive: %w", err)
+		}
+		return archive.Bytes(), "personal-data.zip", "application/zip", nil
+	default:
+		return nil, "", "", fmt.Errorf("unsupported export format '%s'", format)
+	}
+}
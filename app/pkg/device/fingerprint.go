@@ -0,0 +1,37 @@
+package device
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+)
+
+const (
+	// IPv4PrefixLength and IPv6PrefixLength are the network prefixes identifying the
+	// device location, so the address changes within the same network keep the device known
+	IPv4PrefixLength = 24
+	IPv6PrefixLength = 48
+)
+
+// IPPrefix masks the IP address into its network prefix in the CIDR notation
+// (e.g. '203.0.113.0/24'), the unparsable address is returned as is
+func IPPrefix(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ip
+	}
+	if ipv4 := parsed.To4(); ipv4 != nil {
+		network := net.IPNet{IP: ipv4.Mask(net.CIDRMask(IPv4PrefixLength, 32)), Mask: net.CIDRMask(IPv4PrefixLength, 32)}
+		return network.String()
+	}
+	network := net.IPNet{IP: parsed.Mask(net.CIDRMask(IPv6PrefixLength, 128)), Mask: net.CIDRMask(IPv6PrefixLength, 128)}
+	return network.String()
+}
+
+// Fingerprint identifies the device by its user agent and its IP prefix, it's the
+// SHA-256 hex string so it can be stored and compared without the raw values
+func Fingerprint(userAgent string, ip string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(userAgent) + "\n" + IPPrefix(ip)))
+	return hex.EncodeToString(hash[:])
+}
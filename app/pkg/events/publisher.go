@@ -0,0 +1,102 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureHeader is the header of the HMAC-SHA256 signature of the webhook body
+	SignatureHeader = "X-Event-Signature"
+
+	// DefaultWebhookTimeout is the timeout of delivering a single event to the webhook
+	DefaultWebhookTimeout = 10 * time.Second
+)
+
+// Event is the domain event published to the other services, e.g. to purge the data
+// of the deleted user. The ID is unique per event so the consumers can deduplicate
+// the redelivered events.
+type Event struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Data       map[string]string `json:"data"`
+}
+
+// Publisher delivers the events to the other services
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// WebhookPublisher posts the event as JSON to the webhook URL, the body is signed with
+// the secret when it's configured
+type WebhookPublisher struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, secret string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(p.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(p.secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign computes the hex encoded HMAC-SHA256 of the body with the secret
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewPublisherFromEnv creates the publisher configured by the environment variables, no
+// publisher is created without the 'EVENT_WEBHOOK_URL' so the events are kept pending
+// instead of being dropped, e.g. the deleted user's data would never be purged
+func NewPublisherFromEnv() Publisher {
+	url := env.GetenvOrDefault("EVENT_WEBHOOK_URL", "")
+	if url == "" {
+		log.Warn("EVENT_WEBHOOK_URL is not set, events are kept pending until it's configured")
+		return nil
+	}
+
+	log.WithField("url", url).Info("Event webhook publisher loaded")
+	return NewWebhookPublisher(
+		url,
+		env.GetenvOrDefault("EVENT_WEBHOOK_SECRET", ""),
+		env.GetDurationOrDefault("EVENT_WEBHOOK_TIMEOUT", DefaultWebhookTimeout),
+	)
+}
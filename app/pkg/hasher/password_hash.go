@@ -6,7 +6,9 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -42,6 +44,9 @@ func (h PasswordHasherImpl) GenerateHashPassword(password []byte, salt []byte) (
 		return nil, errors.New("can't hash an empty salt")
 	}
 
+	// Record the hashing duration of the algorithm
+	defer metrics.ObserveHashDuration(string(h.algorithm), "generate", time.Now())
+
 	// Create a salted password
 	saltedPassword := append([]byte(password), salt...)
 
@@ -93,6 +98,9 @@ func (h PasswordHasherImpl) VerifyPassword(hashedPassword []byte, password []byt
 		return false, fmt.Errorf("mismatched algorithm stored '%s', found for verifying '%s'", storedAlgorithm, h.algorithm)
 	}
 
+	// Record the verifying duration of the algorithm
+	defer metrics.ObserveHashDuration(string(h.algorithm), "verify", time.Now())
+
 	// Create a salted password
 	saltedPassword := append([]byte(password), salt...)
 
@@ -1,6 +1,9 @@
 package env
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 func GetenvOrDefault(key string, defaultValue string) string {
 	value := os.Getenv(key)
@@ -9,3 +12,13 @@ func GetenvOrDefault(key string, defaultValue string) string {
 	}
 	return value
 }
+
+// GetDurationOrDefault parse the duration value (e.g. '15s', '1h') of the environment
+// variable, the default value is used when it's empty or invalid
+func GetDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
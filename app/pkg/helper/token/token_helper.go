@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"strings"
 )
 
 // SessionTokenLength should be an even number
@@ -26,3 +28,91 @@ func GenerateSessionToken() (string, error) {
 	// Encode the hash to base64 format
 	return base64.URLEncoding.EncodeToString(hash[:])[:length], nil
 }
+
+const (
+	// Identifiers are the leading part of the prefixed tokens, so leaked tokens are easily recognized
+	APIKeyIdentifier              = "bgk"
+	PersonalAccessTokenIdentifier = "bgp"
+
+	// PrefixedTokenPrefixLength is the length of the public prefix identifying the token
+	PrefixedTokenPrefixLength = 8
+
+	// PrefixedTokenSecretLength is the length of the secret part of the token
+	PrefixedTokenSecretLength = 40
+)
+
+// GenerateAPIKey generates the API key in the 'bgk_<prefix>_<secret>' format, the
+// prefix identifies the key and only the secret should be hashed for storing
+func GenerateAPIKey() (key string, prefix string, secret string, err error) {
+	return generatePrefixedToken(APIKeyIdentifier)
+}
+
+// ParseAPIKey splits the API key into its prefix and secret
+func ParseAPIKey(key string) (prefix string, secret string, ok bool) {
+	return parsePrefixedToken(APIKeyIdentifier, key)
+}
+
+// GeneratePersonalAccessToken generates the personal access token in the
+// 'bgp_<prefix>_<secret>' format, only the secret should be hashed for storing
+func GeneratePersonalAccessToken() (token string, prefix string, secret string, err error) {
+	return generatePrefixedToken(PersonalAccessTokenIdentifier)
+}
+
+// ParsePersonalAccessToken splits the personal access token into its prefix and secret
+func ParsePersonalAccessToken(token string) (prefix string, secret string, ok bool) {
+	return parsePrefixedToken(PersonalAccessTokenIdentifier, token)
+}
+
+// IsPersonalAccessToken checks the token is a personal access token rather than a session token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenIdentifier+"_")
+}
+
+// generatePrefixedToken generates the token in the '<identifier>_<prefix>_<secret>' format
+func generatePrefixedToken(identifier string) (token string, prefix string, secret string, err error) {
+	if prefix, err = randomString(PrefixedTokenPrefixLength); err != nil {
+		return "", "", "", err
+	}
+	if secret, err = randomString(PrefixedTokenSecretLength); err != nil {
+		return "", "", "", err
+	}
+	return strings.Join([]string{identifier, prefix, secret}, "_"), prefix, secret, nil
+}
+
+// parsePrefixedToken splits the token of the identifier into its prefix and secret
+func parsePrefixedToken(identifier string, token string) (prefix string, secret string, ok bool) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != identifier ||
+		len(parts[1]) != PrefixedTokenPrefixLength || len(parts[2]) != PrefixedTokenSecretLength {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// randomString generates a random alphanumeric string with the given length, the
+// bytes above the largest multiple of the alphabet size are skipped to avoid bias
+func randomString(length int) (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	const limit = 256 - 256%len(alphabet)
+
+	result := make([]byte, 0, length)
+	randomBytes := make([]byte, length)
+	for len(result) < length {
+		if _, err := rand.Read(randomBytes); err != nil {
+			return "", err
+		}
+		for _, b := range randomBytes {
+			if int(b) < limit && len(result) < length {
+				result = append(result, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(result), nil
+}
+
+// HashToken hashes the high entropy token with SHA-256 into the hex string, so
+// the token can be looked up by its hash
+func HashToken(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
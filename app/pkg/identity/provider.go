@@ -0,0 +1,150 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrInvalidIDToken is returned when the ID token of the provider can't be verified
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Identity is the verified identity of the user on the external provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Authorization is the pending authorization request, the verifier and nonce
+// should be kept by the service until the provider redirects back with the code
+type Authorization struct {
+	URL      string
+	State    string
+	Verifier string
+	Nonce    string
+}
+
+// Provider is the OAuth2/OIDC identity provider, the discovery document is fetched
+// on the first use so the service starts even when the provider is unreachable
+type Provider struct {
+	config ProviderConfig
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider creates the provider of the configuration
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the registered name of the provider
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// Issuer returns the issuer URL of the provider
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// discover fetches the discovery document and the JWKS location of the provider once
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.config.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover provider '%s': %w", p.config.Name, err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.config.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+// Authorize creates the authorization code request with PKCE (S256), the state
+// and nonce are generated randomly
+func (p *Provider) Authorize(ctx context.Context) (*Authorization, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := &Authorization{
+		State:    oauth2.GenerateVerifier(),
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    oauth2.GenerateVerifier(),
+	}
+
+	options := []oauth2.AuthCodeOption{
+		oauth2.S256ChallengeOption(authorization.Verifier),
+		oidc.Nonce(authorization.Nonce),
+	}
+	for key, value := range p.config.AuthParams {
+		options = append(options, oauth2.SetAuthURLParam(key, value))
+	}
+	authorization.URL = config.AuthCodeURL(authorization.State, options...)
+
+	return authorization, nil
+}
+
+// Exchange redeems the authorization code and returns the identity of the verified
+// ID token, the token signature is validated against the provider JWKS
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Identity, error) {
+	config, idTokenVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing from the token response", ErrInvalidIDToken)
+	}
+	idToken, err := idTokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	return &Identity{
+		Subject: idToken.Subject,
+		Email:   claims.Email,
+		// Apple returns the boolean claim as a string
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Provider types, the google and apple types have the issuer and the scopes preset
+const (
+	TypeGoogle = "google"
+	TypeApple  = "apple"
+	TypeOIDC   = "oidc"
+)
+
+// ProviderConfig is the configuration of the external identity provider, the
+// values are expanded with the environment variables (e.g. '${GOOGLE_CLIENT_SECRET}')
+type ProviderConfig struct {
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Issuer       string            `json:"issuer"`
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret"`
+	RedirectURL  string            `json:"redirect_url"`
+	Scopes       []string          `json:"scopes"`
+	AuthParams   map[string]string `json:"auth_params"`
+}
+
+// LoadProviderConfigs reads the provider configurations from the JSON file, no
+// provider is configured when the file doesn't exist
+func LoadProviderConfigs(path string) ([]ProviderConfig, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read providers file: %w", err)
+	}
+
+	var configs []ProviderConfig
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(content))), &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse providers file: %w", err)
+	}
+
+	for i := range configs {
+		if err := configs[i].applyPreset(); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// applyPreset fills the defaults of the provider type and validates the configuration
+func (c *ProviderConfig) applyPreset() error {
+	switch c.Type {
+	case TypeGoogle:
+		c.Issuer = valueOrDefault(c.Issuer, "https://accounts.google.com")
+	case TypeApple:
+		c.Issuer = valueOrDefault(c.Issuer, "https://appleid.apple.com")
+		// Apple only returns the authorization response as a form post when the email
+		// scope is requested, the redirect URL should forward the code and state
+		if c.AuthParams == nil {
+			c.AuthParams = map[string]string{"response_mode": "form_post"}
+		}
+	case TypeOIDC:
+	default:
+		return fmt.Errorf("provider '%s' has unsupported type '%s'", c.Name, c.Type)
+	}
+
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile"}
+		if c.Type == TypeApple {
+			c.Scopes = []string{"openid", "email"}
+		}
+	}
+
+	if c.Name == "" || c.Issuer == "" || c.ClientID == "" || c.RedirectURL == "" {
+		return fmt.Errorf("provider '%s' requires the name, issuer, client_id and redirect_url", c.Name)
+	}
+	return nil
+}
+
+func valueOrDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,55 @@
+package identity
+
+import (
+	"slices"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultProvidersFile is the path of the providers configuration file
+const DefaultProvidersFile = "./external_providers.json"
+
+// Registry holds the configured external identity providers by their name
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry creates the registry of the provider configurations
+func NewRegistry(configs []ProviderConfig) *Registry {
+	registry := &Registry{providers: make(map[string]*Provider, len(configs))}
+	for _, config := range configs {
+		registry.providers[config.Name] = NewProvider(config)
+	}
+	return registry
+}
+
+// NewRegistryFromEnv creates the registry of the providers configured in the
+// EXTERNAL_PROVIDERS_FILE
+func NewRegistryFromEnv() *Registry {
+	path := env.GetenvOrDefault("EXTERNAL_PROVIDERS_FILE", DefaultProvidersFile)
+	configs, err := LoadProviderConfigs(path)
+	if err != nil {
+		log.Fatalf("failed to load external providers: %v", err)
+	}
+
+	registry := NewRegistry(configs)
+	log.WithFields(log.Fields{"providers": registry.Names()}).Info("External identity providers loaded")
+	return registry
+}
+
+// Provider get the provider by its name
+func (r *Registry) Provider(name string) (*Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names returns the sorted names of the registered providers
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -20,16 +20,7 @@ func InitLogger() {
 	// Configure the log format
 	log.SetLevel(getLevel())
 	log.SetReportCaller(true)
-	log.SetFormatter(&nested.Formatter{
-		HideKeys:              true,
-		ShowFullLevel:         false,
-		TrimMessages:          true,
-		NoColors:              isProduction,
-		TimestampFormat:       time.StampMilli,
-		FieldsOrder:           []string{"component", "category"},
-		CallerFirst:           true,
-		CustomCallerFormatter: getCustomCallerFormatter,
-	})
+	log.SetFormatter(getFormatter())
 
 	// Redirect logs to a file in production
 	if isProduction {
@@ -74,6 +65,30 @@ func getLevel() log.Level {
 	return log.InfoLevel
 }
 
+// getFormatter get the log formatter according to the LOG_FORMAT environment
+// variable, the structured JSON format is used for log aggregation
+func getFormatter() log.Formatter {
+	if os.Getenv("LOG_FORMAT") == "json" {
+		return &log.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			CallerPrettyfier: func(caller *runtime.Frame) (string, string) {
+				return caller.Function, fmt.Sprintf("%s:%d", caller.File, caller.Line)
+			},
+		}
+	}
+
+	return &nested.Formatter{
+		HideKeys:              true,
+		ShowFullLevel:         false,
+		TrimMessages:          true,
+		NoColors:              isProduction,
+		TimestampFormat:       time.StampMilli,
+		FieldsOrder:           []string{"component", "category"},
+		CallerFirst:           true,
+		CustomCallerFormatter: getCustomCallerFormatter,
+	}
+}
+
 // getCustomCallerFormatter format the caller function file path and line
 func getCustomCallerFormatter(caller *runtime.Frame) string {
 	// On production APP_ENV the caller file will present,
@@ -2,18 +2,31 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"text/template"
 	"time"
 
 	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/mail.v2"
 )
 
-const EmailVerificationTemplatePath = "./app/pkg/mailer/email_verification_template.html"
+const (
+	EmailVerificationTemplatePath = "./app/pkg/mailer/email_verification_template.html"
+	MagicLinkTemplatePath         = "./app/pkg/mailer/magic_link_template.html"
+	NewDeviceTemplatePath         = "./app/pkg/mailer/new_device_template.html"
+	PasswordResetTemplatePath     = "./app/pkg/mailer/password_reset_template.html"
+	AccountDeletionTemplatePath   = "./app/pkg/mailer/account_deletion_template.html"
+	DataExportTemplatePath        = "./app/pkg/mailer/data_export_template.html"
+	InvitationTemplatePath        = "./app/pkg/mailer/invitation_template.html"
+)
 
 // EmailVerificationData holds data for email verification template in 'email_verification_template.html'
 type EmailVerificationData struct {
@@ -24,14 +37,106 @@ type EmailVerificationData struct {
 	Expiration       int
 }
 
+// MagicLinkData holds data for magic link template in 'magic_link_template.html'
+type MagicLinkData struct {
+	User         string
+	LoginLink    string
+	SupportEmail string
+	CompanyName  string
+	Expiration   int
+}
+
+// NewDeviceData holds data for new device notification template in 'new_device_template.html'
+type NewDeviceData struct {
+	User         string
+	LoginTime    string
+	UserAgent    string
+	IPPrefix     string
+	ReportLink   string
+	SupportEmail string
+	CompanyName  string
+	Expiration   int
+}
+
+// PasswordResetData holds data for password reset template in 'password_reset_template.html'
+type PasswordResetData struct {
+	User         string
+	ResetLink    string
+	SupportEmail string
+	CompanyName  string
+	Expiration   int
+}
+
+// AccountDeletionData holds data for account deletion template in 'account_deletion_template.html'
+type AccountDeletionData struct {
+	User         string
+	DeletionTime string
+	LoginLink    string
+	SupportEmail string
+	CompanyName  string
+}
+
+// DataExportData holds data for data export template in 'data_export_template.html'
+type DataExportData struct {
+	User         string
+	DownloadLink string
+	SupportEmail string
+	CompanyName  string
+	Expiration   int
+}
+
+// InvitationData holds data for invitation template in 'invitation_template.html'
+type InvitationData struct {
+	RegistrationLink string
+	InvitationCode   string
+	SupportEmail     string
+	CompanyName      string
+	Expiration       int
+}
+
 // RenderEmailVerificationTemplate renders the email verification template
 func RenderEmailVerificationTemplate(data *EmailVerificationData) (string, error) {
-	templateFile, err := os.ReadFile(EmailVerificationTemplatePath)
+	return renderTemplate("email_verification", EmailVerificationTemplatePath, data)
+}
+
+// RenderMagicLinkTemplate renders the magic link template
+func RenderMagicLinkTemplate(data *MagicLinkData) (string, error) {
+	return renderTemplate("magic_link", MagicLinkTemplatePath, data)
+}
+
+// RenderNewDeviceTemplate renders the new device notification template
+func RenderNewDeviceTemplate(data *NewDeviceData) (string, error) {
+	return renderTemplate("new_device", NewDeviceTemplatePath, data)
+}
+
+// RenderPasswordResetTemplate renders the password reset template
+func RenderPasswordResetTemplate(data *PasswordResetData) (string, error) {
+	return renderTemplate("password_reset", PasswordResetTemplatePath, data)
+}
+
+// RenderAccountDeletionTemplate renders the account deletion template
+func RenderAccountDeletionTemplate(data *AccountDeletionData) (string, error) {
+	return renderTemplate("account_deletion", AccountDeletionTemplatePath, data)
+}
+
+// RenderDataExportTemplate renders the data export template
+func RenderDataExportTemplate(data *DataExportData) (string, error) {
+	return renderTemplate("data_export", DataExportTemplatePath, data)
+}
+
+// RenderInvitationTemplate renders the invitation template
+func RenderInvitationTemplate(data *InvitationData) (string, error) {
+	return renderTemplate("invitation", InvitationTemplatePath, data)
+}
+
+// renderTemplate renders the HTML template file with the data
+func renderTemplate(name string, path string, data interface{}) (string, error) {
+	templateFile, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read template file: %w", err)
 	}
 
-	temp, err := template.New("email_verification").Parse(string(templateFile))
+	temp, err := template.New(name).Parse(string(templateFile))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -45,23 +150,23 @@ func RenderEmailVerificationTemplate(data *EmailVerificationData) (string, error
 }
 
 // ComposeEmailMessage composes the email message
-func ComposeEmailMessage(emailTo string, body string) *mail.Message {
+func ComposeEmailMessage(emailTo string, subject string, body string) *mail.Message {
 	m := mail.NewMessage()
 	m.SetHeaders(map[string][]string{
 		"From":    {m.FormatAddress(os.Getenv("SMTP_SENDER_EMAIL"), os.Getenv("SMTP_SENDER_ALIAS"))},
 		"To":      {emailTo},
-		"Subject": {"Email Verification"},
+		"Subject": {subject},
 	})
 	m.SetBody("text/html", body)
 	return m
 }
 
-// SendEmail sends the email
-func SendEmail(emailTo string, body string) error {
+// newDialer creates the SMTP dialer according to the SMTP configuration
+func newDialer() (*mail.Dialer, error) {
 	// Settings for SMTP server
 	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
 	if err != nil {
-		return fmt.Errorf("invalid SMTP port: %w", err)
+		return nil, fmt.Errorf("invalid SMTP port: %w", err)
 	}
 	dial := mail.NewDialer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_SENDER_EMAIL"), os.Getenv("SMTP_SENDER_PASS"))
 
@@ -69,8 +174,56 @@ func SendEmail(emailTo string, body string) error {
 	// In production, this should be set to false.
 	dial.TLSConfig = &tls.Config{InsecureSkipVerify: os.Getenv("APP_ENV") == "local"}
 
+	return dial, nil
+}
+
+// CheckConnection verifies the SMTP server is reachable and accepts the sender credentials,
+// the connection is bounded by the deadline of the context so a hung server doesn't block
+// the caller past its timeout
+func CheckConnection(ctx context.Context) error {
+	dial, err := newDialer()
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return ctx.Err()
+		}
+		// The timeout bounds both the dial and the SMTP handshake
+		dial.Timeout = timeout
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		sender, err := dial.Dial()
+		if err != nil {
+			result <- fmt.Errorf("failed to connect SMTP server: %w", err)
+			return
+		}
+		result <- sender.Close()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("failed to connect SMTP server: %w", ctx.Err())
+	}
+}
+
+// SendEmail sends the email
+func SendEmail(ctx context.Context, emailTo string, subject string, body string) (err error) {
+	_, span := tracer.Start(ctx, "mailer.SendEmail", attribute.String("smtp.host", os.Getenv("SMTP_HOST")))
+	defer func() { tracer.End(span, err) }()
+
+	dial, err := newDialer()
+	if err != nil {
+		return err
+	}
+
 	// Send the email
-	if err := dial.DialAndSend(ComposeEmailMessage(emailTo, body)); err != nil {
+	if err := dial.DialAndSend(ComposeEmailMessage(emailTo, subject, body)); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -78,11 +231,11 @@ func SendEmail(emailTo string, body string) error {
 }
 
 // SendEmailVerification sends an email with the specified mail data
-func SendEmailVerification(emailTo string, userName string, verificationToken string, expiredAt time.Time) error {
-	// TODO: Later 'VerificationLink', 'SupportEmail', 'CompanyName', and 'Expiration' will be retrieved from the configuration (database)
+func SendEmailVerification(ctx context.Context, emailTo string, userName string, verificationToken string, expiredAt time.Time) error {
+	// TODO: Later 'SupportEmail', 'CompanyName', and 'Expiration' will be retrieved from the configuration (database)
 	data := EmailVerificationData{
 		User:             userName,
-		VerificationLink: fmt.Sprintf("http://localhost:8080/email-verification/%s", verificationToken),
+		VerificationLink: fmt.Sprintf("%s/email-verification/%s", env.GetenvOrDefault("APP_BASE_URL", "http://localhost:8081"), verificationToken),
 		SupportEmail:     "Andresuryana17@gmail.com",
 		CompanyName:      "Budgetin",
 		Expiration:       model.TokenExpDuration,
@@ -93,9 +246,183 @@ func SendEmailVerification(emailTo string, userName string, verificationToken st
 		return fmt.Errorf("failed to render email template: %w", err)
 	}
 
-	if err := SendEmail(emailTo, body); err != nil {
+	if err := SendEmail(ctx, emailTo, "Email Verification", body); err != nil {
 		return fmt.Errorf("error sending email: %w", err)
 	}
 
 	return nil
 }
+
+// SendMagicLink sends an email with the passwordless login link
+func SendMagicLink(ctx context.Context, emailTo string, userName string, loginToken string, expiration time.Duration) error {
+	link, err := tokenLink(env.GetenvOrDefault("MAGIC_LINK_URL", "http://localhost:3000/magic-link"), loginToken)
+	if err != nil {
+		return fmt.Errorf("invalid magic link url: %w", err)
+	}
+
+	data := MagicLinkData{
+		User:         userName,
+		LoginLink:    link,
+		SupportEmail: "Andresuryana17@gmail.com",
+		CompanyName:  "Budgetin",
+		Expiration:   int(expiration.Minutes()),
+	}
+
+	body, err := RenderMagicLinkTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "Your Login Link", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendNewDeviceNotification sends an email notifying the login from the unknown device,
+// the report link lets the user revoke the sessions when the login wasn't theirs
+func SendNewDeviceNotification(ctx context.Context, emailTo string, userName string, loginTime time.Time, userAgent string, ipPrefix string, reportToken string, expiration time.Duration) error {
+	link, err := tokenLink(env.GetenvOrDefault("UNRECOGNIZED_LOGIN_URL", "http://localhost:3000/unrecognized-login"), reportToken)
+	if err != nil {
+		return fmt.Errorf("invalid unrecognized login url: %w", err)
+	}
+
+	data := NewDeviceData{
+		User:         userName,
+		LoginTime:    loginTime.UTC().Format(time.RFC1123),
+		UserAgent:    userAgent,
+		IPPrefix:     ipPrefix,
+		ReportLink:   link,
+		SupportEmail: "Andresuryana17@gmail.com",
+		CompanyName:  "Budgetin",
+		Expiration:   int(expiration.Hours()),
+	}
+
+	body, err := RenderNewDeviceTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "New Login to Your Account", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendPasswordReset sends an email with the password reset link
+func SendPasswordReset(ctx context.Context, emailTo string, userName string, recoveryToken string, expiration time.Duration) error {
+	link, err := tokenLink(env.GetenvOrDefault("PASSWORD_RESET_URL", "http://localhost:3000/password-reset"), recoveryToken)
+	if err != nil {
+		return fmt.Errorf("invalid password reset url: %w", err)
+	}
+
+	data := PasswordResetData{
+		User:         userName,
+		ResetLink:    link,
+		SupportEmail: "Andresuryana17@gmail.com",
+		CompanyName:  "Budgetin",
+		Expiration:   int(expiration.Minutes()),
+	}
+
+	body, err := RenderPasswordResetTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "Reset Your Password", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendAccountDeletionScheduled sends an email confirming the account is deleted at the
+// scheduled time unless the user logs in before
+func SendAccountDeletionScheduled(ctx context.Context, emailTo string, userName string, scheduledAt time.Time) error {
+	data := AccountDeletionData{
+		User:         userName,
+		DeletionTime: scheduledAt.UTC().Format("Jan 2, 2006 at 15:04 MST"),
+		LoginLink:    env.GetenvOrDefault("LOGIN_URL", "http://localhost:3000/login"),
+		SupportEmail: "Andresuryana17@gmail.com",
+		CompanyName:  "Budgetin",
+	}
+
+	body, err := RenderAccountDeletionTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "Your Account Is Scheduled for Deletion", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendDataExportReady sends an email with the download link of the personal data export
+func SendDataExportReady(ctx context.Context, emailTo string, userName string, downloadToken string, expiration time.Duration) error {
+	link, err := tokenLink(env.GetenvOrDefault("DATA_EXPORT_URL", "http://localhost:3000/data-export"), downloadToken)
+	if err != nil {
+		return fmt.Errorf("invalid data export url: %w", err)
+	}
+
+	data := DataExportData{
+		User:         userName,
+		DownloadLink: link,
+		SupportEmail: "Andresuryana17@gmail.com",
+		CompanyName:  "Budgetin",
+		Expiration:   int(expiration.Hours()),
+	}
+
+	body, err := RenderDataExportTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "Your Data Export Is Ready", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendInvitation sends an email inviting to register with the invitation code
+func SendInvitation(ctx context.Context, emailTo string, invitationCode string, expiration time.Duration) error {
+	link, err := tokenLink(env.GetenvOrDefault("REGISTRATION_URL", "http://localhost:3000/register"), invitationCode)
+	if err != nil {
+		return fmt.Errorf("invalid registration url: %w", err)
+	}
+
+	data := InvitationData{
+		RegistrationLink: link,
+		InvitationCode:   invitationCode,
+		SupportEmail:     "Andresuryana17@gmail.com",
+		CompanyName:      "Budgetin",
+		Expiration:       int(expiration.Hours()),
+	}
+
+	body, err := RenderInvitationTemplate(&data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	if err := SendEmail(ctx, emailTo, "You're Invited to Budgetin", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// tokenLink sets the token as the 'token' query parameter of the web app URL
+func tokenLink(rawURL string, token string) (string, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
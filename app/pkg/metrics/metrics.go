@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	namespace = "budgetin"
+	subsystem = "user_service"
+
+	// Login failure reasons
+	ReasonNotFound        = "not_found"
+	ReasonInvalidPassword = "invalid_password"
+	ReasonSessionConflict = "session_conflict"
+	ReasonAccountDisabled = "account_disabled"
+	ReasonInternal        = "internal"
+
+	// Operation results
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	// RequestDuration records the latency of the handled gRPC requests per method
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of the handled gRPC requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// RequestsTotal counts the handled gRPC requests per method and status code
+	RequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "grpc_requests_total",
+		Help:      "Total of the handled gRPC requests.",
+	}, []string{"method", "code"})
+
+	// LoginsTotal counts the login attempts by the result and the failure reason
+	LoginsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "logins_total",
+		Help:      "Total of the login attempts.",
+	}, []string{"result", "reason"})
+
+	// RegistrationsTotal counts the user registrations by the result
+	RegistrationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "registrations_total",
+		Help:      "Total of the user registrations.",
+	}, []string{"result"})
+
+	// VerificationEmailsTotal counts the verification emails delivery by the result
+	VerificationEmailsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "verification_emails_total",
+		Help:      "Total of the sent verification emails.",
+	}, []string{"result"})
+
+	// ActiveSessions is the number of the unexpired user sessions
+	ActiveSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "active_sessions",
+		Help:      "Number of the active user sessions.",
+	})
+
+	// PanicsTotal counts the recovered panics per method
+	PanicsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "grpc_panics_total",
+		Help:      "Total of the recovered panics while handling gRPC requests.",
+	}, []string{"method"})
+
+	// HashDuration records the password hashing duration per algorithm and operation
+	HashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "password_hash_duration_seconds",
+		Help:      "Duration of the password hashing operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"algorithm", "operation"})
+
+	// SchedulerJobRunsTotal counts the scheduled job runs by the job and the result
+	SchedulerJobRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scheduler_job_runs_total",
+		Help:      "Total of the scheduled job runs.",
+	}, []string{"job", "result"})
+
+	// SchedulerJobDuration records the duration of the scheduled job runs
+	SchedulerJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scheduler_job_duration_seconds",
+		Help:      "Duration of the scheduled job runs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job"})
+
+	// SchedulerJobAffectedTotal counts the rows affected by the scheduled jobs
+	SchedulerJobAffectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scheduler_job_affected_total",
+		Help:      "Total of the rows affected by the scheduled jobs.",
+	}, []string{"job"})
+
+	// SchedulerLeader is 1 when the instance is the leader running the scheduled jobs
+	SchedulerLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scheduler_leader",
+		Help:      "Whether the instance is the scheduler leader.",
+	})
+)
+
+// ObserveLogin records the login attempt, the reason is ignored when it succeeded
+func ObserveLogin(success bool, reason string) {
+	if success {
+		LoginsTotal.WithLabelValues(ResultSuccess, "").Inc()
+	} else {
+		LoginsTotal.WithLabelValues(ResultFailure, reason).Inc()
+	}
+}
+
+// ObserveResult increments the counter with the success or failure result label
+func ObserveResult(counter *prometheus.CounterVec, err error) {
+	if err != nil {
+		counter.WithLabelValues(ResultFailure).Inc()
+	} else {
+		counter.WithLabelValues(ResultSuccess).Inc()
+	}
+}
+
+// ObserveHashDuration records the elapsed hashing duration since the start time
+func ObserveHashDuration(algorithm string, operation string, start time.Time) {
+	HashDuration.WithLabelValues(algorithm, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveJob records the scheduled job run started at the start time
+func ObserveJob(job string, affected int64, start time.Time, err error) {
+	SchedulerJobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SchedulerJobRunsTotal.WithLabelValues(job, ResultFailure).Inc()
+		return
+	}
+	SchedulerJobRunsTotal.WithLabelValues(job, ResultSuccess).Inc()
+	SchedulerJobAffectedTotal.WithLabelValues(job).Add(float64(affected))
+}
+
+// Serve exposes the registered metrics on the '/metrics' path of the given port
+func Serve(port string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	address := fmt.Sprintf(":%s", port)
+	log.Infof("Metrics server listening: %s", address)
+	if err := http.ListenAndServe(address, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.WithFields(log.Fields{"address": address}).Errorf("Metrics server failed to serve: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package passkey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout is the duration the client is given to complete the ceremony
+const DefaultTimeout = 5 * time.Minute
+
+var (
+	// ErrInvalidResponse is returned when the authenticator response can't be parsed
+	ErrInvalidResponse = errors.New("invalid authenticator response")
+
+	// ErrVerificationFailed is returned when the authenticator response doesn't
+	// match the ceremony or the credential
+	ErrVerificationFailed = errors.New("authenticator response verification failed")
+
+	// ErrCloneDetected is returned when the signature counter doesn't increase,
+	// the credential private key may have been cloned
+	ErrCloneDetected = errors.New("authenticator signature counter regressed")
+)
+
+// Config is the relying party configuration, the origins are the fully qualified
+// origins of the web app (e.g. 'https://app.budgetin.id')
+type Config struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	Timeout       time.Duration
+}
+
+// NewConfigFromEnv create the relying party configuration from the WEBAUTHN_* environment variables
+func NewConfigFromEnv() Config {
+	var origins []string
+	for _, origin := range strings.Split(env.GetenvOrDefault("WEBAUTHN_RP_ORIGINS", "http://localhost:3000"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return Config{
+		RPID:          env.GetenvOrDefault("WEBAUTHN_RP_ID", "localhost"),
+		RPDisplayName: env.GetenvOrDefault("WEBAUTHN_RP_DISPLAY_NAME", "Budgetin"),
+		RPOrigins:     origins,
+		Timeout:       env.GetDurationOrDefault("WEBAUTHN_TIMEOUT", DefaultTimeout),
+	}
+}
+
+// Ceremony is the started registration or assertion ceremony. The options are sent
+// to the client as is, the session should be kept until the client responds.
+type Ceremony struct {
+	Options []byte
+	Session webauthn.SessionData
+}
+
+// Challenge returns the challenge of the ceremony, it identifies the ceremony
+// of the authenticator response
+func (c *Ceremony) Challenge() string {
+	return c.Session.Challenge
+}
+
+// RelyingParty runs the WebAuthn ceremonies of the service
+type RelyingParty struct {
+	webAuthn *webauthn.WebAuthn
+}
+
+// NewRelyingParty creates the relying party of the configuration
+func NewRelyingParty(config Config) (*RelyingParty, error) {
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: config.Timeout, TimeoutUVD: config.Timeout}
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPDisplayName,
+		RPOrigins:     config.RPOrigins,
+		Timeouts:      webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure relying party: %w", err)
+	}
+	return &RelyingParty{webAuthn: webAuthn}, nil
+}
+
+// NewRelyingPartyFromEnv creates the relying party configured by the environment variables
+func NewRelyingPartyFromEnv() *RelyingParty {
+	config := NewConfigFromEnv()
+	rp, err := NewRelyingParty(config)
+	if err != nil {
+		log.Fatalf("failed to load webauthn relying party: %v", err)
+	}
+
+	log.WithFields(log.Fields{"rp_id": config.RPID, "origins": config.RPOrigins}).Info("WebAuthn relying party loaded")
+	return rp
+}
+
+// BeginRegistration starts registering a new discoverable credential of the user, the
+// registered credentials are excluded so the same authenticator isn't registered twice
+func (rp *RelyingParty) BeginRegistration(user *User) (*Ceremony, error) {
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.Credentials))
+	for _, credential := range user.Credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := rp.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithConveyancePreference(protocol.PreferNoAttestation),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newCeremony(creation, session)
+}
+
+// FinishRegistration verifies the attestation of the registration ceremony and returns the new credential
+func (rp *RelyingParty) FinishRegistration(user *User, session webauthn.SessionData, response *protocol.ParsedCredentialCreationData) (*webauthn.Credential, error) {
+	credential, err := rp.webAuthn.CreateCredential(user, session, response)
+	if err != nil {
+		return nil, verificationError(err)
+	}
+	return credential, nil
+}
+
+// BeginLogin starts the assertion ceremony of the user's credentials, it's used as the
+// second factor after the user is identified, so the user verification is only preferred
+func (rp *RelyingParty) BeginLogin(user *User) (*Ceremony, error) {
+	assertion, session, err := rp.webAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		return nil, err
+	}
+	return newCeremony(assertion, session)
+}
+
+// BeginDiscoverableLogin starts the assertion ceremony of any discoverable credential, the
+// passkey is the only factor so the user verification is required
+func (rp *RelyingParty) BeginDiscoverableLogin() (*Ceremony, error) {
+	assertion, session, err := rp.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, err
+	}
+	return newCeremony(assertion, session)
+}
+
+// FinishLogin verifies the assertion of the user's credential and returns the asserted
+// credential with the updated signature counter
+func (rp *RelyingParty) FinishLogin(user *User, session webauthn.SessionData, response *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error) {
+	credential, err := rp.webAuthn.ValidateLogin(user, session, response)
+	if err != nil {
+		return nil, verificationError(err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, ErrCloneDetected
+	}
+	return credential, nil
+}
+
+// FinishDiscoverableLogin verifies the assertion of the discoverable credential, the user
+// is resolved by the user handle returned by the authenticator
+func (rp *RelyingParty) FinishDiscoverableLogin(
+	findUser func(userID uint) (*User, error),
+	session webauthn.SessionData,
+	response *protocol.ParsedCredentialAssertionData,
+) (*User, *webauthn.Credential, error) {
+	var user *User
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, ok := ParseUserHandle(userHandle)
+		if !ok {
+			return nil, errors.New("unknown user handle")
+		}
+		found, err := findUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		user = found
+		return found, nil
+	}
+
+	credential, err := rp.webAuthn.ValidateDiscoverableLogin(handler, session, response)
+	if err != nil {
+		return nil, nil, verificationError(err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, nil, ErrCloneDetected
+	}
+	return user, credential, nil
+}
+
+// ParseCreationResponse parses the JSON encoded 'PublicKeyCredential' of the registration
+func ParseCreationResponse(response string) (*protocol.ParsedCredentialCreationData, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(strings.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, errorDetails(err))
+	}
+	return parsed, nil
+}
+
+// ParseAssertionResponse parses the JSON encoded 'PublicKeyCredential' of the assertion
+func ParseAssertionResponse(response string) (*protocol.ParsedCredentialAssertionData, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(strings.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, errorDetails(err))
+	}
+	return parsed, nil
+}
+
+func newCeremony(options interface{}, session *webauthn.SessionData) (*Ceremony, error) {
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return &Ceremony{Options: encoded, Session: *session}, nil
+}
+
+// verificationError wraps the protocol error with its details, the protocol errors
+// only describe the error type in their message
+func verificationError(err error) error {
+	return fmt.Errorf("%w: %s", ErrVerificationFailed, errorDetails(err))
+}
+
+func errorDetails(err error) string {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.Details != "" {
+		return protocolErr.Details
+	}
+	return err.Error()
+}
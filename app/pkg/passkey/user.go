@@ -0,0 +1,58 @@
+package passkey
+
+import (
+	"encoding/binary"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// userHandleLength is the length of the user handle, it's the big-endian user ID
+const userHandleLength = 8
+
+// User is the WebAuthn user with the registered credentials
+type User struct {
+	ID          uint
+	Name        string
+	DisplayName string
+	Credentials []webauthn.Credential
+}
+
+// UserHandle encodes the user ID into the opaque user handle stored by the
+// authenticator, it doesn't contain any personal information of the user
+func UserHandle(userID uint) []byte {
+	handle := make([]byte, userHandleLength)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
+
+// ParseUserHandle decodes the user ID of the user handle returned by the authenticator
+func ParseUserHandle(handle []byte) (uint, bool) {
+	if len(handle) != userHandleLength {
+		return 0, false
+	}
+	userID := binary.BigEndian.Uint64(handle)
+	return uint(userID), userID != 0
+}
+
+func (u *User) WebAuthnID() []byte {
+	return UserHandle(u.ID)
+}
+
+func (u *User) WebAuthnName() string {
+	return u.Name
+}
+
+func (u *User) WebAuthnDisplayName() string {
+	if u.DisplayName == "" {
+		return u.Name
+	}
+	return u.DisplayName
+}
+
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+func (u *User) WebAuthnIcon() string {
+	return ""
+}
@@ -0,0 +1,90 @@
+package redactor
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RedactedValue is the replacement value of the masked fields
+const RedactedValue = "[REDACTED]"
+
+// SensitiveFields is the denylist of the field names that should never be logged,
+// a field is also masked when its name ends with '_<sensitive field>' (e.g. 'new_password')
+var SensitiveFields = []string{
+	"password",
+	"token",
+	"auth_token",
+	"verification_token",
+	"api_key",
+	"secret",
+	"code",
+	"nonce",
+}
+
+// Redact returns a copy of the message with the sensitive fields masked, the
+// original message is left untouched
+func Redact(message proto.Message) proto.Message {
+	if message == nil {
+		return nil
+	}
+	redacted := proto.Clone(message)
+	redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+// String formats the value into a loggable string, the proto messages are
+// redacted and formatted as compact JSON
+func String(value interface{}) string {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return protojson.MarshalOptions{UseProtoNames: true}.Format(Redact(message))
+}
+
+// IsSensitive checks whether the field name is listed in the sensitive fields
+func IsSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range SensitiveFields {
+		if name == field || strings.HasSuffix(name, "_"+field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactMessage masks the sensitive fields of the message and its nested messages
+func redactMessage(message protoreflect.Message) {
+	message.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case IsSensitive(string(fd.Name())):
+			maskField(message, fd)
+		case fd.IsList() && fd.Message() != nil:
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			value.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				redactMessage(v.Message())
+				return true
+			})
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			redactMessage(value.Message())
+		}
+		return true
+	})
+}
+
+// maskField replaces the string value with the redacted value, other kinds are cleared
+func maskField(message protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+		message.Set(fd, protoreflect.ValueOfString(RedactedValue))
+		return
+	}
+	message.Clear(fd)
+}
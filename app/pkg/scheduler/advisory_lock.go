@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// AdvisoryLockKey is the Postgres advisory lock key held by the scheduler leader
+const AdvisoryLockKey int64 = 0x6275646765746e // "budgetn"
+
+// AdvisoryLockElector elects the leader with the session level Postgres advisory lock,
+// the lock is held by the dedicated connection so it's released by the database
+// when the leader instance dies
+type AdvisoryLockElector struct {
+	db   *sql.DB
+	key  int64
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewAdvisoryLockElector creates the elector of the advisory lock key
+func NewAdvisoryLockElector(db *sql.DB, key int64) *AdvisoryLockElector {
+	return &AdvisoryLockElector{db: db, key: key}
+}
+
+// Elect keeps the leadership while the connection holding the lock is alive,
+// otherwise it tries to acquire the lock without waiting
+func (e *AdvisoryLockElector) Elect(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if err := e.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The lock is gone along with the broken connection
+		e.conn.Close()
+		e.conn = nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	e.conn = conn
+	return true, nil
+}
+
+// Resign releases the lock and returns the connection into the pool
+func (e *AdvisoryLockElector) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	defer func() { e.conn = nil }()
+
+	_, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key)
+	if closeErr := e.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
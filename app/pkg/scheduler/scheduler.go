@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultElectionInterval is the interval the instance tries to become or stay the leader
+const DefaultElectionInterval = 15 * time.Second
+
+// Job is the periodic task, it returns the number of the affected rows. The job
+// with the non positive interval is disabled.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// Elector elects the single instance running the jobs across the replicas
+type Elector interface {
+	// Elect tries to become or stay the leader and returns whether the instance is the leader
+	Elect(ctx context.Context) (bool, error)
+	// Resign gives up the leadership so another instance can be elected
+	Resign(ctx context.Context) error
+}
+
+// Scheduler runs the jobs on their interval, only while the instance is the leader
+type Scheduler struct {
+	elector          Elector
+	electionInterval time.Duration
+	jobs             []Job
+	leader           atomic.Bool
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+}
+
+// New creates the scheduler of the enabled jobs with the leader elector
+func New(elector Elector, electionInterval time.Duration, jobs ...Job) *Scheduler {
+	s := &Scheduler{elector: elector, electionInterval: electionInterval}
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			log.WithField("job", job.Name).Info("Scheduled job disabled")
+			continue
+		}
+		s.jobs = append(s.jobs, job)
+	}
+	return s
+}
+
+// Start runs the leader election and the jobs in the background until the context
+// is cancelled or the scheduler is stopped
+func (s *Scheduler) Start(ctx context.Context) {
+	// There is no need to be the leader without any job
+	if len(s.jobs) == 0 {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	// The first election runs before the jobs so the leader doesn't wait an interval
+	s.elect(ctx)
+	s.loop(ctx, s.electionInterval, func() { s.elect(ctx) })
+
+	for _, job := range s.jobs {
+		job := job
+		s.loop(ctx, job.Interval, func() {
+			if s.IsLeader() {
+				s.run(ctx, job)
+			}
+		})
+	}
+}
+
+// Stop stops the jobs, waits for the running ones and resigns the leadership
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+
+	if s.leader.Swap(false) {
+		if err := s.elector.Resign(context.Background()); err != nil {
+			log.Errorf("error resign scheduler leadership: %v", err)
+		}
+		metrics.SchedulerLeader.Set(0)
+	}
+}
+
+// IsLeader checks the instance is currently the leader running the jobs
+func (s *Scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+// loop calls the function on every tick of the interval in the background
+func (s *Scheduler) loop(ctx context.Context, interval time.Duration, fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) elect(ctx context.Context) {
+	leader, err := s.elector.Elect(ctx)
+	if err != nil {
+		log.Errorf("error elect scheduler leader: %v", err)
+	}
+	if was := s.leader.Swap(leader); was != leader {
+		log.WithField("leader", leader).Info("Scheduler leadership changed")
+	}
+	if leader {
+		metrics.SchedulerLeader.Set(1)
+	} else {
+		metrics.SchedulerLeader.Set(0)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	ctx, span := tracer.Start(ctx, "Scheduler.Run", attribute.String("job.name", job.Name))
+
+	start := time.Now()
+	affected, err := job.Run(ctx)
+	tracer.End(span, err)
+	metrics.ObserveJob(job.Name, affected, start, err)
+
+	entry := log.WithFields(log.Fields{"job": job.Name, "affected": affected, "duration": time.Since(start)})
+	if err != nil {
+		entry.Errorf("scheduled job failed: %v", err)
+		return
+	}
+	entry.Debug("Scheduled job completed")
+}
@@ -0,0 +1,199 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client authentication modes of the mutual TLS
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// Config holds the file paths and the client verification policy of the server TLS
+type Config struct {
+	CertFile           string
+	KeyFile            string
+	ClientCAFile       string
+	ClientAuth         string
+	AllowedClientNames []string
+}
+
+// CertReloader keeps the server certificate and the client CA pool loaded from
+// the disk, and reloads them when the files changed without restarting the server
+type CertReloader struct {
+	config    Config
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewCertReloader create the reloader and loads the certificates for the first time
+func NewCertReloader(config Config) (*CertReloader, error) {
+	if config.ClientAuth == "" {
+		config.ClientAuth = ClientAuthNone
+	}
+	if config.ClientAuth != ClientAuthNone && config.ClientCAFile == "" {
+		return nil, errors.New("client CA file is required to verify the client certificates")
+	}
+
+	reloader := &CertReloader{config: config, modTimes: map[string]time.Time{}}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Reload loads the certificate, key and client CA files from the disk
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("failed to parse client CA certificates")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			r.modTimes[file] = info.ModTime()
+		}
+	}
+	return nil
+}
+
+// Watch periodically checks the modification time of the files and reloads the
+// certificates when any of them changed, it stops when the context is done
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.isChanged() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				// Keep serving the previous certificates until the files are valid
+				log.Errorf("failed to reload TLS certificates: %v", err)
+			} else {
+				log.Info("TLS certificates reloaded")
+			}
+		}
+	}
+}
+
+// ServerConfig returns the TLS configuration of the server, the certificate and
+// the client CA pool are resolved on every handshake so the reloaded files take
+// effect on the new connections. The per client configuration is the clone of the
+// base configuration, so it keeps negotiating the "h2" ALPN required by gRPC.
+func (r *CertReloader) ServerConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2"},
+	}
+
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		clientConfig := base.Clone()
+		clientConfig.Certificates = []tls.Certificate{*r.cert}
+		clientConfig.ClientCAs = r.clientCAs
+		clientConfig.ClientAuth = r.clientAuthType()
+		clientConfig.VerifyConnection = r.verifyClientName
+		return clientConfig, nil
+	}
+	return config
+}
+
+// Certificate returns the currently loaded server certificate
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// clientAuthType maps the client authentication mode into the TLS client auth type
+func (r *CertReloader) clientAuthType() tls.ClientAuthType {
+	switch r.config.ClientAuth {
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// verifyClientName ensures the verified client certificate subject is listed in
+// the allowed client names, the common name and the DNS names are checked
+func (r *CertReloader) verifyClientName(state tls.ConnectionState) error {
+	if len(r.config.AllowedClientNames) == 0 || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, name := range names {
+		if slices.Contains(r.config.AllowedClientNames, name) {
+			return nil
+		}
+	}
+	return fmt.Errorf("client certificate subject '%s' is not allowed", cert.Subject.CommonName)
+}
+
+// isChanged checks any of the files modification time changed since the last load
+func (r *CertReloader) isChanged() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// files list the loaded file paths
+func (r *CertReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
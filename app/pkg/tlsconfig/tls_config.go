@@ -0,0 +1,74 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+)
+
+// IsEnabled checks the server TLS is enabled through the TLS_ENABLED environment variable
+func IsEnabled() bool {
+	return os.Getenv("TLS_ENABLED") == "true"
+}
+
+// NewConfigFromEnv create the server TLS configuration from the TLS_* environment variables
+func NewConfigFromEnv() Config {
+	var names []string
+	for _, name := range strings.Split(os.Getenv("TLS_ALLOWED_CLIENT_NAMES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return Config{
+		CertFile:           os.Getenv("TLS_CERT_FILE"),
+		KeyFile:            os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile:       os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:         env.GetenvOrDefault("TLS_CLIENT_AUTH", ClientAuthNone),
+		AllowedClientNames: names,
+	}
+}
+
+// NewClientConfig create the TLS configuration used by the internal clients (e.g.
+// the gateway) to dial the server. The CA file is used to verify the server
+// certificate, and the certificate pair is presented when mutual TLS is required.
+func NewClientConfig(caFile string, serverName string, certFile string, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to parse CA certificates")
+		}
+	}
+
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewGatewayClientConfig create the client TLS configuration of the gateway from
+// the GATEWAY_TLS_* environment variables
+func NewGatewayClientConfig() (*tls.Config, error) {
+	return NewClientConfig(
+		os.Getenv("GATEWAY_TLS_CA_FILE"),
+		env.GetenvOrDefault("GATEWAY_TLS_SERVER_NAME", "localhost"),
+		os.Getenv("GATEWAY_TLS_CERT_FILE"),
+		os.Getenv("GATEWAY_TLS_KEY_FILE"),
+	)
+}
@@ -0,0 +1,114 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Name is the instrumentation name of the service tracer
+	Name = "github.com/budgetin-app/user-service"
+
+	// Trace exporters (TRACE_EXPORTER:none/otlp/stdout/file)
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// InitTracer configures the global tracer provider with the exporter chosen by the
+// TRACE_EXPORTER environment variable, the returned function flushes the remaining
+// spans and should be called when the service stops
+func InitTracer(ctx context.Context) (func(context.Context) error, error) {
+	// Propagate the W3C trace context and baggage between services
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, closer, err := newExporter(ctx, env.GetenvOrDefault("TRACE_EXPORTER", ExporterNone))
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		// Tracing is disabled, keep the default no-op tracer provider
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(env.GetenvOrDefault("TRACE_SERVICE_NAME", "user-service")),
+			semconv.DeploymentEnvironment(os.Getenv("APP_ENV")),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return err
+		}
+		if closer != nil {
+			return closer.Close()
+		}
+		return nil
+	}, nil
+}
+
+// newExporter create the span exporter, the closer is returned when the exporter writes into a file
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch name {
+	case ExporterNone, "":
+		return nil, nil, nil
+	case ExporterOTLP:
+		// The collector endpoint is configured by the standard OTEL_EXPORTER_OTLP_* variables
+		exporter, err := otlptracegrpc.New(ctx)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case ExporterFile:
+		filePath := env.GetenvOrDefault("TRACE_FILE_PATH", "./logs/traces.json")
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return nil, nil, fmt.Errorf("failed to create trace directory: %w", err)
+		}
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		return exporter, file, err
+	default:
+		return nil, nil, fmt.Errorf("trace exporter '%s' is not supported", name)
+	}
+}
+
+// Start creates a span as the child of the span within the context
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(Name).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records the error into the span when present and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
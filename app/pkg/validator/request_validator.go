@@ -6,6 +6,8 @@ const (
 	UsernameRegex = `^[a-zA-Z0-9]*$`
 	EmailRegex    = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
 	UserNameRegex = `^[a-zA-Z ]+`
+
+	ServiceAccountNameRegex = `^[a-z][a-z0-9-]{2,49}$`
 )
 
 func IsValidUsername(username string) bool {
@@ -39,3 +41,8 @@ func IsValidName(name string) bool {
 	nameRegex := regexp.MustCompile(UserNameRegex)
 	return nameRegex.MatchString(name)
 }
+
+func IsValidServiceAccountName(name string) bool {
+	serviceAccountNameRegex := regexp.MustCompile(ServiceAccountNameRegex)
+	return serviceAccountNameRegex.MatchString(name)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userOwnedModels are the models of the user's data deleted along with the account,
+// the audit trail is kept with the client address and user agent of the user erased
+var userOwnedModels = []interface{}{
+	&model.Session{},
+	&model.MagicLink{},
+	&model.KnownDevice{},
+	&model.PersonalAccessToken{},
+	&model.LoginExternal{},
+	&model.ExternalLoginState{},
+	&model.WebAuthnCredential{},
+	&model.WebAuthnCeremony{},
+	&model.DataExport{},
+}
+
+type AccountDeletionRepository interface {
+	ScheduleAccountDeletion(ctx context.Context, userID uint, scheduledAt time.Time) error
+	CancelAccountDeletion(ctx context.Context, userID uint) (bool, error)
+	FindAccountsDueForDeletion(ctx context.Context, dueBefore time.Time, limit int) ([]model.Account, error)
+	PurgeAccount(ctx context.Context, userID uint, dueBefore time.Time, event *model.OutboxEvent) error
+}
+
+type AccountDeletionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAccountDeletionRepository(db *gorm.DB) *AccountDeletionRepositoryImpl {
+	return &AccountDeletionRepositoryImpl{db: db}
+}
+
+// ScheduleAccountDeletion marks the active account as pending for the deletion, the
+// suspended or deactivated account is not found
+func (r AccountDeletionRepositoryImpl) ScheduleAccountDeletion(ctx context.Context, userID uint, scheduledAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.Account{}).
+		Where("user_id = ? AND status IN ?", userID, []string{model.AccountActive, model.AccountPendingDeletion}).
+		Updates(map[string]interface{}{
+			"deletion_scheduled_at": scheduledAt,
+			"status":                model.AccountPendingDeletion,
+			"status_reason":         "deletion requested by the user",
+			"status_changed_by":     nil,
+			"status_changed_at":     time.Now(),
+		})
+	if result.Error != nil {
+		log.Errorf("error schedule account deletion: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrUserNotFound
+	}
+	return nil
+}
+
+// CancelAccountDeletion activates the account pending for the deletion again
+func (r AccountDeletionRepositoryImpl) CancelAccountDeletion(ctx context.Context, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Account{}).
+		Where("user_id = ? AND status = ?", userID, model.AccountPendingDeletion).
+		Updates(map[string]interface{}{
+			"deletion_scheduled_at": nil,
+			"status":                model.AccountActive,
+			"status_reason":         "deletion cancelled by the login",
+			"status_changed_by":     nil,
+			"status_changed_at":     time.Now(),
+		})
+	if result.Error != nil {
+		log.Errorf("error cancel account deletion: %v", result.Error)
+		return false, database.HandleErrorDB(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r AccountDeletionRepositoryImpl) FindAccountsDueForDeletion(ctx context.Context, dueBefore time.Time, limit int) ([]model.Account, error) {
+	var accounts []model.Account
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("deletion_scheduled_at <= ?", dueBefore).
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Find(&accounts).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return accounts, nil
+}
+
+// PurgeAccount hard deletes the account with the user's credentials, sessions and
+// verification records, then erases the client details of the user from the audit
+// trail. The event is stored within the same transaction. The account whose deletion
+// is cancelled meanwhile is not found.
+func (r AccountDeletionRepositoryImpl) PurgeAccount(ctx context.Context, userID uint, dueBefore time.Time, event *model.OutboxEvent) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the account so the concurrent login can't cancel the deletion halfway
+		var account model.Account
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND deletion_scheduled_at <= ?", userID, dueBefore).
+			First(&account).Error; err != nil {
+			return err
+		}
+
+		for _, owned := range userOwnedModels {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+
+		// The login info references its verification and recovery records
+		var info model.LoginInfo
+		err := tx.Unscoped().Where("user_id = ?", userID).First(&info).Error
+		switch {
+		case err == nil:
+			if err := tx.Unscoped().Delete(&info).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&model.EmailVerification{}, info.EmailVerificationID).Error; err != nil {
+				return err
+			}
+			if info.PasswordRecoveryID != nil {
+				if err := tx.Unscoped().Delete(&model.PasswordRecovery{}, *info.PasswordRecoveryID).Error; err != nil {
+					return err
+				}
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if err := tx.Unscoped().Delete(&account).Error; err != nil {
+			return err
+		}
+
+		// The audit events are immutable, the erasure skips the hooks guarding them
+		if err := tx.Model(&model.AuditEvent{}).
+			Where("user_id = ? OR (actor_type = ? AND actor_id = ?)", userID, model.AuditActorUser, userID).
+			UpdateColumns(map[string]interface{}{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		log.Errorf("error purge account: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
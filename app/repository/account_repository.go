@@ -1,17 +1,21 @@
 package repository
 
 import (
+	"context"
+
+	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/domain/model"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type AccountRepository interface {
-	CreateAccount(account *model.Account) (model.Account, error)
-	FindAccountByUserID(userID uint) (model.Account, error)
-	UpdateAccount(newAccount *model.Account) (model.Account, error)
-	DeleteAccount(account *model.Account) (bool, error)
-	BeginTransaction() *gorm.DB
+	CreateAccount(ctx context.Context, account *model.Account) (model.Account, error)
+	FindAccountByUserID(ctx context.Context, userID uint) (model.Account, error)
+	UpdateAccount(ctx context.Context, newAccount *model.Account) (model.Account, error)
+	DeleteAccount(ctx context.Context, account *model.Account) (bool, error)
+	UpdateAccountStatus(ctx context.Context, account *model.Account, fromStatuses []string) (bool, error)
+	BeginTransaction(ctx context.Context) *gorm.DB
 }
 
 type AccountRepositoryImpl struct {
@@ -22,25 +26,25 @@ func NewAccountRepository(db *gorm.DB) *AccountRepositoryImpl {
 	return &AccountRepositoryImpl{db: db}
 }
 
-func (r AccountRepositoryImpl) CreateAccount(account *model.Account) (model.Account, error) {
-	if err := r.db.Create(&account).Error; err != nil {
+func (r AccountRepositoryImpl) CreateAccount(ctx context.Context, account *model.Account) (model.Account, error) {
+	if err := r.db.WithContext(ctx).Create(&account).Error; err != nil {
 		log.Errorf("error create new account: %v", err)
 		return model.Account{}, err
 	}
 	return *account, nil
 }
 
-func (r AccountRepositoryImpl) FindAccountByUserID(userID uint) (model.Account, error) {
+func (r AccountRepositoryImpl) FindAccountByUserID(ctx context.Context, userID uint) (model.Account, error) {
 	account := model.Account{ID: userID}
-	if err := r.db.Find(&account).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&account).Error; err != nil {
 		log.Errorf("error find account by user id: %v", err)
 		return model.Account{}, err
 	}
 	return account, nil
 }
 
-func (r AccountRepositoryImpl) UpdateAccount(newAccount *model.Account) (model.Account, error) {
-	result := r.db.Model(&model.Account{ID: newAccount.ID}).Updates(&newAccount)
+func (r AccountRepositoryImpl) UpdateAccount(ctx context.Context, newAccount *model.Account) (model.Account, error) {
+	result := r.db.WithContext(ctx).Model(&model.Account{ID: newAccount.ID}).Updates(&newAccount)
 	if result.Error != nil {
 		log.Errorf("error update account: %v", result.Error)
 		return model.Account{}, result.Error
@@ -48,8 +52,8 @@ func (r AccountRepositoryImpl) UpdateAccount(newAccount *model.Account) (model.A
 	return *newAccount, nil
 }
 
-func (r AccountRepositoryImpl) DeleteAccount(account *model.Account) (bool, error) {
-	result := r.db.Delete(&account)
+func (r AccountRepositoryImpl) DeleteAccount(ctx context.Context, account *model.Account) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&account)
 	if result.Error != nil {
 		log.Errorf("error delete account: %v", result.Error)
 		return false, result.Error
@@ -57,6 +61,24 @@ func (r AccountRepositoryImpl) DeleteAccount(account *model.Account) (bool, erro
 	return result.RowsAffected > 0, nil
 }
 
-func (r AccountRepositoryImpl) BeginTransaction() *gorm.DB {
-	return r.db.Begin()
+// UpdateAccountStatus changes the status of the account only when its current status is
+// one of the given statuses, so the concurrent changes don't overwrite each other
+func (r AccountRepositoryImpl) UpdateAccountStatus(ctx context.Context, account *model.Account, fromStatuses []string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Account{}).
+		Where("user_id = ? AND status IN ?", account.ID, fromStatuses).
+		Updates(map[string]interface{}{
+			"status":            account.Status,
+			"status_reason":     account.StatusReason,
+			"status_changed_by": account.StatusChangedBy,
+			"status_changed_at": account.StatusChangedAt,
+		})
+	if result.Error != nil {
+		log.Errorf("error update account status: %v", result.Error)
+		return false, database.HandleErrorDB(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r AccountRepositoryImpl) BeginTransaction(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Begin()
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AuditEventFilter filters the audit events, the zero fields are not filtered. The
+// events are ordered from the latest and paginated by the 'AfterID' cursor.
+type AuditEventFilter struct {
+	UserID     *uint
+	EventTypes []string
+	Outcome    string
+	From       *time.Time
+	To         *time.Time
+	AfterID    uint
+	Limit      int
+}
+
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error
+	FindAuditEvents(ctx context.Context, filter AuditEventFilter) ([]model.AuditEvent, error)
+}
+
+type AuditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepositoryImpl {
+	return &AuditRepositoryImpl{db: db}
+}
+
+func (r AuditRepositoryImpl) CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		log.Errorf("error create audit event: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r AuditRepositoryImpl) FindAuditEvents(ctx context.Context, filter AuditEventFilter) ([]model.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Model(&model.AuditEvent{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.AfterID > 0 {
+		// The events are listed from the latest, so the next page has the lower ids
+		query = query.Where("audit_event_id < ?", filter.AfterID)
+	}
+
+	var events []model.AuditEvent
+	if err := query.Order("audit_event_id desc").Limit(filter.Limit).Find(&events).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return events, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type DataExportRepository interface {
+	CreateDataExport(ctx context.Context, export *model.DataExport) error
+	FindPendingDataExport(ctx context.Context, userID uint) (*model.DataExport, error)
+	FindPendingDataExports(ctx context.Context, limit int) ([]model.DataExport, error)
+	FindDataExportByToken(ctx context.Context, tokenHash string) (*model.DataExport, error)
+	CompleteDataExport(ctx context.Context, export *model.DataExport) error
+	UpdateDataExportStatus(ctx context.Context, exportID uint, status string) error
+}
+
+type DataExportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) *DataExportRepositoryImpl {
+	return &DataExportRepositoryImpl{db: db}
+}
+
+func (r DataExportRepositoryImpl) CreateDataExport(ctx context.Context, export *model.DataExport) error {
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		log.Errorf("error create data export: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r DataExportRepositoryImpl) FindPendingDataExport(ctx context.Context, userID uint) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.WithContext(ctx).Omit("content").
+		Where("user_id = ? AND status = ?", userID, model.DataExportPending).
+		First(&export).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return &export, nil
+}
+
+// FindPendingDataExports finds the exports waiting to be generated in the order they're requested
+func (r DataExportRepositoryImpl) FindPendingDataExports(ctx context.Context, limit int) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	if err := r.db.WithContext(ctx).Omit("content").Where("status = ?", model.DataExportPending).
+		Order("data_export_id").Limit(limit).Find(&exports).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return exports, nil
+}
+
+// FindDataExportByToken finds the ready export of the unexpired download token
+func (r DataExportRepositoryImpl) FindDataExportByToken(ctx context.Context, tokenHash string) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.WithContext(ctx).
+		Where("download_token = ? AND status = ? AND download_expiration > ?", tokenHash, model.DataExportReady, time.Now()).
+		First(&export).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return &export, nil
+}
+
+// CompleteDataExport stores the generated file of the export along with its download token
+func (r DataExportRepositoryImpl) CompleteDataExport(ctx context.Context, export *model.DataExport) error {
+	if err := r.db.WithContext(ctx).Model(&model.DataExport{ID: export.ID}).
+		Select("status", "file_name", "content_type", "content", "download_token", "download_expiration").
+		Updates(export).Error; err != nil {
+		log.Errorf("error complete data export: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r DataExportRepositoryImpl) UpdateDataExportStatus(ctx context.Context, exportID uint, status string) error {
+	if err := r.db.WithContext(ctx).Model(&model.DataExport{ID: exportID}).Update("status", status).Error; err != nil {
+		log.Errorf("error update data export status: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
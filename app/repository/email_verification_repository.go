@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/domain/model"
 	log "github.com/sirupsen/logrus"
@@ -8,8 +10,9 @@ import (
 )
 
 type EmailVerificationRepository interface {
-	UpdateEmailVerification(verification *model.EmailVerification) (model.EmailVerification, error)
-	DeleteEmailVerification(verification *model.EmailVerification) (bool, error)
+	FindEmailVerificationByToken(ctx context.Context, token string) (model.EmailVerification, error)
+	UpdateEmailVerification(ctx context.Context, verification *model.EmailVerification) (model.EmailVerification, error)
+	DeleteEmailVerification(ctx context.Context, verification *model.EmailVerification) (bool, error)
 }
 
 type EmailVerificationRepositoryImpl struct {
@@ -20,8 +23,16 @@ func NewEmailVerificationRepository(db *gorm.DB) *EmailVerificationRepositoryImp
 	return &EmailVerificationRepositoryImpl{db: db}
 }
 
-func (r EmailVerificationRepositoryImpl) UpdateEmailVerification(verification *model.EmailVerification) (model.EmailVerification, error) {
-	result := r.db.Model(&model.EmailVerification{ID: verification.ID}).Updates(&verification)
+func (r EmailVerificationRepositoryImpl) FindEmailVerificationByToken(ctx context.Context, token string) (model.EmailVerification, error) {
+	var verification model.EmailVerification
+	if err := r.db.WithContext(ctx).Where("verification_token = ?", token).First(&verification).Error; err != nil {
+		return model.EmailVerification{}, database.HandleErrorDB(err)
+	}
+	return verification, nil
+}
+
+func (r EmailVerificationRepositoryImpl) UpdateEmailVerification(ctx context.Context, verification *model.EmailVerification) (model.EmailVerification, error) {
+	result := r.db.WithContext(ctx).Model(&model.EmailVerification{ID: verification.ID}).Updates(&verification)
 	if result.Error != nil {
 		log.Errorf("error update email verification: %v", result.Error)
 		return model.EmailVerification{}, database.HandleErrorDB(result.Error)
@@ -29,8 +40,8 @@ func (r EmailVerificationRepositoryImpl) UpdateEmailVerification(verification *m
 	return *verification, nil
 }
 
-func (r EmailVerificationRepositoryImpl) DeleteEmailVerification(verification *model.EmailVerification) (bool, error) {
-	result := r.db.Delete(&verification)
+func (r EmailVerificationRepositoryImpl) DeleteEmailVerification(ctx context.Context, verification *model.EmailVerification) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&verification)
 	if result.Error != nil {
 		log.Errorf("error update email verification: %v", result.Error)
 		return false, database.HandleErrorDB(result.Error)
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type EventRepository interface {
+	FindPendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkEventPublished(ctx context.Context, eventID uint, publishedAt time.Time) error
+	MarkEventFailed(ctx context.Context, eventID uint, reason string) error
+}
+
+type EventRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) *EventRepositoryImpl {
+	return &EventRepositoryImpl{db: db}
+}
+
+// FindPendingEvents finds the unpublished events in the order they happened
+func (r EventRepositoryImpl) FindPendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	if err := r.db.WithContext(ctx).Where("published_at IS NULL").
+		Order("event_id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return events, nil
+}
+
+func (r EventRepositoryImpl) MarkEventPublished(ctx context.Context, eventID uint, publishedAt time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&model.OutboxEvent{ID: eventID}).Updates(map[string]interface{}{
+		"published_at": publishedAt,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	}).Error; err != nil {
+		log.Errorf("error mark event published: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r EventRepositoryImpl) MarkEventFailed(ctx context.Context, eventID uint, reason string) error {
+	if err := r.db.WithContext(ctx).Model(&model.OutboxEvent{ID: eventID}).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+	}).Error; err != nil {
+		log.Errorf("error mark event failed: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
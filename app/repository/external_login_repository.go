@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ExternalLoginRepository interface {
+	FindOrCreateProvider(ctx context.Context, name string, url string) (model.ExternalProvider, error)
+	CreateLoginState(ctx context.Context, state *model.ExternalLoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (model.ExternalLoginState, error)
+	FindLoginExternal(ctx context.Context, providerID uint, subject string) (model.LoginExternal, error)
+	FindLoginExternalsByUser(ctx context.Context, userID uint) ([]model.LoginExternal, error)
+	CreateLoginExternal(ctx context.Context, login *model.LoginExternal) (model.LoginExternal, error)
+	CreateExternalUser(ctx context.Context, account *model.Account, login *model.LoginExternal) (model.LoginExternal, error)
+	DeleteLoginExternal(ctx context.Context, userID uint, providerID uint) error
+}
+
+type ExternalLoginRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewExternalLoginRepository(db *gorm.DB) *ExternalLoginRepositoryImpl {
+	return &ExternalLoginRepositoryImpl{db: db}
+}
+
+func (r ExternalLoginRepositoryImpl) FindOrCreateProvider(ctx context.Context, name string, url string) (model.ExternalProvider, error) {
+	// Keep the stored provider url in sync with the configured issuer
+	provider := model.ExternalProvider{Name: name}
+	if err := r.db.WithContext(ctx).Where(&provider).Assign(model.ExternalProvider{Url: url}).
+		FirstOrCreate(&provider).Error; err != nil {
+		log.Errorf("error find or create external provider: %v", err)
+		return model.ExternalProvider{}, database.HandleErrorDB(err)
+	}
+	return provider, nil
+}
+
+func (r ExternalLoginRepositoryImpl) CreateLoginState(ctx context.Context, state *model.ExternalLoginState) error {
+	if err := r.db.WithContext(ctx).Create(state).Error; err != nil {
+		log.Errorf("error create external login state: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r ExternalLoginRepositoryImpl) ConsumeLoginState(ctx context.Context, state string) (model.ExternalLoginState, error) {
+	var loginState model.ExternalLoginState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ? AND state_expiration > ?", state, time.Now()).First(&loginState).Error; err != nil {
+			return err
+		}
+
+		// The state is single use, the concurrent callback with the same state loses the race
+		result := tx.Unscoped().Delete(&loginState)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected <= 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return model.ExternalLoginState{}, database.HandleErrorDB(err)
+	}
+	return loginState, nil
+}
+
+func (r ExternalLoginRepositoryImpl) FindLoginExternal(ctx context.Context, providerID uint, subject string) (model.LoginExternal, error) {
+	var login model.LoginExternal
+	if err := r.db.WithContext(ctx).Where("external_provider_id = ? AND provider_subject = ?", providerID, subject).
+		First(&login).Error; err != nil {
+		return model.LoginExternal{}, database.HandleErrorDB(err)
+	}
+	return login, nil
+}
+
+func (r ExternalLoginRepositoryImpl) FindLoginExternalsByUser(ctx context.Context, userID uint) ([]model.LoginExternal, error) {
+	var logins []model.LoginExternal
+	if err := r.db.WithContext(ctx).Preload("Provider").Where("user_id = ?", userID).
+		Order("login_external_id").Find(&logins).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return logins, nil
+}
+
+func (r ExternalLoginRepositoryImpl) CreateLoginExternal(ctx context.Context, login *model.LoginExternal) (model.LoginExternal, error) {
+	if err := r.db.WithContext(ctx).Omit("User", "Provider").Create(login).Error; err != nil {
+		log.Errorf("error create external login: %v", err)
+		return model.LoginExternal{}, database.HandleErrorDB(err)
+	}
+	return *login, nil
+}
+
+func (r ExternalLoginRepositoryImpl) CreateExternalUser(ctx context.Context, account *model.Account, login *model.LoginExternal) (model.LoginExternal, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+		login.UserID = account.ID
+		return tx.Omit("User", "Provider").Create(login).Error
+	})
+	if err != nil {
+		log.Errorf("error create external user: %v", err)
+		return model.LoginExternal{}, database.HandleErrorDB(err)
+	}
+	return *login, nil
+}
+
+func (r ExternalLoginRepositoryImpl) DeleteLoginExternal(ctx context.Context, userID uint, providerID uint) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND external_provider_id = ?", userID, providerID).
+		Delete(&model.LoginExternal{})
+	if result.Error != nil {
+		log.Errorf("error delete external login: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrExternalAccountNotFound
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	CreateInvitation(ctx context.Context, invitation *model.Invitation) error
+	FindInvitations(ctx context.Context) ([]model.Invitation, error)
+	RevokeInvitation(ctx context.Context, invitationID uint) error
+	ConsumeInvitation(ctx context.Context, tx *gorm.DB, codeHash string, email string) (*model.Invitation, error)
+}
+
+type InvitationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepositoryImpl {
+	return &InvitationRepositoryImpl{db: db}
+}
+
+func (r InvitationRepositoryImpl) CreateInvitation(ctx context.Context, invitation *model.Invitation) error {
+	if err := r.db.WithContext(ctx).Create(invitation).Error; err != nil {
+		log.Errorf("error create invitation: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r InvitationRepositoryImpl) FindInvitations(ctx context.Context) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	if err := r.db.WithContext(ctx).Order("invitation_id desc").Find(&invitations).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return invitations, nil
+}
+
+func (r InvitationRepositoryImpl) RevokeInvitation(ctx context.Context, invitationID uint) error {
+	result := r.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("invitation_id = ? AND revoked_at IS NULL", invitationID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Errorf("error revoke invitation: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrInvitationNotFound
+	}
+	return nil
+}
+
+// ConsumeInvitation uses the invitation of the code within the registration transaction,
+// so the use is undone when the registration fails. The use is counted atomically, so
+// the concurrent registrations can't exceed the max uses. The conditions are the ones
+// of the Invitation.IsUsableBy.
+func (r InvitationRepositoryImpl) ConsumeInvitation(ctx context.Context, tx *gorm.DB, codeHash string, email string) (*model.Invitation, error) {
+	result := tx.WithContext(ctx).Model(&model.Invitation{}).
+		Where("invitation_code = ? AND revoked_at IS NULL AND invitation_expiration > ? AND uses < max_uses", codeHash, time.Now()).
+		Where("email = '' OR LOWER(email) = LOWER(?)", email).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		log.Errorf("error consume invitation: %v", result.Error)
+		return nil, database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return nil, apperror.ErrInvalidInvitation
+	}
+
+	var invitation model.Invitation
+	if err := tx.WithContext(ctx).Where("invitation_code = ?", codeHash).First(&invitation).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return &invitation, nil
+}
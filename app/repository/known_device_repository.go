@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type KnownDeviceRepository interface {
+	CreateKnownDevice(ctx context.Context, device *model.KnownDevice) (model.KnownDevice, error)
+	FindKnownDevice(ctx context.Context, userID uint, fingerprint string) (model.KnownDevice, error)
+	CountKnownDevices(ctx context.Context, userID uint) (int64, error)
+	UpdateKnownDeviceLastSeen(ctx context.Context, deviceID uint, lastSeenAt time.Time) error
+	ConsumeDeviceReport(ctx context.Context, reportTokenHash string) (model.KnownDevice, error)
+}
+
+type KnownDeviceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewKnownDeviceRepository(db *gorm.DB) *KnownDeviceRepositoryImpl {
+	return &KnownDeviceRepositoryImpl{db: db}
+}
+
+func (r KnownDeviceRepositoryImpl) CreateKnownDevice(ctx context.Context, device *model.KnownDevice) (model.KnownDevice, error) {
+	if err := r.db.WithContext(ctx).Create(device).Error; err != nil {
+		log.Errorf("error create known device: %v", err)
+		return model.KnownDevice{}, database.HandleErrorDB(err)
+	}
+	return *device, nil
+}
+
+func (r KnownDeviceRepositoryImpl) FindKnownDevice(ctx context.Context, userID uint, fingerprint string) (model.KnownDevice, error) {
+	var device model.KnownDevice
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND fingerprint = ?", userID, fingerprint).
+		First(&device).Error; err != nil {
+		return model.KnownDevice{}, database.HandleErrorDB(err)
+	}
+	return device, nil
+}
+
+func (r KnownDeviceRepositoryImpl) CountKnownDevices(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		log.Errorf("error count known devices: %v", err)
+		return 0, database.HandleErrorDB(err)
+	}
+	return count, nil
+}
+
+func (r KnownDeviceRepositoryImpl) UpdateKnownDeviceLastSeen(ctx context.Context, deviceID uint, lastSeenAt time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&model.KnownDevice{ID: deviceID}).Update("last_seen_at", lastSeenAt).Error; err != nil {
+		log.Errorf("error update known device: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+// ConsumeDeviceReport deletes the device of the unexpired report token, so the report
+// link can only be used once and the device is treated as unknown on the next login
+func (r KnownDeviceRepositoryImpl) ConsumeDeviceReport(ctx context.Context, reportTokenHash string) (model.KnownDevice, error) {
+	var device model.KnownDevice
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("report_token_hash = ? AND report_token_expiration > ?", reportTokenHash, time.Now()).
+			First(&device).Error; err != nil {
+			return err
+		}
+
+		// The concurrent request with the same token loses the race
+		result := tx.Unscoped().Delete(&model.KnownDevice{}, device.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected <= 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return model.KnownDevice{}, database.HandleErrorDB(err)
+	}
+	return device, nil
+}
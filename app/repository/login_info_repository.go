@@ -1,16 +1,22 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/domain/model"
 	"gorm.io/gorm"
 )
 
 type LoginInfoRepository interface {
-	CreateLoginInfo(info *model.LoginInfo) (model.LoginInfo, error)
-	FindLoginInfo(info *model.LoginInfo) error
-	UpdateLoginInfo(newInfo *model.LoginInfo) (model.LoginInfo, error)
-	DeleteLoginInfo(info *model.LoginInfo) (bool, error)
+	CreateLoginInfo(ctx context.Context, info *model.LoginInfo) (model.LoginInfo, error)
+	FindLoginInfo(ctx context.Context, info *model.LoginInfo) error
+	UpdateLoginInfo(ctx context.Context, newInfo *model.LoginInfo) (model.LoginInfo, error)
+	DeleteLoginInfo(ctx context.Context, info *model.LoginInfo) (bool, error)
+	CreatePasswordRecovery(ctx context.Context, userID uint, recovery *model.PasswordRecovery) error
+	FindLoginInfoByRecoveryToken(ctx context.Context, tokenHash string) (model.LoginInfo, error)
+	UpdatePassword(ctx context.Context, info *model.LoginInfo) error
 }
 
 type LoginInfoRepositoryImpl struct {
@@ -21,23 +27,23 @@ func NewLoginInfoRepository(db *gorm.DB) *LoginInfoRepositoryImpl {
 	return &LoginInfoRepositoryImpl{db: db}
 }
 
-func (r LoginInfoRepositoryImpl) CreateLoginInfo(info *model.LoginInfo) (model.LoginInfo, error) {
+func (r LoginInfoRepositoryImpl) CreateLoginInfo(ctx context.Context, info *model.LoginInfo) (model.LoginInfo, error) {
 	// Check hash algorithm already exists
 	var hashAlgorithm model.HashAlgorithm
-	if err := r.db.FirstOrCreate(&hashAlgorithm, &info.HashAlgorithm).Error; err != nil {
+	if err := r.db.WithContext(ctx).FirstOrCreate(&hashAlgorithm, &info.HashAlgorithm).Error; err != nil {
 		return model.LoginInfo{}, database.HandleErrorDB(err)
 	}
 
 	// Create login info using the hashAlgorithm found
 	info.HashAlgorithm = hashAlgorithm
-	if err := r.db.Create(&info).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(&info).Error; err != nil {
 		return model.LoginInfo{}, database.HandleErrorDB(err)
 	}
 	return *info, nil
 }
 
-func (r LoginInfoRepositoryImpl) FindLoginInfo(info *model.LoginInfo) error {
-	err := r.db.Preload("EmailVerification").
+func (r LoginInfoRepositoryImpl) FindLoginInfo(ctx context.Context, info *model.LoginInfo) error {
+	err := r.db.WithContext(ctx).Preload("EmailVerification").
 		Preload("HashAlgorithm").
 		Where(info).
 		First(&info).Error
@@ -47,18 +53,88 @@ func (r LoginInfoRepositoryImpl) FindLoginInfo(info *model.LoginInfo) error {
 	return nil
 }
 
-func (r LoginInfoRepositoryImpl) UpdateLoginInfo(newInfo *model.LoginInfo) (model.LoginInfo, error) {
-	result := r.db.Model(&model.LoginInfo{ID: newInfo.ID}).Updates(&newInfo)
+func (r LoginInfoRepositoryImpl) UpdateLoginInfo(ctx context.Context, newInfo *model.LoginInfo) (model.LoginInfo, error) {
+	result := r.db.WithContext(ctx).Model(&model.LoginInfo{ID: newInfo.ID}).Updates(&newInfo)
 	if result.Error != nil {
 		return model.LoginInfo{}, database.HandleErrorDB(result.Error)
 	}
 	return *newInfo, nil
 }
 
-func (r LoginInfoRepositoryImpl) DeleteLoginInfo(info *model.LoginInfo) (bool, error) {
-	result := r.db.Delete(&info)
+func (r LoginInfoRepositoryImpl) DeleteLoginInfo(ctx context.Context, info *model.LoginInfo) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&info)
 	if result.Error != nil {
 		return false, database.HandleErrorDB(result.Error)
 	}
 	return result.RowsAffected > 0, nil
 }
+
+// CreatePasswordRecovery replaces the password recovery of the user, so only the
+// latest recovery token is valid
+func (r LoginInfoRepositoryImpl) CreatePasswordRecovery(ctx context.Context, userID uint, recovery *model.PasswordRecovery) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var info model.LoginInfo
+		if err := tx.First(&info, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(recovery).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&info).Update("password_recovery_id", recovery.ID).Error; err != nil {
+			return err
+		}
+		if info.PasswordRecoveryID != nil {
+			return tx.Unscoped().Delete(&model.PasswordRecovery{}, *info.PasswordRecoveryID).Error
+		}
+		return nil
+	})
+	return database.HandleErrorDB(err)
+}
+
+func (r LoginInfoRepositoryImpl) FindLoginInfoByRecoveryToken(ctx context.Context, tokenHash string) (model.LoginInfo, error) {
+	var info model.LoginInfo
+	err := r.db.WithContext(ctx).Preload("PasswordRecovery").
+		Joins("JOIN password_recovery_info ON password_recovery_info.password_recovery_id = user_login_info.password_recovery_id").
+		Where("password_recovery_info.recovery_token = ? AND password_recovery_info.token_expiration > ?", tokenHash, time.Now()).
+		First(&info).Error
+	if err != nil {
+		return model.LoginInfo{}, database.HandleErrorDB(err)
+	}
+	return info, nil
+}
+
+// UpdatePassword stores the new password of the user and removes the password
+// recovery, so the recovery token can't be used again
+func (r LoginInfoRepositoryImpl) UpdatePassword(ctx context.Context, info *model.LoginInfo) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var hashAlgorithm model.HashAlgorithm
+		if err := tx.FirstOrCreate(&hashAlgorithm, &info.HashAlgorithm).Error; err != nil {
+			return err
+		}
+		info.HashAlgorithm, info.HashAlgorithmID = hashAlgorithm, hashAlgorithm.ID
+
+		// The recovery is removed only when it's still the one being used
+		result := tx.Model(&model.LoginInfo{ID: info.ID}).
+			Where("password_recovery_id IS NOT DISTINCT FROM ?", info.PasswordRecoveryID).
+			Updates(map[string]interface{}{
+				"password_hash":        info.PasswordHash,
+				"password_salt":        info.PasswordSalt,
+				"hash_algorithm_id":    info.HashAlgorithmID,
+				"password_recovery_id": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected <= 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if info.PasswordRecoveryID != nil {
+			if err := tx.Unscoped().Delete(&model.PasswordRecovery{}, *info.PasswordRecoveryID).Error; err != nil {
+				return err
+			}
+		}
+		info.PasswordRecoveryID = nil
+		return nil
+	})
+	return database.HandleErrorDB(err)
+}
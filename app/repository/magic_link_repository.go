@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type MagicLinkRepository interface {
+	CreateMagicLink(ctx context.Context, link *model.MagicLink) (model.MagicLink, error)
+	CountRecentMagicLinks(ctx context.Context, userID uint, since time.Time) (int64, error)
+	ConsumeMagicLink(ctx context.Context, tokenHash string, nonceHash string) (model.MagicLink, error)
+}
+
+type MagicLinkRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkRepository(db *gorm.DB) *MagicLinkRepositoryImpl {
+	return &MagicLinkRepositoryImpl{db: db}
+}
+
+func (r MagicLinkRepositoryImpl) CreateMagicLink(ctx context.Context, link *model.MagicLink) (model.MagicLink, error) {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		log.Errorf("error create magic link: %v", err)
+		return model.MagicLink{}, database.HandleErrorDB(err)
+	}
+	return *link, nil
+}
+
+func (r MagicLinkRepositoryImpl) CountRecentMagicLinks(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.MagicLink{}).
+		Where("user_id = ? AND created_at > ?", userID, since).Count(&count).Error; err != nil {
+		log.Errorf("error count magic links: %v", err)
+		return 0, database.HandleErrorDB(err)
+	}
+	return count, nil
+}
+
+func (r MagicLinkRepositoryImpl) ConsumeMagicLink(ctx context.Context, tokenHash string, nonceHash string) (model.MagicLink, error) {
+	var link model.MagicLink
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where("token_hash = ?", tokenHash).First(&link).Error; err != nil {
+			return err
+		}
+		if !link.IsUsable(nonceHash, now) {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Mark the link as consumed, the concurrent request with the same link loses the race
+		result := tx.Model(&model.MagicLink{}).
+			Where("magic_link_id = ? AND consumed_at IS NULL", link.ID).
+			Update("consumed_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected <= 0 {
+			return gorm.ErrRecordNotFound
+		}
+		link.ConsumedAt = &now
+		return nil
+	})
+	if err != nil {
+		return model.MagicLink{}, database.HandleErrorDB(err)
+	}
+	return link, nil
+}
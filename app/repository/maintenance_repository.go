@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// softDeletedModels are the models whose soft deleted rows are hard deleted after the
+// retention, the rows referenced by other tables (e.g. accounts) are kept
+var softDeletedModels = []interface{}{
+	&model.Session{},
+	&model.MagicLink{},
+	&model.PasswordRecovery{},
+	&model.KnownDevice{},
+	&model.PersonalAccessToken{},
+	&model.APIKey{},
+	&model.LoginExternal{},
+	&model.WebAuthnCredential{},
+}
+
+type MaintenanceRepository interface {
+	PurgeExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error)
+	PurgeExpiredTokens(ctx context.Context, expiredBefore time.Time) (int64, error)
+	PurgeSoftDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	FindStuckVerifications(ctx context.Context, updatedBefore time.Time, createdAfter time.Time, limit int) ([]model.LoginInfo, error)
+}
+
+type MaintenanceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepositoryImpl {
+	return &MaintenanceRepositoryImpl{db: db}
+}
+
+func (r MaintenanceRepositoryImpl) PurgeExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("session_expiration < ?", expiredBefore).Delete(&model.Session{})
+	if result.Error != nil {
+		log.Errorf("error purge expired sessions: %v", result.Error)
+		return 0, database.HandleErrorDB(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// PurgeExpiredTokens deletes the expired single use tokens, the verification token of
+// the verified email is cleared since the verification status is still needed
+func (r MaintenanceRepositoryImpl) PurgeExpiredTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deletions := []struct {
+			model  interface{}
+			column string
+		}{
+			{&model.MagicLink{}, "magic_link_expiration"},
+			{&model.WebAuthnCeremony{}, "ceremony_expiration"},
+			{&model.ExternalLoginState{}, "state_expiration"},
+			{&model.DataExport{}, "download_expiration"},
+		}
+		for _, deletion := range deletions {
+			result := tx.Unscoped().Where(deletion.column+" < ?", expiredBefore).Delete(deletion.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			total += result.RowsAffected
+		}
+
+		// Unlink the expired password recoveries before deleting them
+		expiredRecoveries := tx.Model(&model.PasswordRecovery{}).Unscoped().
+			Select("password_recovery_id").Where("token_expiration < ?", expiredBefore)
+		if err := tx.Model(&model.LoginInfo{}).Where("password_recovery_id IN (?)", expiredRecoveries).
+			Update("password_recovery_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("token_expiration < ?", expiredBefore).Delete(&model.PasswordRecovery{})
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+
+		result = tx.Model(&model.EmailVerification{}).
+			Where("status = ? AND token_expiration < ? AND verification_token IS NOT NULL", model.EmailVerified, expiredBefore).
+			Update("verification_token", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		log.Errorf("error purge expired tokens: %v", err)
+		return 0, database.HandleErrorDB(err)
+	}
+	return total, nil
+}
+
+// PurgeSoftDeleted deletes the soft deleted rows after the retention, the published
+// events are kept for the same retention
+func (r MaintenanceRepositoryImpl) PurgeSoftDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var total int64
+	for _, softDeleted := range softDeletedModels {
+		result := r.db.WithContext(ctx).Unscoped().Where("deleted_at < ?", deletedBefore).Delete(softDeleted)
+		if result.Error != nil {
+			log.Errorf("error purge soft deleted rows: %v", result.Error)
+			return total, database.HandleErrorDB(result.Error)
+		}
+		total += result.RowsAffected
+	}
+
+	result := r.db.WithContext(ctx).Where("published_at < ?", deletedBefore).Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		log.Errorf("error purge published events: %v", result.Error)
+		return total, database.HandleErrorDB(result.Error)
+	}
+	return total + result.RowsAffected, nil
+}
+
+// FindStuckVerifications finds the credentials whose verification email was never sent
+// or failed to be sent, only the registrations within the creation window are retried
+func (r MaintenanceRepositoryImpl) FindStuckVerifications(ctx context.Context, updatedBefore time.Time, createdAfter time.Time, limit int) ([]model.LoginInfo, error) {
+	var credentials []model.LoginInfo
+	err := r.db.WithContext(ctx).Preload("EmailVerification").
+		Joins("JOIN email_verification_info ON email_verification_info.email_verification_id = user_login_info.email_verification_id").
+		Where("email_verification_info.status IN ? AND email_verification_info.updated_at < ? AND email_verification_info.created_at > ?",
+			[]string{model.VerificationPending, model.VerificationError}, updatedBefore, createdAfter).
+		Order("email_verification_info.updated_at").
+		Limit(limit).
+		Find(&credentials).Error
+	if err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return credentials, nil
+}
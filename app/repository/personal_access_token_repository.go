@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository interface {
+	CreatePersonalAccessToken(ctx context.Context, token *model.PersonalAccessToken) (model.PersonalAccessToken, error)
+	FindPersonalAccessTokens(ctx context.Context, userID uint) ([]model.PersonalAccessToken, error)
+	FindPersonalAccessTokenByPrefix(ctx context.Context, prefix string) (model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, userID uint, prefix string) error
+	UpdatePersonalAccessTokenLastUsed(ctx context.Context, tokenID uint, usedAt time.Time) error
+}
+
+type PersonalAccessTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) *PersonalAccessTokenRepositoryImpl {
+	return &PersonalAccessTokenRepositoryImpl{db: db}
+}
+
+func (r PersonalAccessTokenRepositoryImpl) CreatePersonalAccessToken(ctx context.Context, token *model.PersonalAccessToken) (model.PersonalAccessToken, error) {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		log.Errorf("error create personal access token: %v", err)
+		return model.PersonalAccessToken{}, database.HandleErrorDB(err)
+	}
+	return *token, nil
+}
+
+func (r PersonalAccessTokenRepositoryImpl) FindPersonalAccessTokens(ctx context.Context, userID uint) ([]model.PersonalAccessToken, error) {
+	var tokens []model.PersonalAccessToken
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("token_id").Find(&tokens).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return tokens, nil
+}
+
+func (r PersonalAccessTokenRepositoryImpl) FindPersonalAccessTokenByPrefix(ctx context.Context, prefix string) (model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.db.WithContext(ctx).Preload("User").Where("token_prefix = ?", prefix).First(&token).Error; err != nil {
+		return model.PersonalAccessToken{}, database.HandleErrorDB(err)
+	}
+	return token, nil
+}
+
+func (r PersonalAccessTokenRepositoryImpl) RevokePersonalAccessToken(ctx context.Context, userID uint, prefix string) error {
+	// The token is only revoked by its owner
+	result := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND token_prefix = ? AND revoked_at IS NULL", userID, prefix).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Errorf("error revoke personal access token: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+func (r PersonalAccessTokenRepositoryImpl) UpdatePersonalAccessTokenLastUsed(ctx context.Context, tokenID uint, usedAt time.Time) error {
+	// Update the column only, so the 'updated_at' keeps the last change of the token
+	if err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{ID: tokenID}).UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		log.Errorf("error update personal access token last used: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
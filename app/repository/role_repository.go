@@ -1,19 +1,22 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/domain/model"
 	"gorm.io/gorm"
 )
 
 type RoleRepository interface {
-	CreateRole(role *model.Role) (model.Role, error)
-	AssignRolePermissions(role *model.Role, permissions ...model.Permission) error
-	UpdateRole(newRole *model.Role) (model.Role, error)
-	DeleteRole(role *model.Role) (bool, error)
+	CreateRole(ctx context.Context, role *model.Role) (model.Role, error)
+	FindRoleByID(ctx context.Context, roleID uint) (model.Role, error)
+	AssignRolePermissions(ctx context.Context, role *model.Role, permissions ...model.Permission) error
+	UpdateRole(ctx context.Context, newRole *model.Role) (model.Role, error)
+	DeleteRole(ctx context.Context, role *model.Role) (bool, error)
 }
 
 type RoleRepositoryImpl struct {
@@ -24,15 +27,23 @@ func NewRoleRepository(db *gorm.DB) *RoleRepositoryImpl {
 	return &RoleRepositoryImpl{db: db}
 }
 
-func (r RoleRepositoryImpl) CreateRole(role *model.Role) (model.Role, error) {
-	if err := r.db.Create(&role).Error; err != nil {
+func (r RoleRepositoryImpl) CreateRole(ctx context.Context, role *model.Role) (model.Role, error) {
+	if err := r.db.WithContext(ctx).Create(&role).Error; err != nil {
 		log.Errorf("error create new role: %v", err)
 		return model.Role{}, err
 	}
 	return *role, nil
 }
 
-func (r RoleRepositoryImpl) AssignRolePermissions(role *model.Role, permissions ...model.Permission) error {
+func (r RoleRepositoryImpl) FindRoleByID(ctx context.Context, roleID uint) (model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).First(&role, roleID).Error; err != nil {
+		return model.Role{}, database.HandleErrorDB(err)
+	}
+	return role, nil
+}
+
+func (r RoleRepositoryImpl) AssignRolePermissions(ctx context.Context, role *model.Role, permissions ...model.Permission) error {
 	// Check the permission ids
 	if len(permissions) == 0 {
 		return errors.New("permission id's should not be empty")
@@ -43,7 +54,7 @@ func (r RoleRepositoryImpl) AssignRolePermissions(role *model.Role, permissions
 	role.Permissions = append(role.Permissions, permissions...)
 
 	// Save the role with the updated permissions
-	if err := r.db.Save(&role).Error; err != nil {
+	if err := r.db.WithContext(ctx).Save(&role).Error; err != nil {
 		log.Errorf("error save role: %v", err)
 		return err
 	}
@@ -51,16 +62,16 @@ func (r RoleRepositoryImpl) AssignRolePermissions(role *model.Role, permissions
 	return nil
 }
 
-func (r RoleRepositoryImpl) UpdateRole(newRole *model.Role) (model.Role, error) {
-	result := r.db.Model(&model.Role{ID: newRole.ID}).Updates(&newRole)
+func (r RoleRepositoryImpl) UpdateRole(ctx context.Context, newRole *model.Role) (model.Role, error) {
+	result := r.db.WithContext(ctx).Model(&model.Role{ID: newRole.ID}).Updates(&newRole)
 	if result.Error != nil {
 		log.Errorf("error update role: %v", result.Error)
 		return model.Role{}, result.Error
 	}
 	return *newRole, nil
 }
-func (r RoleRepositoryImpl) DeleteRole(role *model.Role) (bool, error) {
-	result := r.db.Delete(&role)
+func (r RoleRepositoryImpl) DeleteRole(ctx context.Context, role *model.Role) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&role)
 	if result.Error != nil {
 		log.Errorf("error delete role: %v", result.Error)
 		return false, result.Error
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ServiceAccountRepository interface {
+	CreateServiceAccount(ctx context.Context, account *model.ServiceAccount) (model.ServiceAccount, error)
+	FindServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error)
+	FindServiceAccountByID(ctx context.Context, serviceAccountID uint) (model.ServiceAccount, error)
+	FindAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	RotateAPIKey(ctx context.Context, key *model.APIKey, overlapUntil time.Time) (model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, prefix string) error
+	UpdateAPIKeyLastUsed(ctx context.Context, keyID uint, usedAt time.Time) error
+}
+
+type ServiceAccountRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewServiceAccountRepository(db *gorm.DB) *ServiceAccountRepositoryImpl {
+	return &ServiceAccountRepositoryImpl{db: db}
+}
+
+func (r ServiceAccountRepositoryImpl) CreateServiceAccount(ctx context.Context, account *model.ServiceAccount) (model.ServiceAccount, error) {
+	// The API keys of the account are created within the same transaction
+	if err := r.db.WithContext(ctx).Create(account).Error; err != nil {
+		log.Errorf("error create service account: %v", err)
+		return model.ServiceAccount{}, database.HandleErrorDB(err)
+	}
+	return *account, nil
+}
+
+func (r ServiceAccountRepositoryImpl) FindServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	var accounts []model.ServiceAccount
+	if err := r.db.WithContext(ctx).Preload("APIKeys", orderAPIKeysByNewest).Order("service_account_id").Find(&accounts).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return accounts, nil
+}
+
+func (r ServiceAccountRepositoryImpl) FindServiceAccountByID(ctx context.Context, serviceAccountID uint) (model.ServiceAccount, error) {
+	var account model.ServiceAccount
+	if err := r.db.WithContext(ctx).Preload("APIKeys", orderAPIKeysByNewest).First(&account, serviceAccountID).Error; err != nil {
+		return model.ServiceAccount{}, database.HandleErrorDB(err)
+	}
+	return account, nil
+}
+
+func (r ServiceAccountRepositoryImpl) FindAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Preload("ServiceAccount").Where("key_prefix = ?", prefix).First(&key).Error; err != nil {
+		return model.APIKey{}, database.HandleErrorDB(err)
+	}
+	return key, nil
+}
+
+func (r ServiceAccountRepositoryImpl) RotateAPIKey(ctx context.Context, key *model.APIKey, overlapUntil time.Time) (model.APIKey, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Shorten the expiration of the current keys to the end of the overlap window,
+		// the keys already expiring before the window are left untouched
+		if err := tx.Model(&model.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", key.ServiceAccountID).
+			Where("key_expiration IS NULL OR key_expiration > ?", overlapUntil).
+			Update("key_expiration", overlapUntil).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+	if err != nil {
+		log.Errorf("error rotate api key: %v", err)
+		return model.APIKey{}, database.HandleErrorDB(err)
+	}
+	return *key, nil
+}
+
+func (r ServiceAccountRepositoryImpl) RevokeAPIKey(ctx context.Context, prefix string) error {
+	result := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("key_prefix = ? AND revoked_at IS NULL", prefix).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Errorf("error revoke api key: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r ServiceAccountRepositoryImpl) UpdateAPIKeyLastUsed(ctx context.Context, keyID uint, usedAt time.Time) error {
+	// Update the column only, so the 'updated_at' keeps the last change of the key
+	if err := r.db.WithContext(ctx).Model(&model.APIKey{ID: keyID}).UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		log.Errorf("error update api key last used: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+// orderAPIKeysByNewest preloads the API keys of the service account from the newest one
+func orderAPIKeysByNewest(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at desc")
+}
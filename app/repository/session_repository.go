@@ -1,19 +1,29 @@
 package repository
 
 import (
-	"errors"
+	"context"
 	"time"
 
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
 	"github.com/budgetin-app/user-service/app/domain/model"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type SessionRepository interface {
-	CreateSession(userID uint, token string) (model.Session, error)
-	FindActiveSession(userID uint) (*model.Session, error)
-	UpdateSessionStatus(sessionID uint, status string) (bool, error)
-	DeleteSessionByToken(authToken string) error
+	CreateSession(ctx context.Context, session *model.Session) (model.Session, error)
+	FindActiveSession(ctx context.Context, userID uint) (*model.Session, error)
+	FindActiveSessionByToken(ctx context.Context, authToken string) (*model.Session, error)
+	FindActiveSessionsByUser(ctx context.Context, userID uint) ([]model.Session, error)
+	FindUserSession(ctx context.Context, userID uint, sessionID uint) (*model.Session, error)
+	UpdateSessionAuthentication(ctx context.Context, session *model.Session) error
+	UpdateSessionStatus(ctx context.Context, sessionID uint, status string) (bool, error)
+	ExtendSession(ctx context.Context, session *model.Session, seenBefore time.Time) (bool, error)
+	DeleteSessionByToken(ctx context.Context, authToken string) error
+	DeleteSessionsByUser(ctx context.Context, userID uint) (int64, error)
+	DeleteUserSession(ctx context.Context, userID uint, sessionID uint) error
+	CountActiveSessions(ctx context.Context) (int64, error)
 }
 
 type SessionRepositoryImpl struct {
@@ -24,50 +34,136 @@ func NewSessionRepository(db *gorm.DB) *SessionRepositoryImpl {
 	return &SessionRepositoryImpl{db: db}
 }
 
-func (r SessionRepositoryImpl) CreateSession(userID uint, token string) (model.Session, error) {
-	session := &model.Session{
-		UserID: userID,
-		Token:  token,
-	}
-	if err := r.db.Create(&session).Error; err != nil {
+func (r SessionRepositoryImpl) CreateSession(ctx context.Context, session *model.Session) (model.Session, error) {
+	if err := r.db.WithContext(ctx).Create(&session).Error; err != nil {
 		log.Errorf("error create new session: %v", err)
-		return model.Session{}, err
+		return model.Session{}, database.HandleErrorDB(err)
 	}
 	return *session, nil
 }
 
-func (r SessionRepositoryImpl) FindActiveSession(userID uint) (*model.Session, error) {
+func (r SessionRepositoryImpl) FindActiveSession(ctx context.Context, userID uint) (*model.Session, error) {
 	var session model.Session
 
-	// Find the last active session associated with the given userID
-	if err := r.db.Where("user_id = ? AND session_expiration > ?", userID, time.Now()).
+	// Find the last active regular session associated with the given userID, the
+	// impersonation session isn't the user's own session
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND session_expiration > ? AND remember_me = ? AND impersonator_id IS NULL", userID, time.Now(), false).
 		Order("session_expiration desc").First(&session).Error; err != nil {
-		return nil, err
+		return nil, database.HandleErrorDB(err)
+	}
+
+	return &session, nil
+}
+
+func (r SessionRepositoryImpl) FindActiveSessionByToken(ctx context.Context, authToken string) (*model.Session, error) {
+	var session model.Session
+
+	// Find the unexpired session of the token along with the user account
+	if err := r.db.WithContext(ctx).Preload("User").
+		Where("session_token = ? AND session_expiration > ?", authToken, time.Now()).
+		First(&session).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+
+	return &session, nil
+}
+
+func (r SessionRepositoryImpl) FindActiveSessionsByUser(ctx context.Context, userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND session_expiration > ?", userID, time.Now()).
+		Order("last_seen_at desc").Find(&sessions).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
 	}
+	return sessions, nil
+}
 
+func (r SessionRepositoryImpl) FindUserSession(ctx context.Context, userID uint, sessionID uint) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ? AND session_expiration > ?", sessionID, userID, time.Now()).
+		First(&session).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
 	return &session, nil
 }
 
-func (r SessionRepositoryImpl) UpdateSessionStatus(sessionID uint, status string) (bool, error) {
-	result := r.db.Model(model.Session{ID: sessionID}).Update("status", status)
+func (r SessionRepositoryImpl) UpdateSessionAuthentication(ctx context.Context, session *model.Session) error {
+	if err := r.db.WithContext(ctx).Model(&model.Session{ID: session.ID}).
+		Select("authenticated_at", "auth_methods").
+		Updates(session).Error; err != nil {
+		log.Errorf("error update session authentication: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r SessionRepositoryImpl) UpdateSessionStatus(ctx context.Context, sessionID uint, status string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(model.Session{ID: sessionID}).Update("status", status)
 	if result.Error != nil {
 		log.Errorf("error finish session: %v", result.Error)
-		return false, result.Error
+		return false, database.HandleErrorDB(result.Error)
 	}
 	return result.RowsAffected > 0, nil
 }
 
-func (r SessionRepositoryImpl) DeleteSessionByToken(authToken string) error {
-	result := r.db.Where("session_token = ?", authToken).Delete(&model.Session{})
+// ExtendSession stores the extended expiration of the session, the session seen after
+// the given time is left untouched so the concurrent requests only write it once
+func (r SessionRepositoryImpl) ExtendSession(ctx context.Context, session *model.Session, seenBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("session_id = ? AND last_seen_at < ?", session.ID, seenBefore).
+		Updates(map[string]interface{}{
+			"last_seen_at":       session.LastSeenAt,
+			"session_expiration": session.ExpiredAt,
+		})
+	if result.Error != nil {
+		log.Errorf("error extend session: %v", result.Error)
+		return false, database.HandleErrorDB(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r SessionRepositoryImpl) DeleteSessionByToken(ctx context.Context, authToken string) error {
+	result := r.db.WithContext(ctx).Where("session_token = ?", authToken).Delete(&model.Session{})
 	if result.Error != nil {
 		log.Errorf("error delete session: %v", result.Error)
-		return result.Error
+		return database.HandleErrorDB(result.Error)
 	}
 
 	log.Debugf("session deleted count: %d", result.RowsAffected)
 	if result.RowsAffected <= 0 {
-		return errors.New("no session deleted")
+		return apperror.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r SessionRepositoryImpl) DeleteSessionsByUser(ctx context.Context, userID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Session{})
+	if result.Error != nil {
+		log.Errorf("error delete user sessions: %v", result.Error)
+		return 0, database.HandleErrorDB(result.Error)
 	}
+	return result.RowsAffected, nil
+}
 
+func (r SessionRepositoryImpl) DeleteUserSession(ctx context.Context, userID uint, sessionID uint) error {
+	result := r.db.WithContext(ctx).Where("session_id = ? AND user_id = ?", sessionID, userID).Delete(&model.Session{})
+	if result.Error != nil {
+		log.Errorf("error delete session: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrSessionNotFound
+	}
 	return nil
 }
+
+func (r SessionRepositoryImpl) CountActiveSessions(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Session{}).Where("session_expiration > ?", time.Now()).Count(&count).Error; err != nil {
+		log.Errorf("error count active sessions: %v", err)
+		return 0, database.HandleErrorDB(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"gorm.io/gorm"
+)
+
+// UserFilter filters the users, the zero fields are not filtered. The query matches the
+// prefix of the username or the email. The users are ordered from the latest registered
+// and paginated by the 'AfterID' cursor.
+type UserFilter struct {
+	Query              string
+	RoleID             uint
+	Status             string
+	VerificationStatus string
+	CreatedFrom        *time.Time
+	CreatedTo          *time.Time
+	AfterID            uint
+	Limit              int
+}
+
+type UserDirectoryRepository interface {
+	FindUsers(ctx context.Context, filter UserFilter) ([]model.UserSummary, error)
+}
+
+type UserDirectoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserDirectoryRepository(db *gorm.DB) *UserDirectoryRepositoryImpl {
+	return &UserDirectoryRepositoryImpl{db: db}
+}
+
+func (r UserDirectoryRepositoryImpl) FindUsers(ctx context.Context, filter UserFilter) ([]model.UserSummary, error) {
+	query := r.db.WithContext(ctx).Model(&model.Account{}).
+		Select(`user_accounts.user_id, user_login_info.username, user_login_info.email,
+			user_accounts.role_id, user_roles.role_name, user_accounts.status,
+			email_verification_info.status AS verification_status, user_accounts.created_at`).
+		Joins("LEFT JOIN user_roles ON user_roles.role_id = user_accounts.role_id").
+		Joins("LEFT JOIN user_login_info ON user_login_info.user_id = user_accounts.user_id AND user_login_info.deleted_at IS NULL").
+		Joins("LEFT JOIN email_verification_info ON email_verification_info.email_verification_id = user_login_info.email_verification_id")
+
+	if filter.Query != "" {
+		prefix := EscapeLike(filter.Query) + "%"
+		query = query.Where("user_login_info.username ILIKE ? OR user_login_info.email ILIKE ?", prefix, prefix)
+	}
+	if filter.RoleID > 0 {
+		query = query.Where("user_accounts.role_id = ?", filter.RoleID)
+	}
+	if filter.Status != "" {
+		query = query.Where("user_accounts.status = ?", filter.Status)
+	}
+	if filter.VerificationStatus != "" {
+		query = query.Where("email_verification_info.status = ?", filter.VerificationStatus)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("user_accounts.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("user_accounts.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.AfterID > 0 {
+		// The users are listed from the latest, so the next page has the lower ids
+		query = query.Where("user_accounts.user_id < ?", filter.AfterID)
+	}
+
+	var users []model.UserSummary
+	if err := query.Order("user_accounts.user_id desc").Limit(filter.Limit).Scan(&users).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return users, nil
+}
+
+// EscapeLike escapes the wildcards of the LIKE pattern, so the value is matched literally
+func EscapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type WebAuthnRepository interface {
+	CreateCeremony(ctx context.Context, ceremony *model.WebAuthnCeremony) error
+	ConsumeCeremony(ctx context.Context, challenge string) (model.WebAuthnCeremony, error)
+	CreateCredential(ctx context.Context, credential *model.WebAuthnCredential) (model.WebAuthnCredential, error)
+	FindCredentials(ctx context.Context, userID uint) ([]model.WebAuthnCredential, error)
+	UpdateCredentialUsage(ctx context.Context, credential *model.WebAuthnCredential, usedAt time.Time) error
+	DeleteCredential(ctx context.Context, userID uint, credentialID string) error
+}
+
+type WebAuthnRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) *WebAuthnRepositoryImpl {
+	return &WebAuthnRepositoryImpl{db: db}
+}
+
+func (r WebAuthnRepositoryImpl) CreateCeremony(ctx context.Context, ceremony *model.WebAuthnCeremony) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(ceremony).Error; err != nil {
+		log.Errorf("error create webauthn ceremony: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r WebAuthnRepositoryImpl) ConsumeCeremony(ctx context.Context, challenge string) (model.WebAuthnCeremony, error) {
+	var ceremony model.WebAuthnCeremony
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("challenge = ? AND ceremony_expiration > ?", challenge, time.Now()).First(&ceremony).Error; err != nil {
+			return err
+		}
+
+		// The challenge is single use, the concurrent response with the same challenge loses the race
+		result := tx.Unscoped().Delete(&ceremony)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected <= 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return model.WebAuthnCeremony{}, database.HandleErrorDB(err)
+	}
+	return ceremony, nil
+}
+
+func (r WebAuthnRepositoryImpl) CreateCredential(ctx context.Context, credential *model.WebAuthnCredential) (model.WebAuthnCredential, error) {
+	if err := r.db.WithContext(ctx).Omit("User").Create(credential).Error; err != nil {
+		log.Errorf("error create webauthn credential: %v", err)
+		return model.WebAuthnCredential{}, database.HandleErrorDB(err)
+	}
+	return *credential, nil
+}
+
+func (r WebAuthnRepositoryImpl) FindCredentials(ctx context.Context, userID uint) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("webauthn_credential_id").Find(&credentials).Error; err != nil {
+		return nil, database.HandleErrorDB(err)
+	}
+	return credentials, nil
+}
+
+func (r WebAuthnRepositoryImpl) UpdateCredentialUsage(ctx context.Context, credential *model.WebAuthnCredential, usedAt time.Time) error {
+	// Update the columns only, so the 'updated_at' keeps the last change of the credential
+	if err := r.db.WithContext(ctx).Model(&model.WebAuthnCredential{ID: credential.ID}).UpdateColumns(map[string]interface{}{
+		"sign_count":   credential.SignCount,
+		"backup_state": credential.BackupState,
+		"last_used_at": usedAt,
+	}).Error; err != nil {
+		log.Errorf("error update webauthn credential usage: %v", err)
+		return database.HandleErrorDB(err)
+	}
+	return nil
+}
+
+func (r WebAuthnRepositoryImpl) DeleteCredential(ctx context.Context, userID uint, credentialID string) error {
+	// The credential is only deleted by its owner
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND credential_id = ?", userID, credentialID).
+		Delete(&model.WebAuthnCredential{})
+	if result.Error != nil {
+		log.Errorf("error delete webauthn credential: %v", result.Error)
+		return database.HandleErrorDB(result.Error)
+	}
+	if result.RowsAffected <= 0 {
+		return apperror.ErrPasskeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+)
+
+// CORSConfig holds the cross-origin configuration of the gateway
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         string
+}
+
+// NewCORSConfig create the CORS configuration from the CORS_* environment variables, no
+// origin is allowed unless the CORS_ALLOWED_ORIGINS opts in, '*' allows every origin
+func NewCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: splitList(env.GetenvOrDefault("CORS_ALLOWED_ORIGINS", "")),
+		AllowedMethods: splitList(env.GetenvOrDefault("CORS_ALLOWED_METHODS", "GET,POST,DELETE,OPTIONS")),
+		AllowedHeaders: splitList(env.GetenvOrDefault("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Api-Key,X-Device-Secret,X-Request-Id")),
+		ExposedHeaders: []string{"X-Request-Id", "X-Impersonator-Id"},
+		MaxAge:         env.GetenvOrDefault("CORS_MAX_AGE", "600"),
+	}
+}
+
+// IsOriginAllowed checks the origin is listed in the allowed origins
+func (c CORSConfig) IsOriginAllowed(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// WithCORS handles the CORS preflight requests and sets the CORS headers of
+// the requests coming from the allowed origins
+func WithCORS(next http.Handler, config CORSConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !config.IsOriginAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+
+		// Answer the preflight request without calling the gateway
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			header.Set("Access-Control-Max-Age", config.MaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitList split the comma separated values
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EmailVerificationPath is the path of the emailed verification link
+const EmailVerificationPath = "/email-verification/"
+
+// emailVerificationPage asks the user to confirm the email address, the confirmation is
+// POSTed so the link prefetchers and the mail scanners opening the link don't confirm it
+var emailVerificationPage = template.Must(template.New("email_verification").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex">
+    <title>Email Verification</title>
+</head>
+<body style="font-family: Arial, sans-serif; background-color: #f2f2f2; padding: 20px;">
+    <div style="max-width: 600px; margin: 0 auto; background-color: #ffffff; padding: 30px; border-radius: 10px;">
+        <h2 style="color: #333333;">Email Verification</h2>
+        <p id="message">Click the button below to confirm your email address.</p>
+        <button id="confirm" type="button"
+            style="background-color: #007bff; color: #ffffff; padding: 10px 20px; border: none; border-radius: 5px;">Confirm
+            Email</button>
+    </div>
+    <script>
+        document.getElementById("confirm").addEventListener("click", async function () {
+            this.disabled = true;
+            const message = document.getElementById("message");
+            try {
+                const response = await fetch("/v1/users/email-verification", {
+                    method: "POST",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify({ verification_token: {{.}} }),
+                });
+                message.textContent = response.ok
+                    ? "Your email address is confirmed."
+                    : "The verification link is invalid or expired.";
+            } catch (e) {
+                message.textContent = "Failed to confirm your email address, please try again.";
+                this.disabled = false;
+            }
+        });
+    </script>
+</body>
+</html>
+`))
+
+// handleEmailVerification serves the confirmation page of the emailed verification link,
+// the page doesn't change any state
+func handleEmailVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	verificationToken := strings.TrimPrefix(r.URL.Path, EmailVerificationPath)
+	if verificationToken == "" || strings.Contains(verificationToken, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := emailVerificationPage.Execute(w, verificationToken); err != nil {
+		log.Errorf("error render email verification page: %v", err)
+	}
+}
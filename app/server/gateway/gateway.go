@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	pb "github.com/budgetin-app/user-service/app/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultOpenAPIPath is the OpenAPI document generated by 'protoc-gen-openapiv2'
+const DefaultOpenAPIPath = "./app/proto/userservice.swagger.json"
+
+// forwardedHeaders are the HTTP headers forwarded from and to the gRPC metadata
+var forwardedHeaders = []string{"X-Request-Id", "X-Api-Key", "X-Device-Secret", "X-Impersonator-Id"}
+
+// NewGateway creates the HTTP handler that translates the REST/JSON requests into
+// the gRPC requests of the User service listening on the gRPC address. The gRPC
+// status codes are mapped into the HTTP status codes by the gateway runtime.
+func NewGateway(ctx context.Context, grpcAddress string, dialOptions ...grpc.DialOption) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+
+	// Dial the gRPC server, so the requests pass through the server interceptors
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	if err := pb.RegisterUserHandlerFromEndpoint(ctx, mux, grpcAddress, dialOptions); err != nil {
+		return nil, fmt.Errorf("failed to register user gateway: %w", err)
+	}
+
+	root := http.NewServeMux()
+	root.Handle("/", mux)
+	root.HandleFunc(EmailVerificationPath, handleEmailVerification)
+	root.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, env.GetenvOrDefault("OPENAPI_PATH", DefaultOpenAPIPath))
+	})
+
+	return WithCORS(root, NewCORSConfig()), nil
+}
+
+// Serve listens the HTTP requests of the gateway on the given port
+func Serve(ctx context.Context, port string, grpcAddress string, dialOptions ...grpc.DialOption) error {
+	handler, err := NewGateway(ctx, grpcAddress, dialOptions...)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: handler}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Infof("Gateway listening: %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("gateway failed to serve: %w", err)
+	}
+	return nil
+}
+
+// incomingHeaderMatcher forwards the forwarded headers into the gRPC metadata as is,
+// other headers are forwarded according to the gateway default
+func incomingHeaderMatcher(key string) (string, bool) {
+	if isForwardedHeader(key) {
+		return key, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher returns the forwarded headers from the gRPC metadata as is,
+// other metadata are prefixed according to the gateway default
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if isForwardedHeader(key) {
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// isForwardedHeader checks the header is listed in the forwarded headers
+func isForwardedHeader(key string) bool {
+	for _, header := range forwardedHeaders {
+		if strings.EqualFold(key, header) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/budgetin-app/user-management-service/config/database"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+	pb "github.com/budgetin-app/user-service/app/proto"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+const (
+	// LivenessService is the health service name probed to know the process is alive
+	LivenessService = "liveness"
+	// ReadinessService is the health service name probed to know the dependencies are ready
+	ReadinessService = "readiness"
+
+	// Default configuration of the health checks
+	DefaultCheckInterval = 15 * time.Second
+	DefaultCheckTimeout  = 5 * time.Second
+)
+
+// Check is a single dependency check, it returns an error when the dependency is unhealthy
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// HealthChecker serves the standard 'grpc.health.v1.Health' service with the
+// statuses resolved from the live dependency checks
+type HealthChecker struct {
+	server   *health.Server
+	checks   []Check
+	interval time.Duration
+	timeout  time.Duration
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewHealthChecker create the health checker with the database, mail transport
+// and migration state checks
+func NewHealthChecker(db *gorm.DB) *HealthChecker {
+	return &HealthChecker{
+		server:   health.NewServer(),
+		interval: env.GetDurationOrDefault("HEALTH_CHECK_INTERVAL", DefaultCheckInterval),
+		timeout:  env.GetDurationOrDefault("HEALTH_CHECK_TIMEOUT", DefaultCheckTimeout),
+		stop:     make(chan struct{}),
+		checks: []Check{
+			{Name: "database", Run: func(ctx context.Context) error { return database.PingDB(ctx, db) }},
+			{Name: "migration", Run: func(ctx context.Context) error { return database.CheckMigration(db.WithContext(ctx)) }},
+			{Name: "mailer", Run: func(ctx context.Context) error { return mailer.CheckConnection(ctx) }},
+		},
+	}
+}
+
+// Register registers the health service into the gRPC server
+func (h *HealthChecker) Register(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, h.server)
+}
+
+// Start marks the process as alive and periodically evaluates the readiness checks
+func (h *HealthChecker) Start() {
+	h.server.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_SERVING)
+	h.evaluate()
+
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				h.evaluate()
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown flips every service to NOT_SERVING and ignores the later updates,
+// so the orchestrator stops routing traffic while the server drains
+func (h *HealthChecker) Shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		h.server.Shutdown()
+	})
+}
+
+// evaluate runs all the checks and updates the readiness status accordingly
+func (h *HealthChecker) evaluate() {
+	status := healthpb.HealthCheckResponse_SERVING
+	for _, check := range h.checks {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		err := check.Run(ctx)
+		cancel()
+
+		if err != nil {
+			log.WithFields(log.Fields{"check": check.Name}).Warnf("health check failed: %v", err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	// The empty service name represents the overall server health
+	for _, service := range []string{"", ReadinessService, pb.User_ServiceDesc.ServiceName} {
+		h.server.SetServingStatus(service, status)
+	}
+}
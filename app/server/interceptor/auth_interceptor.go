@@ -0,0 +1,203 @@
+package interceptor
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// AuthorizationHeader is the metadata key of the session token in the 'Bearer <token>' format
+	AuthorizationHeader = "authorization"
+
+	// APIKeyHeader is the metadata key of the service account API key
+	APIKeyHeader = "x-api-key"
+
+	// ImpersonatorIDHeader is the response metadata key of the admin impersonating the
+	// user, so the clients and the downstream services show the impersonation banner
+	ImpersonatorIDHeader = "x-impersonator-id"
+
+	// DefaultStepUpMaxAge is the max age of the authentication for the sensitive methods
+	// without their own max age
+	DefaultStepUpMaxAge = 15 * time.Minute
+)
+
+// MethodScopes defines the scopes required to call the method, the methods not
+// listed are public and don't require the caller to be authenticated
+var MethodScopes = map[string][]string{
+	"/userservice.User/CreateServiceAccount": {constant.ScopeServiceAccountsWrite},
+	"/userservice.User/ListServiceAccounts":  {constant.ScopeServiceAccountsRead},
+	"/userservice.User/RotateApiKey":         {constant.ScopeServiceAccountsWrite},
+	"/userservice.User/RevokeApiKey":         {constant.ScopeServiceAccountsWrite},
+
+	"/userservice.User/CreatePersonalAccessToken": {constant.ScopeTokensWrite},
+	"/userservice.User/ListPersonalAccessTokens":  {constant.ScopeTokensRead},
+	"/userservice.User/RevokePersonalAccessToken": {constant.ScopeTokensWrite},
+
+	"/userservice.User/LinkExternalAccount":   {constant.ScopeAccountWrite},
+	"/userservice.User/ListExternalAccounts":  {constant.ScopeAccountRead},
+	"/userservice.User/UnlinkExternalAccount": {constant.ScopeAccountWrite},
+
+	"/userservice.User/BeginPasskeyRegistration":  {constant.ScopeAccountWrite},
+	"/userservice.User/FinishPasskeyRegistration": {constant.ScopeAccountWrite},
+	"/userservice.User/ListPasskeys":              {constant.ScopeAccountRead},
+	"/userservice.User/DeletePasskey":             {constant.ScopeAccountWrite},
+
+	"/userservice.User/GetLoginHistory": {constant.ScopeAccountRead},
+	"/userservice.User/ListAuditEvents": {constant.ScopeAuditRead},
+
+	"/userservice.User/ListSessions":  {constant.ScopeAccountRead},
+	"/userservice.User/RevokeSession": {constant.ScopeAccountWrite},
+
+	"/userservice.User/BeginPasskeyReauthentication": {constant.ScopeAccountWrite},
+	"/userservice.User/Reauthenticate":               {constant.ScopeAccountWrite},
+
+	"/userservice.User/RequestAccountDeletion": {constant.ScopeAccountWrite},
+	"/userservice.User/ExportMyData":           {constant.ScopeAccountRead},
+
+	"/userservice.User/ListUsers":      {constant.ScopeUsersRead},
+	"/userservice.User/SearchUsers":    {constant.ScopeUsersRead},
+	"/userservice.User/SuspendUser":    {constant.ScopeUsersWrite},
+	"/userservice.User/DeactivateUser": {constant.ScopeUsersWrite},
+	"/userservice.User/ReinstateUser":  {constant.ScopeUsersWrite},
+
+	"/userservice.User/CreateInvitation": {constant.ScopeUsersWrite},
+	"/userservice.User/ListInvitations":  {constant.ScopeUsersRead},
+	"/userservice.User/RevokeInvitation": {constant.ScopeUsersWrite},
+
+	"/userservice.User/ImpersonateUser":   {constant.ScopeUsersImpersonate},
+	"/userservice.User/StopImpersonation": {constant.ScopeAccountWrite},
+}
+
+// MethodMaxAuthAge defines the sensitive methods requiring the caller to have
+// authenticated within the max age, not only to hold a valid session. The zero max
+// age is the STEP_UP_MAX_AGE. The caller should call 'Reauthenticate' to continue.
+var MethodMaxAuthAge = map[string]time.Duration{
+	"/userservice.User/CreatePersonalAccessToken": 0,
+	"/userservice.User/LinkExternalAccount":       0,
+	"/userservice.User/UnlinkExternalAccount":     0,
+	"/userservice.User/BeginPasskeyRegistration":  0,
+	"/userservice.User/FinishPasskeyRegistration": 0,
+	"/userservice.User/DeletePasskey":             5 * time.Minute,
+	"/userservice.User/RequestAccountDeletion":    5 * time.Minute,
+	"/userservice.User/ExportMyData":              0,
+	"/userservice.User/ImpersonateUser":           5 * time.Minute,
+}
+
+// ImpersonationBlockedMethods are the methods the impersonated user can't call besides
+// the sensitive methods of the MethodMaxAuthAge, so the impersonator can't take over
+// the account or extend the impersonation
+var ImpersonationBlockedMethods = map[string]bool{
+	"/userservice.User/BeginPasskeyReauthentication": true,
+	"/userservice.User/Reauthenticate":               true,
+	"/userservice.User/RevokeSession":                true,
+	"/userservice.User/RevokePersonalAccessToken":    true,
+	"/userservice.User/ImpersonateUser":              true,
+}
+
+// Authenticator resolves the principal of the given credential
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*principal.Principal, error)
+}
+
+// NewAuthInterceptor creates the interceptor authenticating the caller from the
+// request metadata, either with the API key or the bearer token. The bearer token
+// is either a session token or a personal access token. The principal is attached
+// into the request context and checked against the method scopes.
+func NewAuthInterceptor(
+	sessionAuthenticator Authenticator,
+	personalAccessTokenAuthenticator Authenticator,
+	apiKeyAuthenticator Authenticator,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		scopes, protected := MethodScopes[info.FullMethod]
+
+		// Resolve the caller from the provided credential
+		var p *principal.Principal
+		var err error
+		md, _ := metadata.FromIncomingContext(ctx)
+		if apiKey := firstValue(md, APIKeyHeader); apiKey != "" {
+			p, err = apiKeyAuthenticator.Authenticate(ctx, apiKey)
+		} else if authToken, ok := bearerToken(firstValue(md, AuthorizationHeader)); ok {
+			if token.IsPersonalAccessToken(authToken) {
+				p, err = personalAccessTokenAuthenticator.Authenticate(ctx, authToken)
+			} else {
+				p, err = sessionAuthenticator.Authenticate(ctx, authToken)
+			}
+		} else if protected {
+			return nil, apperror.ErrMissingCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// The caller should be granted every scope required by the method
+		for _, scope := range scopes {
+			if !p.HasScope(scope) {
+				return nil, apperror.ErrInsufficientScope
+			}
+		}
+
+		// The impersonated user can't perform the sensitive actions, the impersonator is
+		// exposed to the caller to show the impersonation is in progress
+		if p != nil && p.IsImpersonated() {
+			if _, sensitive := MethodMaxAuthAge[info.FullMethod]; sensitive || ImpersonationBlockedMethods[info.FullMethod] {
+				return nil, apperror.ErrImpersonationRestricted
+			}
+			grpc.SetHeader(ctx, metadata.Pairs(ImpersonatorIDHeader, strconv.FormatUint(uint64(p.ImpersonatorID), 10)))
+		}
+
+		// The sensitive method requires the recent authentication, not only the valid session
+		if maxAge, ok := getMethodMaxAuthAge(info.FullMethod); ok && !p.AuthenticatedWithin(maxAge) {
+			return nil, apperror.ErrStepUpRequired
+		}
+
+		if p != nil {
+			ctx = principal.NewContext(ctx, p)
+		}
+
+		// Call the actual handler to process the request
+		return handler(ctx, req)
+	}
+}
+
+// getMethodMaxAuthAge get the max age of the authentication required by the method,
+// the method without the requirement isn't found
+func getMethodMaxAuthAge(method string) (time.Duration, bool) {
+	maxAge, ok := MethodMaxAuthAge[method]
+	if ok && maxAge <= 0 {
+		maxAge = env.GetDurationOrDefault("STEP_UP_MAX_AGE", DefaultStepUpMaxAge)
+	}
+	return maxAge, ok
+}
+
+// firstValue get the first value of the metadata key
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// bearerToken extracts the token of the 'Bearer <token>' authorization value
+func bearerToken(value string) (string, bool) {
+	scheme, authToken, found := strings.Cut(value, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || authToken == "" {
+		return "", false
+	}
+	return authToken, true
+}
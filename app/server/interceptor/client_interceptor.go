@@ -0,0 +1,79 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// ForwardedForHeader is the metadata key of the client address set by the gateway
+	ForwardedForHeader = "x-forwarded-for"
+
+	// GatewayUserAgentHeader is the metadata key of the HTTP user agent set by the gateway
+	GatewayUserAgentHeader = "grpcgateway-user-agent"
+
+	// UserAgentHeader is the metadata key of the gRPC client user agent
+	UserAgentHeader = "user-agent"
+
+	// DeviceSecretHeader is the metadata key of the device secret of the "remember me" session
+	DeviceSecretHeader = "x-device-secret"
+)
+
+// ClientInterceptor attaches the client address, user agent and device secret into the
+// request context. The forwarded address is only trusted from the loopback peer, which is
+// the gateway dialing the server, so the direct callers can't spoof their address. The
+// reverse proxies in front of the gateway are trusted up to TRUSTED_PROXY_HOPS.
+func ClientInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	clientInfo := client.Info{IP: peerIP(ctx)}
+	if forwardedFor := firstValue(md, ForwardedForHeader); forwardedFor != "" && isLoopback(clientInfo.IP) {
+		clientInfo.IP = forwardedClientIP(forwardedFor)
+	}
+
+	clientInfo.UserAgent = firstValue(md, GatewayUserAgentHeader)
+	if clientInfo.UserAgent == "" {
+		clientInfo.UserAgent = firstValue(md, UserAgentHeader)
+	}
+
+	clientInfo.DeviceSecret = firstValue(md, DeviceSecretHeader)
+
+	return handler(client.NewContext(ctx, clientInfo), req)
+}
+
+// peerIP get the IP address of the peer without the port
+func peerIP(ctx context.Context) string {
+	address := getPeerAddress(ctx)
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// forwardedClientIP get the client address from the forwarded addresses, the gateway
+// appends the address of its peer to the ones sent by the client. The addresses on the
+// left can be spoofed by the client, so the address is taken from the right skipping the
+// ones appended by the trusted proxies.
+func forwardedClientIP(forwardedFor string) string {
+	addresses := strings.Split(forwardedFor, ",")
+	hops, _ := strconv.Atoi(os.Getenv("TRUSTED_PROXY_HOPS"))
+	index := max(len(addresses)-1-max(hops, 0), 0)
+	return strings.TrimSpace(addresses[index])
+}
+
+func isLoopback(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"google.golang.org/grpc"
+)
+
+// DefaultRequestTimeout is the deadline of every request whose method has no specific
+// deadline, it's overridden by the REQUEST_TIMEOUT
+const DefaultRequestTimeout = 10 * time.Second
+
+// MethodTimeouts overrides the server-side default deadline of the methods needing a
+// shorter or longer deadline, every other method gets the REQUEST_TIMEOUT. The deadline
+// is applied when the client doesn't set its own deadline.
+var MethodTimeouts = map[string]time.Duration{
+	"/userservice.User/RegisterUser":             15 * time.Second,
+	"/userservice.User/LoginUser":                10 * time.Second,
+	"/userservice.User/LogoutUser":               5 * time.Second,
+	"/userservice.User/VerifyEmailAddress":       5 * time.Second,
+	"/userservice.User/ConfirmEmailVerification": 5 * time.Second,
+
+	// The external login calls the identity provider over HTTP
+	"/userservice.User/StartExternalLogin":    20 * time.Second,
+	"/userservice.User/CompleteExternalLogin": 20 * time.Second,
+	"/userservice.User/LinkExternalAccount":   20 * time.Second,
+}
+
+// DeadlineInterceptor applies the default deadline of the method to the request
+// context, so the handler stops working once the deadline exceeded. Every method gets
+// a deadline, the methods not listed in MethodTimeouts get the REQUEST_TIMEOUT.
+func DeadlineInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, getMethodTimeout(info.FullMethod))
+		defer cancel()
+	}
+
+	// Call the actual handler to process the request
+	return handler(ctx, req)
+}
+
+// getMethodTimeout get the deadline of the method or the REQUEST_TIMEOUT as the default
+func getMethodTimeout(method string) time.Duration {
+	if timeout, ok := MethodTimeouts[method]; ok {
+		return timeout
+	}
+	return env.GetDurationOrDefault("REQUEST_TIMEOUT", DefaultRequestTimeout)
+}
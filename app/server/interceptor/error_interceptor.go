@@ -0,0 +1,83 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the error details returned to the client
+const ErrorDomain = "user-service.budgetin"
+
+// ErrorInterceptor maps the domain errors returned by the handler into the gRPC
+// status with the error details, the internal errors are returned with a generic message
+func ErrorInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, ToStatusError(ctx, info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+// ToStatusError converts the error into the gRPC status error
+func ToStatusError(ctx context.Context, method string, err error) error {
+	// The error is already a gRPC status (e.g. request validation), keep it as is
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	// The client gave up or the deadline exceeded while handling the request
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || errors.Is(err, apperror.ErrInternal) {
+		log.WithFields(log.Fields{
+			"request_id": RequestIDFromContext(ctx),
+			"method":     method,
+		}).Errorf("internal error: %v", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	st := status.New(toCode(appErr.Kind()), appErr.Message())
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: appErr.Reason(),
+		Domain: ErrorDomain,
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// toCode maps the kind of the domain error into the gRPC status code
+func toCode(kind error) codes.Code {
+	switch kind {
+	case apperror.ErrNotFound:
+		return codes.NotFound
+	case apperror.ErrAlreadyExists:
+		return codes.AlreadyExists
+	case apperror.ErrInvalidArgument:
+		return codes.InvalidArgument
+	case apperror.ErrInvalidCredentials, apperror.ErrUnauthenticated:
+		return codes.Unauthenticated
+	case apperror.ErrPermissionDenied:
+		return codes.PermissionDenied
+	case apperror.ErrSessionConflict, apperror.ErrFailedPrecondition:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
@@ -3,37 +3,92 @@ package interceptor
 import (
 	"context"
 	"os"
+	"time"
 
+	"github.com/budgetin-app/user-service/app/pkg/redactor"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
-// LoggingInterceptor intercept the incoming request and outcoming response
-// when the LOG_LEVEL set to DEBUG
+// RequestIDHeader is the metadata key of the request id, it's generated when
+// the caller doesn't provide one
+const RequestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext get the request id assigned by the LoggingInterceptor
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// LoggingInterceptor logs every handled request with its request id, peer address,
+// method, duration and status code. The request and response (with the sensitive
+// fields redacted) are also logged when the LOG_LEVEL set to DEBUG
 func LoggingInterceptor(
 	ctx context.Context,
 	req interface{},
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	start := time.Now()
+
 	// Check the value of the LOG_LEVEL environment variable
-	logLevel := os.Getenv("LOG_LEVEL")
+	isDebug := os.Getenv("LOG_LEVEL") == "DEBUG"
+
+	// Assign the request id, so it can be correlated by the caller
+	requestID := getRequestID(ctx)
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	fields := log.Fields{
+		"request_id": requestID,
+		"method":     info.FullMethod,
+		"peer":       getPeerAddress(ctx),
+	}
 
 	// Log incoming request if LOG_LEVEL is set to DEBUG
-	if logLevel == "DEBUG" {
-		log.WithFields(log.Fields{"method": info.FullMethod}).Infof("Request -> %v", req)
+	if isDebug {
+		log.WithFields(fields).Infof("Request -> %s", redactor.String(req))
 	}
 
 	// Call the actual handler to process the request
 	resp, err := handler(ctx, req)
 
-	// Log outgoing response if LOG_LEVEL is set to DEBUG
-	if logLevel == "DEBUG" {
-		if err != nil {
-			log.WithFields(log.Fields{"method": info.FullMethod}).Errorf("Response -> %v", resp)
-		} else {
-			log.WithFields(log.Fields{"method": info.FullMethod}).Infof("Response -> %v", resp)
-		}
+	fields["duration_ms"] = time.Since(start).Milliseconds()
+	fields["code"] = status.Code(err).String()
+
+	// Log outgoing response, the response content only logged if LOG_LEVEL is set to DEBUG
+	entry := log.WithFields(fields)
+	switch {
+	case err != nil:
+		entry.WithError(err).Error("Request failed")
+	case isDebug:
+		entry.Infof("Response -> %s", redactor.String(resp))
+	default:
+		entry.Info("Request handled")
 	}
 	return resp, err
 }
+
+// getRequestID get the request id from the incoming metadata or generate a new one
+func getRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return uuid.New().String()
+}
+
+// getPeerAddress get the address of the caller
+func getPeerAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
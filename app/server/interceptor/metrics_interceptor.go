@@ -0,0 +1,29 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsInterceptor records the latency and the status code of every handled request
+func MetricsInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	start := time.Now()
+
+	// Call the actual handler to process the request
+	resp, err := handler(ctx, req)
+
+	// Record the request duration and the resolved status code
+	metrics.RequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	metrics.RequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+	return resp, err
+}
@@ -0,0 +1,38 @@
+package interceptor
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor recovers the panic raised while handling the request, so
+// it doesn't kill the server. The panic is logged with its stack trace and
+// returned as an internal error
+func RecoveryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.PanicsTotal.WithLabelValues(info.FullMethod).Inc()
+			log.WithFields(log.Fields{
+				"request_id": RequestIDFromContext(ctx),
+				"method":     info.FullMethod,
+				"stack":      string(debug.Stack()),
+			}).Errorf("recovered from panic: %v", r)
+
+			resp, err = nil, status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	// Call the actual handler to process the request
+	return handler(ctx, req)
+}
@@ -0,0 +1,93 @@
+package maintenance
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/pkg/helper/env"
+	"github.com/budgetin-app/user-service/app/pkg/scheduler"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Default intervals of the maintenance jobs
+const (
+	DefaultSessionPurgeInterval       = time.Hour
+	DefaultTokenPurgeInterval         = time.Hour
+	DefaultSoftDeletePurgeInterval    = 24 * time.Hour
+	DefaultVerificationResendInterval = 5 * time.Minute
+	DefaultAccountPurgeInterval       = time.Hour
+	DefaultEventPublishInterval       = 30 * time.Second
+	DefaultDataExportInterval         = time.Minute
+	DefaultJobTimeout                 = 5 * time.Minute
+)
+
+// NewMaintenanceScheduler creates the scheduler of the maintenance jobs, only the
+// replica holding the Postgres advisory lock runs them. The job is disabled with the
+// zero interval, the whole scheduler is disabled with 'SCHEDULER_ENABLED=false'.
+func NewMaintenanceScheduler(
+	db *gorm.DB,
+	maintenanceController controller.MaintenanceController,
+	accountDeletionController controller.AccountDeletionController,
+	dataExportController controller.DataExportController,
+	eventController controller.EventController,
+) *scheduler.Scheduler {
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("failed to get database connection pool: %v", err)
+	}
+
+	timeout := env.GetDurationOrDefault("SCHEDULER_JOB_TIMEOUT", DefaultJobTimeout)
+	jobs := []scheduler.Job{
+		{
+			Name:     "purge_expired_sessions",
+			Interval: env.GetDurationOrDefault("SESSION_PURGE_INTERVAL", DefaultSessionPurgeInterval),
+			Run:      maintenanceController.PurgeExpiredSessions,
+		},
+		{
+			Name:     "purge_expired_tokens",
+			Interval: env.GetDurationOrDefault("TOKEN_PURGE_INTERVAL", DefaultTokenPurgeInterval),
+			Run:      maintenanceController.PurgeExpiredTokens,
+		},
+		{
+			Name:     "purge_soft_deleted",
+			Interval: env.GetDurationOrDefault("SOFT_DELETE_PURGE_INTERVAL", DefaultSoftDeletePurgeInterval),
+			Run:      maintenanceController.PurgeSoftDeleted,
+		},
+		{
+			Name:     "resend_stuck_verifications",
+			Interval: env.GetDurationOrDefault("VERIFICATION_RESEND_INTERVAL", DefaultVerificationResendInterval),
+			Run:      maintenanceController.ResendStuckVerifications,
+		},
+		{
+			Name:     "purge_deleted_accounts",
+			Interval: env.GetDurationOrDefault("ACCOUNT_PURGE_INTERVAL", DefaultAccountPurgeInterval),
+			Run:      accountDeletionController.PurgeDeletedAccounts,
+		},
+		{
+			Name:     "generate_data_exports",
+			Interval: env.GetDurationOrDefault("DATA_EXPORT_INTERVAL", DefaultDataExportInterval),
+			Run:      dataExportController.GenerateDataExports,
+		},
+		{
+			Name:     "publish_events",
+			Interval: env.GetDurationOrDefault("EVENT_PUBLISH_INTERVAL", DefaultEventPublishInterval),
+			Run:      eventController.PublishPendingEvents,
+		},
+	}
+	for i := range jobs {
+		jobs[i].Timeout = timeout
+	}
+
+	if enabled, _ := strconv.ParseBool(env.GetenvOrDefault("SCHEDULER_ENABLED", "true")); !enabled {
+		log.Warn("Maintenance scheduler is disabled")
+		jobs = nil
+	}
+
+	return scheduler.New(
+		scheduler.NewAdvisoryLockElector(sqlDB, scheduler.AdvisoryLockKey),
+		env.GetDurationOrDefault("SCHEDULER_ELECTION_INTERVAL", scheduler.DefaultElectionInterval),
+		jobs...,
+	)
+}
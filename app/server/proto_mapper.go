@@ -0,0 +1,201 @@
+package server
+
+import (
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+	pb "github.com/budgetin-app/user-service/app/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toServiceAccountProto maps the service account model into the proto message
+func toServiceAccountProto(account *model.ServiceAccount) *pb.ServiceAccount {
+	message := &pb.ServiceAccount{
+		ServiceAccountId: uint32(account.ID),
+		Name:             account.Name,
+		Description:      account.Description,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		ApiKeys:          make([]*pb.ApiKey, 0, len(account.APIKeys)),
+	}
+	for i := range account.APIKeys {
+		message.ApiKeys = append(message.ApiKeys, toAPIKeyProto(&account.APIKeys[i]))
+	}
+	return message
+}
+
+// toAPIKeyProto maps the API key model into the proto message, the key hash is never exposed
+func toAPIKeyProto(key *model.APIKey) *pb.ApiKey {
+	return &pb.ApiKey{
+		KeyPrefix:  key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  timestamppb.New(key.CreatedAt),
+		ExpiresAt:  toTimestamp(key.ExpiredAt),
+		LastUsedAt: toTimestamp(key.LastUsedAt),
+		RevokedAt:  toTimestamp(key.RevokedAt),
+	}
+}
+
+// toPersonalAccessTokenProto maps the personal access token model into the proto
+// message, the token hash is never exposed
+func toPersonalAccessTokenProto(token *model.PersonalAccessToken) *pb.PersonalAccessToken {
+	return &pb.PersonalAccessToken{
+		TokenPrefix: token.Prefix,
+		Name:        token.Name,
+		Scopes:      token.Scopes,
+		CreatedAt:   timestamppb.New(token.CreatedAt),
+		ExpiresAt:   toTimestamp(token.ExpiredAt),
+		LastUsedAt:  toTimestamp(token.LastUsedAt),
+		RevokedAt:   toTimestamp(token.RevokedAt),
+	}
+}
+
+// toExternalAccountProto maps the external login model into the proto message
+func toExternalAccountProto(login *model.LoginExternal) *pb.ExternalAccount {
+	return &pb.ExternalAccount{
+		Provider: login.Provider.Name,
+		Email:    login.Email,
+		LinkedAt: timestamppb.New(login.CreatedAt),
+	}
+}
+
+// toPasskeyProto maps the WebAuthn credential model into the proto message
+func toPasskeyProto(credential *model.WebAuthnCredential) *pb.Passkey {
+	return &pb.Passkey{
+		CredentialId: credential.CredentialID,
+		Name:         credential.Name,
+		Transports:   credential.Transports,
+		BackedUp:     credential.BackupState,
+		CreatedAt:    timestamppb.New(credential.CreatedAt),
+		LastUsedAt:   toTimestamp(credential.LastUsedAt),
+	}
+}
+
+// toSessionProto maps the session model into the proto message, the current session
+// is the session of the caller
+func toSessionProto(session *model.Session, currentSessionID uint) *pb.Session {
+	message := &pb.Session{
+		SessionId:  uint32(session.ID),
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IpAddress:  session.IPAddress,
+		RememberMe: session.RememberMe,
+		Current:    session.ID == currentSessionID,
+		CreatedAt:  timestamppb.New(session.CreatedAt),
+		LastSeenAt: timestamppb.New(session.LastSeenAt),
+		ExpiresAt:  timestamppb.New(session.ExpiredAt),
+	}
+	if session.ImpersonatorID != nil {
+		message.ImpersonatorId = uint32(*session.ImpersonatorID)
+	}
+	return message
+}
+
+// toReauthenticateResponse maps the authentication of the reauthenticated session
+func toReauthenticateResponse(session *model.Session) *pb.ReauthenticateResponse {
+	return &pb.ReauthenticateResponse{
+		AuthenticatedAt: timestamppb.New(session.AuthenticatedAt),
+		AuthMethods:     session.AuthMethods,
+	}
+}
+
+// toAuditEventProto maps the audit event model into the proto message
+func toAuditEventProto(event *model.AuditEvent) *pb.AuditEvent {
+	message := &pb.AuditEvent{
+		AuditEventId: uint32(event.ID),
+		EventType:    event.EventType,
+		Outcome:      event.Outcome,
+		Reason:       event.Reason,
+		ActorType:    event.ActorType,
+		IpAddress:    event.IPAddress,
+		UserAgent:    event.UserAgent,
+		Metadata:     event.Metadata,
+		CreatedAt:    timestamppb.New(event.CreatedAt),
+	}
+	if event.UserID != nil {
+		message.UserId = uint32(*event.UserID)
+	}
+	if event.ActorID != nil {
+		message.ActorId = uint32(*event.ActorID)
+	}
+	return message
+}
+
+// toAuditEventsProto maps the audit event models into the proto messages
+func toAuditEventsProto(events []model.AuditEvent) []*pb.AuditEvent {
+	messages := make([]*pb.AuditEvent, 0, len(events))
+	for i := range events {
+		messages = append(messages, toAuditEventProto(&events[i]))
+	}
+	return messages
+}
+
+// toUserSummariesProto maps the user summaries into the proto messages
+func toUserSummariesProto(users []model.UserSummary) []*pb.UserSummary {
+	messages := make([]*pb.UserSummary, 0, len(users))
+	for _, user := range users {
+		messages = append(messages, &pb.UserSummary{
+			UserId:             uint32(user.ID),
+			Username:           user.Username,
+			Email:              user.Email,
+			RoleId:             uint32(user.RoleID),
+			RoleName:           user.RoleName,
+			Status:             user.Status,
+			VerificationStatus: user.VerificationStatus,
+			CreatedAt:          timestamppb.New(user.CreatedAt),
+		})
+	}
+	return messages
+}
+
+// toTime maps the optional proto timestamp into the time
+func toTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := t.AsTime()
+	return &value
+}
+
+// toTimestamp maps the optional time into the proto timestamp
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// daysToDuration converts the number of days into the duration
+func daysToDuration(days uint32) time.Duration {
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// toInvitationProto maps the invitation model into the proto message
+func toInvitationProto(invitation *model.Invitation) *pb.Invitation {
+	message := &pb.Invitation{
+		InvitationId: uint32(invitation.ID),
+		Email:        invitation.Email,
+		MaxUses:      uint32(invitation.MaxUses),
+		Uses:         uint32(invitation.Uses),
+		Status:       invitation.Status(time.Now()),
+		CreatedBy:    uint32(invitation.CreatedBy),
+		CreatedAt:    timestamppb.New(invitation.CreatedAt),
+		ExpiresAt:    timestamppb.New(invitation.ExpiredAt),
+	}
+	if invitation.RoleID != nil {
+		message.RoleId = uint32(*invitation.RoleID)
+	}
+	return message
+}
+
+// toAccountStatusResponse maps the changed status of the account into the response
+func toAccountStatusResponse(account *model.Account) *pb.AccountStatusResponse {
+	response := &pb.AccountStatusResponse{
+		UserId: uint32(account.ID),
+		Status: account.Status,
+		Reason: account.StatusReason,
+	}
+	if account.StatusChangedAt != nil {
+		response.ChangedAt = timestamppb.New(*account.StatusChangedAt)
+	}
+	return response
+}
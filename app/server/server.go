@@ -7,14 +7,47 @@ import (
 	"google.golang.org/grpc"
 )
 
-func InitServer(config *config.Configuration) *grpc.Server {
-	// Create a new gRPC server
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(interceptor.LoggingInterceptor),
-	)
+func InitServer(config *config.Configuration, options ...grpc.ServerOption) *grpc.Server {
+	// Create a new gRPC server, the additional options (e.g. transport credentials) are appended
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			interceptor.TracingInterceptor,
+			interceptor.MetricsInterceptor,
+			interceptor.LoggingInterceptor,
+			interceptor.RecoveryInterceptor,
+			interceptor.DeadlineInterceptor,
+			interceptor.ClientInterceptor,
+			interceptor.ErrorInterceptor,
+			interceptor.NewAuthInterceptor(
+				config.AuthController,
+				config.PersonalAccessTokenController,
+				config.ServiceAccountController,
+			),
+		),
+	}, options...)...)
 
 	// Register the "service implementation (gRPC server methods) with the gRPC server
-	pb.RegisterUserServer(server, NewUserServer(config.AuthController))
+	pb.RegisterUserServer(server, NewUserServer(
+		config.AuthController,
+		config.ServiceAccountController,
+		config.PersonalAccessTokenController,
+		config.ExternalAuthController,
+		config.MagicLinkController,
+		config.PasskeyController,
+		config.AuditController,
+		config.DeviceController,
+		config.PasswordRecoveryController,
+		config.SessionController,
+		config.AccountDeletionController,
+		config.DataExportController,
+		config.AccountStatusController,
+		config.UserDirectoryController,
+		config.ImpersonationController,
+		config.InvitationController,
+	))
+
+	// Register the standard health service used by the orchestrator probes
+	config.HealthChecker.Register(server)
 
 	return server
 }
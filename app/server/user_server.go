@@ -2,22 +2,80 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/budgetin-app/user-service/app/constant"
 	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/pkg/dataexport"
 	"github.com/budgetin-app/user-service/app/pkg/validator"
 	pb "github.com/budgetin-app/user-service/app/proto"
+	"github.com/budgetin-app/user-service/app/repository"
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type UserServerImpl struct {
-	authController controller.AuthController
+	authController                controller.AuthController
+	serviceAccountController      controller.ServiceAccountController
+	personalAccessTokenController controller.PersonalAccessTokenController
+	externalAuthController        controller.ExternalAuthController
+	magicLinkController           controller.MagicLinkController
+	passkeyController             controller.PasskeyController
+	auditController               controller.AuditController
+	deviceController              controller.DeviceController
+	passwordRecoveryController    controller.PasswordRecoveryController
+	sessionController             controller.SessionController
+	accountDeletionController     controller.AccountDeletionController
+	dataExportController          controller.DataExportController
+	accountStatusController       controller.AccountStatusController
+	userDirectoryController       controller.UserDirectoryController
+	impersonationController       controller.ImpersonationController
+	invitationController          controller.InvitationController
 	pb.UnimplementedUserServer
 }
 
-func NewUserServer(authController controller.AuthController) *UserServerImpl {
-	return &UserServerImpl{authController: authController}
+func NewUserServer(
+	authController controller.AuthController,
+	serviceAccountController controller.ServiceAccountController,
+	personalAccessTokenController controller.PersonalAccessTokenController,
+	externalAuthController controller.ExternalAuthController,
+	magicLinkController controller.MagicLinkController,
+	passkeyController controller.PasskeyController,
+	auditController controller.AuditController,
+	deviceController controller.DeviceController,
+	passwordRecoveryController controller.PasswordRecoveryController,
+	sessionController controller.SessionController,
+	accountDeletionController controller.AccountDeletionController,
+	dataExportController controller.DataExportController,
+	accountStatusController controller.AccountStatusController,
+	userDirectoryController controller.UserDirectoryController,
+	impersonationController controller.ImpersonationController,
+	invitationController controller.InvitationController,
+) *UserServerImpl {
+	return &UserServerImpl{
+		authController:                authController,
+		serviceAccountController:      serviceAccountController,
+		personalAccessTokenController: personalAccessTokenController,
+		externalAuthController:        externalAuthController,
+		magicLinkController:           magicLinkController,
+		passkeyController:             passkeyController,
+		auditController:               auditController,
+		deviceController:              deviceController,
+		passwordRecoveryController:    passwordRecoveryController,
+		sessionController:             sessionController,
+		accountDeletionController:     accountDeletionController,
+		dataExportController:          dataExportController,
+		accountStatusController:       accountStatusController,
+		userDirectoryController:       userDirectoryController,
+		impersonationController:       impersonationController,
+		invitationController:          invitationController,
+	}
 }
 
 func (s *UserServerImpl) RegisterUser(ctx context.Context, r *pb.AuthenticationRequest) (*pb.RegisterResponse, error) {
@@ -33,9 +91,9 @@ func (s *UserServerImpl) RegisterUser(ctx context.Context, r *pb.AuthenticationR
 	}
 
 	// Begin to register new user
-	credential, err := s.authController.Register(r.Username, r.Email, r.Password)
+	credential, err := s.authController.Register(ctx, r.Username, r.Email, r.Password, strings.TrimSpace(r.InvitationCode))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to register user: %v", err)
+		return nil, fmt.Errorf("failed to register user: %w", err)
 	}
 
 	return &pb.RegisterResponse{UserId: *proto.Uint32(uint32(credential.ID))}, nil
@@ -65,12 +123,17 @@ func (s *UserServerImpl) LoginUser(ctx context.Context, r *pb.AuthenticationRequ
 	}
 
 	// Begin to authenticate user
-	session, err := s.authController.Login(isEmail, identifier, r.Password)
+	options := model.SessionOptions{RememberMe: r.RememberMe, DeviceName: r.DeviceName}
+	result, err := s.authController.Login(ctx, isEmail, identifier, r.Password, options)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to login user: %v", err)
+		return nil, fmt.Errorf("failed to login user: %w", err)
 	}
 
-	return &pb.LoginResponse{AuthToken: session.Token}, nil
+	// The session is created once the passkey of the second factor is asserted
+	if result.Session == nil {
+		return &pb.LoginResponse{MfaRequired: true, MfaOptions: string(result.MFAOptions)}, nil
+	}
+	return &pb.LoginResponse{AuthToken: result.Session.Token, DeviceSecret: result.Session.DeviceSecret}, nil
 }
 
 func (s *UserServerImpl) LogoutUser(ctx context.Context, r *pb.LogoutRequest) (*pb.LogoutResponse, error) {
@@ -80,9 +143,9 @@ func (s *UserServerImpl) LogoutUser(ctx context.Context, r *pb.LogoutRequest) (*
 	}
 
 	// Begin to logout the user
-	success, err := s.authController.Logout(r.AuthToken)
+	success, err := s.authController.Logout(ctx, r.AuthToken)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to logout user: %v", err)
+		return nil, fmt.Errorf("failed to logout user: %w", err)
 	}
 
 	return &pb.LogoutResponse{Success: success}, nil
@@ -95,10 +158,828 @@ func (s *UserServerImpl) VerifyEmailAddress(ctx context.Context, r *pb.VerifyEma
 	}
 
 	// Begin to verify the email address
-	verified, err := s.authController.VerifyEmail(r.Email)
+	verified, err := s.authController.VerifyEmail(ctx, r.Email)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+		return nil, fmt.Errorf("failed to verify email: %w", err)
 	}
 
 	return &pb.VerifyEmailResponse{Verified: verified}, nil
 }
+
+func (s *UserServerImpl) ConfirmEmailVerification(ctx context.Context, r *pb.ConfirmEmailRequest) (*pb.VerifyEmailResponse, error) {
+	// Request validation
+	if len(r.VerificationToken) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "verification token must be provided")
+	}
+
+	// Begin to confirm the email address
+	verified, err := s.authController.ConfirmEmail(ctx, r.VerificationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm email: %w", err)
+	}
+
+	return &pb.VerifyEmailResponse{Verified: verified}, nil
+}
+
+func (s *UserServerImpl) RequestMagicLink(ctx context.Context, r *pb.RequestMagicLinkRequest) (*pb.RequestMagicLinkResponse, error) {
+	// Request validation
+	if !validator.IsValidEmail(r.Email) {
+		return nil, status.Error(codes.InvalidArgument, "invalid email")
+	}
+
+	// Begin to send the magic link
+	nonce, err := s.magicLinkController.RequestMagicLink(ctx, r.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request magic link: %w", err)
+	}
+
+	return &pb.RequestMagicLinkResponse{Nonce: nonce}, nil
+}
+
+func (s *UserServerImpl) ConsumeMagicLink(ctx context.Context, r *pb.ConsumeMagicLinkRequest) (*pb.LoginResponse, error) {
+	// Request validation
+	if len(r.Token) == 0 || len(r.Nonce) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "token and nonce must be provided")
+	}
+
+	// Begin to authenticate the user with the magic link
+	result, err := s.magicLinkController.ConsumeMagicLink(ctx, r.Token, r.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+
+	// The session is created once the passkey of the second factor is asserted
+	if result.Session == nil {
+		return &pb.LoginResponse{MfaRequired: true, MfaOptions: string(result.MFAOptions)}, nil
+	}
+	return &pb.LoginResponse{AuthToken: result.Session.Token, DeviceSecret: result.Session.DeviceSecret}, nil
+}
+
+func (s *UserServerImpl) ReportUnrecognizedLogin(ctx context.Context, r *pb.ReportUnrecognizedLoginRequest) (*pb.ReportUnrecognizedLoginResponse, error) {
+	// Request validation
+	if len(r.Token) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "token must be provided")
+	}
+
+	// Begin to revoke the sessions and start the password reset of the user
+	if err := s.deviceController.ReportUnrecognizedLogin(ctx, r.Token); err != nil {
+		return nil, fmt.Errorf("failed to report unrecognized login: %w", err)
+	}
+
+	return &pb.ReportUnrecognizedLoginResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) ResetPassword(ctx context.Context, r *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	// Request validation
+	if len(r.Token) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "token must be provided")
+	}
+	if !validator.IsValidPassword(r.Password) {
+		return nil, status.Error(codes.InvalidArgument, "invalid password")
+	}
+
+	// Begin to replace the password of the user
+	if err := s.passwordRecoveryController.ResetPassword(ctx, r.Token, r.Password); err != nil {
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return &pb.ResetPasswordResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) BeginPasskeyRegistration(ctx context.Context, r *pb.BeginPasskeyRegistrationRequest) (*pb.PasskeyOptionsResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin to register the passkey of the caller
+	options, err := s.passkeyController.BeginRegistration(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin passkey registration: %w", err)
+	}
+
+	return &pb.PasskeyOptionsResponse{Options: string(options)}, nil
+}
+
+func (s *UserServerImpl) FinishPasskeyRegistration(ctx context.Context, r *pb.FinishPasskeyRegistrationRequest) (*pb.FinishPasskeyRegistrationResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.Name) == 0 || len(r.Name) > 50 {
+		return nil, status.Error(codes.InvalidArgument, "invalid passkey name")
+	}
+	if len(r.Credential) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "credential must be provided")
+	}
+
+	// Begin to store the passkey created by the authenticator
+	credential, err := s.passkeyController.FinishRegistration(ctx, caller.ID, r.Name, r.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish passkey registration: %w", err)
+	}
+
+	return &pb.FinishPasskeyRegistrationResponse{Passkey: toPasskeyProto(credential)}, nil
+}
+
+func (s *UserServerImpl) BeginPasskeyLogin(ctx context.Context, r *pb.BeginPasskeyLoginRequest) (*pb.PasskeyOptionsResponse, error) {
+	options, err := s.passkeyController.BeginLogin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin passkey login: %w", err)
+	}
+
+	return &pb.PasskeyOptionsResponse{Options: string(options)}, nil
+}
+
+func (s *UserServerImpl) FinishPasskeyLogin(ctx context.Context, r *pb.FinishPasskeyLoginRequest) (*pb.LoginResponse, error) {
+	// Request validation
+	if len(r.Credential) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "credential must be provided")
+	}
+
+	// Begin to authenticate the user with the passkey assertion
+	session, err := s.passkeyController.FinishLogin(ctx, r.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish passkey login: %w", err)
+	}
+
+	return &pb.LoginResponse{AuthToken: session.Token}, nil
+}
+
+func (s *UserServerImpl) ListPasskeys(ctx context.Context, r *pb.ListPasskeysRequest) (*pb.ListPasskeysResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.passkeyController.ListPasskeys(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	response := &pb.ListPasskeysResponse{Passkeys: make([]*pb.Passkey, 0, len(credentials))}
+	for i := range credentials {
+		response.Passkeys = append(response.Passkeys, toPasskeyProto(&credentials[i]))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) DeletePasskey(ctx context.Context, r *pb.DeletePasskeyRequest) (*pb.DeletePasskeyResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.CredentialId) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "credential id must be provided")
+	}
+
+	// Begin to delete the passkey
+	if err := s.passkeyController.DeletePasskey(ctx, caller.ID, r.CredentialId); err != nil {
+		return nil, fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	return &pb.DeletePasskeyResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) ListSessions(ctx context.Context, r *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionController.ListSessions(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	response := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for i := range sessions {
+		response.Sessions = append(response.Sessions, toSessionProto(&sessions[i], caller.SessionID))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) RevokeSession(ctx context.Context, r *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if r.SessionId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "session id must be provided")
+	}
+
+	// Begin to revoke the session
+	if err := s.sessionController.RevokeSession(ctx, caller.ID, uint(r.SessionId)); err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return &pb.RevokeSessionResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) BeginPasskeyReauthentication(ctx context.Context, r *pb.BeginPasskeyReauthenticationRequest) (*pb.PasskeyOptionsResponse, error) {
+	caller, err := sessionPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.passkeyController.BeginReauthentication(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin passkey reauthentication: %w", err)
+	}
+
+	return &pb.PasskeyOptionsResponse{Options: string(options)}, nil
+}
+
+func (s *UserServerImpl) Reauthenticate(ctx context.Context, r *pb.ReauthenticateRequest) (*pb.ReauthenticateResponse, error) {
+	caller, err := sessionPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if (len(r.Password) == 0) == (len(r.PasskeyResponse) == 0) {
+		return nil, status.Error(codes.InvalidArgument, "either password or passkey response must be provided")
+	}
+
+	// Begin to reauthenticate the caller's session
+	session, err := s.authController.Reauthenticate(ctx, caller.ID, caller.SessionID, r.Password, r.PasskeyResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reauthenticate: %w", err)
+	}
+
+	return toReauthenticateResponse(session), nil
+}
+
+func (s *UserServerImpl) RequestAccountDeletion(ctx context.Context, r *pb.RequestAccountDeletionRequest) (*pb.RequestAccountDeletionResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin to schedule the caller's account deletion
+	scheduledAt, err := s.accountDeletionController.RequestAccountDeletion(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request account deletion: %w", err)
+	}
+
+	return &pb.RequestAccountDeletionResponse{ScheduledAt: timestamppb.New(scheduledAt)}, nil
+}
+
+func (s *UserServerImpl) ListUsers(ctx context.Context, r *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	filter, err := toUserFilter(r.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin to list the users
+	users, nextPageToken, err := s.userDirectoryController.ListUsers(ctx, filter, int(r.PageSize), r.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return &pb.ListUsersResponse{Users: toUserSummariesProto(users), NextPageToken: nextPageToken}, nil
+}
+
+func (s *UserServerImpl) SearchUsers(ctx context.Context, r *pb.SearchUsersRequest) (*pb.ListUsersResponse, error) {
+	// Request validation
+	query := strings.TrimSpace(r.Query)
+	if query == "" || len(query) > 100 {
+		return nil, status.Error(codes.InvalidArgument, "invalid search query")
+	}
+	filter, err := toUserFilter(r.Filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.Query = query
+
+	// Begin to search the users
+	users, nextPageToken, err := s.userDirectoryController.SearchUsers(ctx, filter, int(r.PageSize), r.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	return &pb.ListUsersResponse{Users: toUserSummariesProto(users), NextPageToken: nextPageToken}, nil
+}
+
+// toUserFilter validates the filters of the listed users
+func toUserFilter(f *pb.UserFilter) (repository.UserFilter, error) {
+	if f == nil {
+		return repository.UserFilter{}, nil
+	}
+
+	switch f.Status {
+	case "", model.AccountActive, model.AccountSuspended, model.AccountDeactivated, model.AccountPendingDeletion:
+	default:
+		return repository.UserFilter{}, status.Error(codes.InvalidArgument, "invalid account status")
+	}
+	switch f.VerificationStatus {
+	case "", model.VerificationPending, model.VerificationSent, model.VerificationError, model.EmailVerified:
+	default:
+		return repository.UserFilter{}, status.Error(codes.InvalidArgument, "invalid verification status")
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.AsTime().Before(f.CreatedTo.AsTime()) {
+		return repository.UserFilter{}, status.Error(codes.InvalidArgument, "created from must be before created to")
+	}
+
+	return repository.UserFilter{
+		RoleID:             uint(f.RoleId),
+		Status:             f.Status,
+		VerificationStatus: f.VerificationStatus,
+		CreatedFrom:        toTime(f.CreatedFrom),
+		CreatedTo:          toTime(f.CreatedTo),
+	}, nil
+}
+
+func (s *UserServerImpl) SuspendUser(ctx context.Context, r *pb.ChangeAccountStatusRequest) (*pb.AccountStatusResponse, error) {
+	return s.changeAccountStatus(ctx, r, s.accountStatusController.SuspendAccount)
+}
+
+func (s *UserServerImpl) DeactivateUser(ctx context.Context, r *pb.ChangeAccountStatusRequest) (*pb.AccountStatusResponse, error) {
+	return s.changeAccountStatus(ctx, r, s.accountStatusController.DeactivateAccount)
+}
+
+func (s *UserServerImpl) ReinstateUser(ctx context.Context, r *pb.ChangeAccountStatusRequest) (*pb.AccountStatusResponse, error) {
+	return s.changeAccountStatus(ctx, r, s.accountStatusController.ReinstateAccount)
+}
+
+// changeAccountStatus validates the request of the admin and changes the account status
+// with the given change
+func (s *UserServerImpl) changeAccountStatus(
+	ctx context.Context,
+	r *pb.ChangeAccountStatusRequest,
+	change func(ctx context.Context, actorID uint, userID uint, reason string) (*model.Account, error),
+) (*pb.AccountStatusResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if r.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user id must be provided")
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason must be provided")
+	}
+
+	// Begin to change the account status
+	account, err := change(ctx, caller.ID, uint(r.UserId), strings.TrimSpace(r.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to change account status: %w", err)
+	}
+
+	return toAccountStatusResponse(account), nil
+}
+
+func (s *UserServerImpl) ImpersonateUser(ctx context.Context, r *pb.ImpersonateUserRequest) (*pb.ImpersonateUserResponse, error) {
+	// Only the admin logged in with the session impersonates, not with the tokens
+	caller, err := sessionPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if r.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user id must be provided")
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason must be provided")
+	}
+
+	// Begin to impersonate the user
+	session, err := s.impersonationController.StartImpersonation(ctx, caller.ID, uint(r.UserId), strings.TrimSpace(r.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate user: %w", err)
+	}
+
+	return &pb.ImpersonateUserResponse{
+		AuthToken: session.Token,
+		UserId:    uint32(session.UserID),
+		ExpiresAt: timestamppb.New(session.ExpiredAt),
+	}, nil
+}
+
+func (s *UserServerImpl) StopImpersonation(ctx context.Context, r *pb.StopImpersonationRequest) (*pb.StopImpersonationResponse, error) {
+	caller, err := sessionPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsImpersonated() {
+		return nil, apperror.ErrNotImpersonating
+	}
+
+	// Begin to stop the impersonation
+	if err := s.impersonationController.StopImpersonation(ctx, caller.ID, caller.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to stop impersonation: %w", err)
+	}
+
+	return &pb.StopImpersonationResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) ExportMyData(ctx context.Context, r *pb.ExportMyDataRequest) (*pb.ExportMyDataResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	format := r.Format
+	if format == "" {
+		format = dataexport.FormatJSON
+	}
+
+	// Begin to request the caller's data export
+	export, err := s.dataExportController.RequestDataExport(ctx, caller.ID, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request data export: %w", err)
+	}
+
+	return &pb.ExportMyDataResponse{
+		ExportId:    uint32(export.ID),
+		Status:      export.Status,
+		RequestedAt: timestamppb.New(export.CreatedAt),
+	}, nil
+}
+
+func (s *UserServerImpl) DownloadMyData(ctx context.Context, r *pb.DownloadMyDataRequest) (*pb.DownloadMyDataResponse, error) {
+	// Request validation
+	if len(r.DownloadToken) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "download token must be provided")
+	}
+
+	// Begin to download the data export of the token
+	export, err := s.dataExportController.DownloadDataExport(ctx, r.DownloadToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download data export: %w", err)
+	}
+
+	return &pb.DownloadMyDataResponse{
+		FileName:    export.FileName,
+		ContentType: export.ContentType,
+		Content:     export.Content,
+	}, nil
+}
+
+func (s *UserServerImpl) GetLoginHistory(ctx context.Context, r *pb.GetLoginHistoryRequest) (*pb.GetLoginHistoryResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, nextPageToken, err := s.auditController.GetLoginHistory(ctx, caller.ID, int(r.PageSize), r.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	return &pb.GetLoginHistoryResponse{Events: toAuditEventsProto(events), NextPageToken: nextPageToken}, nil
+}
+
+func (s *UserServerImpl) ListAuditEvents(ctx context.Context, r *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	// Request validation
+	if r.Outcome != "" && r.Outcome != model.AuditSuccess && r.Outcome != model.AuditFailure {
+		return nil, status.Error(codes.InvalidArgument, "invalid outcome")
+	}
+	if r.From != nil && r.To != nil && !r.From.AsTime().Before(r.To.AsTime()) {
+		return nil, status.Error(codes.InvalidArgument, "from must be before to")
+	}
+
+	filter := repository.AuditEventFilter{
+		EventTypes: r.EventTypes,
+		Outcome:    r.Outcome,
+		From:       toTime(r.From),
+		To:         toTime(r.To),
+	}
+	if r.UserId != 0 {
+		userID := uint(r.UserId)
+		filter.UserID = &userID
+	}
+
+	// Begin to list the audit events
+	events, nextPageToken, err := s.auditController.ListAuditEvents(ctx, filter, int(r.PageSize), r.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return &pb.ListAuditEventsResponse{Events: toAuditEventsProto(events), NextPageToken: nextPageToken}, nil
+}
+
+func (s *UserServerImpl) CreateServiceAccount(ctx context.Context, r *pb.CreateServiceAccountRequest) (*pb.CreateServiceAccountResponse, error) {
+	caller, err := callerPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if !validator.IsValidServiceAccountName(r.Name) {
+		return nil, status.Error(codes.InvalidArgument, "invalid service account name")
+	}
+	if len(r.Scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one scope must be provided")
+	}
+
+	// Begin to create the service account
+	account, apiKey, err := s.serviceAccountController.CreateServiceAccount(ctx, caller, r.Name, r.Description, r.Scopes, daysToDuration(r.ExpiresInDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	return &pb.CreateServiceAccountResponse{ServiceAccount: toServiceAccountProto(account), ApiKey: apiKey}, nil
+}
+
+func (s *UserServerImpl) ListServiceAccounts(ctx context.Context, r *pb.ListServiceAccountsRequest) (*pb.ListServiceAccountsResponse, error) {
+	accounts, err := s.serviceAccountController.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	response := &pb.ListServiceAccountsResponse{ServiceAccounts: make([]*pb.ServiceAccount, 0, len(accounts))}
+	for i := range accounts {
+		response.ServiceAccounts = append(response.ServiceAccounts, toServiceAccountProto(&accounts[i]))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) RotateApiKey(ctx context.Context, r *pb.RotateApiKeyRequest) (*pb.RotateApiKeyResponse, error) {
+	caller, err := callerPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if r.ServiceAccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service account id must be provided")
+	}
+
+	// Begin to rotate the API key
+	key, apiKey, err := s.serviceAccountController.RotateAPIKey(ctx, caller, uint(r.ServiceAccountId), r.Scopes, daysToDuration(r.ExpiresInDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	return &pb.RotateApiKeyResponse{Key: toAPIKeyProto(key), ApiKey: apiKey}, nil
+}
+
+func (s *UserServerImpl) RevokeApiKey(ctx context.Context, r *pb.RevokeApiKeyRequest) (*pb.RevokeApiKeyResponse, error) {
+	// Request validation
+	if len(r.KeyPrefix) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "key prefix must be provided")
+	}
+
+	// Begin to revoke the API key
+	if err := s.serviceAccountController.RevokeAPIKey(ctx, r.KeyPrefix); err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return &pb.RevokeApiKeyResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) CreateInvitation(ctx context.Context, r *pb.CreateInvitationRequest) (*pb.CreateInvitationResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.Email) > 0 && !validator.IsValidEmail(r.Email) {
+		return nil, status.Error(codes.InvalidArgument, "invalid email")
+	}
+	if roleID := uint(r.RoleId); roleID != 0 && roleID != constant.UserRoleID && roleID != constant.AdminRoleID {
+		return nil, status.Error(codes.InvalidArgument, "invalid role id")
+	}
+	if r.MaxUses > 1000 {
+		return nil, status.Error(codes.InvalidArgument, "max uses must not exceed 1000")
+	}
+
+	// Begin to create the invitation
+	invitation, code, err := s.invitationController.CreateInvitation(ctx, caller, r.Email, uint(r.RoleId), int(r.MaxUses), daysToDuration(r.ExpiresInDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return &pb.CreateInvitationResponse{Invitation: toInvitationProto(invitation), InvitationCode: code}, nil
+}
+
+func (s *UserServerImpl) ListInvitations(ctx context.Context, r *pb.ListInvitationsRequest) (*pb.ListInvitationsResponse, error) {
+	invitations, err := s.invitationController.ListInvitations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	response := &pb.ListInvitationsResponse{Invitations: make([]*pb.Invitation, 0, len(invitations))}
+	for i := range invitations {
+		response.Invitations = append(response.Invitations, toInvitationProto(&invitations[i]))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) RevokeInvitation(ctx context.Context, r *pb.RevokeInvitationRequest) (*pb.RevokeInvitationResponse, error) {
+	// Request validation
+	if r.InvitationId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invitation id must be provided")
+	}
+
+	// Begin to revoke the invitation
+	if err := s.invitationController.RevokeInvitation(ctx, uint(r.InvitationId)); err != nil {
+		return nil, fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return &pb.RevokeInvitationResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) CreatePersonalAccessToken(ctx context.Context, r *pb.CreatePersonalAccessTokenRequest) (*pb.CreatePersonalAccessTokenResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.Name) == 0 || len(r.Name) > 50 {
+		return nil, status.Error(codes.InvalidArgument, "invalid token name")
+	}
+	if len(r.Scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one scope must be provided")
+	}
+
+	// Begin to create the personal access token
+	personalAccessToken, plainToken, err := s.personalAccessTokenController.CreatePersonalAccessToken(ctx, caller, r.Name, r.Scopes, daysToDuration(r.ExpiresInDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create personal access token: %w", err)
+	}
+
+	return &pb.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: toPersonalAccessTokenProto(personalAccessToken),
+		Token:               plainToken,
+	}, nil
+}
+
+func (s *UserServerImpl) ListPersonalAccessTokens(ctx context.Context, r *pb.ListPersonalAccessTokensRequest) (*pb.ListPersonalAccessTokensResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.personalAccessTokenController.ListPersonalAccessTokens(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+
+	response := &pb.ListPersonalAccessTokensResponse{PersonalAccessTokens: make([]*pb.PersonalAccessToken, 0, len(tokens))}
+	for i := range tokens {
+		response.PersonalAccessTokens = append(response.PersonalAccessTokens, toPersonalAccessTokenProto(&tokens[i]))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) RevokePersonalAccessToken(ctx context.Context, r *pb.RevokePersonalAccessTokenRequest) (*pb.RevokePersonalAccessTokenResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.TokenPrefix) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "token prefix must be provided")
+	}
+
+	// Begin to revoke the personal access token
+	if err := s.personalAccessTokenController.RevokePersonalAccessToken(ctx, caller.ID, r.TokenPrefix); err != nil {
+		return nil, fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+
+	return &pb.RevokePersonalAccessTokenResponse{Success: true}, nil
+}
+
+func (s *UserServerImpl) StartExternalLogin(ctx context.Context, r *pb.StartExternalLoginRequest) (*pb.StartExternalLoginResponse, error) {
+	// Request validation
+	if len(r.Provider) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "provider must be provided")
+	}
+
+	// Begin to authorize with the external provider
+	authorization, err := s.externalAuthController.StartExternalLogin(ctx, r.Provider, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start external login: %w", err)
+	}
+
+	return &pb.StartExternalLoginResponse{AuthorizationUrl: authorization.URL, State: authorization.State}, nil
+}
+
+func (s *UserServerImpl) CompleteExternalLogin(ctx context.Context, r *pb.CompleteExternalLoginRequest) (*pb.CompleteExternalLoginResponse, error) {
+	// Request validation
+	if len(r.Provider) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "provider must be provided")
+	}
+	if len(r.Code) == 0 || len(r.State) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "code and state must be provided")
+	}
+
+	// Begin to authenticate the user with the external provider
+	result, err := s.externalAuthController.CompleteExternalLogin(ctx, r.Provider, r.Code, r.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete external login: %w", err)
+	}
+
+	// No session is created when the external account is linked into the user
+	switch {
+	case result == nil:
+		return &pb.CompleteExternalLoginResponse{Linked: true}, nil
+	case result.Session == nil:
+		// The session is created once the passkey of the second factor is asserted
+		return &pb.CompleteExternalLoginResponse{MfaRequired: true, MfaOptions: string(result.MFAOptions)}, nil
+	}
+	return &pb.CompleteExternalLoginResponse{AuthToken: result.Session.Token}, nil
+}
+
+func (s *UserServerImpl) LinkExternalAccount(ctx context.Context, r *pb.LinkExternalAccountRequest) (*pb.StartExternalLoginResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.Provider) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "provider must be provided")
+	}
+
+	// Begin to authorize with the external provider for the caller
+	authorization, err := s.externalAuthController.StartExternalLogin(ctx, r.Provider, &caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link external account: %w", err)
+	}
+
+	return &pb.StartExternalLoginResponse{AuthorizationUrl: authorization.URL, State: authorization.State}, nil
+}
+
+func (s *UserServerImpl) ListExternalAccounts(ctx context.Context, r *pb.ListExternalAccountsRequest) (*pb.ListExternalAccountsResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logins, err := s.externalAuthController.ListExternalAccounts(ctx, caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external accounts: %w", err)
+	}
+
+	response := &pb.ListExternalAccountsResponse{ExternalAccounts: make([]*pb.ExternalAccount, 0, len(logins))}
+	for i := range logins {
+		response.ExternalAccounts = append(response.ExternalAccounts, toExternalAccountProto(&logins[i]))
+	}
+	return response, nil
+}
+
+func (s *UserServerImpl) UnlinkExternalAccount(ctx context.Context, r *pb.UnlinkExternalAccountRequest) (*pb.UnlinkExternalAccountResponse, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request validation
+	if len(r.Provider) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "provider must be provided")
+	}
+
+	// Begin to unlink the external account
+	if err := s.externalAuthController.UnlinkExternalAccount(ctx, caller.ID, r.Provider); err != nil {
+		return nil, fmt.Errorf("failed to unlink external account: %w", err)
+	}
+
+	return &pb.UnlinkExternalAccountResponse{Success: true}, nil
+}
+
+// callerPrincipal get the authenticated caller, either the user or the service account
+func callerPrincipal(ctx context.Context) (*principal.Principal, error) {
+	caller, ok := principal.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+	}
+	return caller, nil
+}
+
+// userPrincipal get the authenticated user calling the method
+func userPrincipal(ctx context.Context) (*principal.Principal, error) {
+	caller, ok := principal.FromContext(ctx)
+	if !ok || caller.Type != principal.TypeUser {
+		return nil, status.Error(codes.PermissionDenied, "the method is only allowed for users")
+	}
+	return caller, nil
+}
+
+// sessionPrincipal get the user calling with the login session, e.g. the personal
+// access token has no session to reauthenticate
+func sessionPrincipal(ctx context.Context) (*principal.Principal, error) {
+	caller, err := userPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller.SessionID == 0 {
+		return nil, apperror.ErrReauthUnsupported
+	}
+	return caller, nil
+}
@@ -1,15 +1,70 @@
 package config
 
-import "github.com/budgetin-app/user-service/app/controller"
+import (
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/pkg/scheduler"
+	"github.com/budgetin-app/user-service/app/server/healthcheck"
+)
 
 type Configuration struct {
-	AuthController controller.AuthController
+	AuthController                controller.AuthController
+	ServiceAccountController      controller.ServiceAccountController
+	PersonalAccessTokenController controller.PersonalAccessTokenController
+	ExternalAuthController        controller.ExternalAuthController
+	MagicLinkController           controller.MagicLinkController
+	PasskeyController             controller.PasskeyController
+	AuditController               controller.AuditController
+	DeviceController              controller.DeviceController
+	PasswordRecoveryController    controller.PasswordRecoveryController
+	SessionController             controller.SessionController
+	AccountDeletionController     controller.AccountDeletionController
+	DataExportController          controller.DataExportController
+	AccountStatusController       controller.AccountStatusController
+	UserDirectoryController       controller.UserDirectoryController
+	ImpersonationController       controller.ImpersonationController
+	InvitationController          controller.InvitationController
+	HealthChecker                 *healthcheck.HealthChecker
+	MaintenanceScheduler          *scheduler.Scheduler
 }
 
 func NewConfiguration(
 	authController controller.AuthController,
+	serviceAccountController controller.ServiceAccountController,
+	personalAccessTokenController controller.PersonalAccessTokenController,
+	externalAuthController controller.ExternalAuthController,
+	magicLinkController controller.MagicLinkController,
+	passkeyController controller.PasskeyController,
+	auditController controller.AuditController,
+	deviceController controller.DeviceController,
+	passwordRecoveryController controller.PasswordRecoveryController,
+	sessionController controller.SessionController,
+	accountDeletionController controller.AccountDeletionController,
+	dataExportController controller.DataExportController,
+	accountStatusController controller.AccountStatusController,
+	userDirectoryController controller.UserDirectoryController,
+	impersonationController controller.ImpersonationController,
+	invitationController controller.InvitationController,
+	healthChecker *healthcheck.HealthChecker,
+	maintenanceScheduler *scheduler.Scheduler,
 ) *Configuration {
 	return &Configuration{
-		AuthController: authController,
+		AuthController:                authController,
+		ServiceAccountController:      serviceAccountController,
+		PersonalAccessTokenController: personalAccessTokenController,
+		ExternalAuthController:        externalAuthController,
+		MagicLinkController:           magicLinkController,
+		PasskeyController:             passkeyController,
+		AuditController:               auditController,
+		DeviceController:              deviceController,
+		PasswordRecoveryController:    passwordRecoveryController,
+		SessionController:             sessionController,
+		AccountDeletionController:     accountDeletionController,
+		DataExportController:          dataExportController,
+		AccountStatusController:       accountStatusController,
+		UserDirectoryController:       userDirectoryController,
+		ImpersonationController:       impersonationController,
+		InvitationController:          invitationController,
+		HealthChecker:                 healthChecker,
+		MaintenanceScheduler:          maintenanceScheduler,
 	}
 }
@@ -14,11 +14,16 @@ func ConnectDB() *gorm.DB {
 	dsn := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s", os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"))
 
 	// Connecting to database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("failed to connect to database")
 	}
 
+	// Trace every database operation through the request context
+	if err := db.Use(TracingPlugin{}); err != nil {
+		log.Fatal("failed to register database tracing plugin")
+	}
+
 	// Run migration
 	MigrateDB(db)
 
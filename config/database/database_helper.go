@@ -1,12 +1,47 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"github.com/budgetin-app/user-service/app/domain/apperror"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// HandleErrorDB translates the database error into the domain error, so the
+// database message is never returned to the client
 func HandleErrorDB(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		log.Debugf("database error: %v", err)
+		return apperror.Wrap(apperror.ErrNotFound, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		log.Errorf("database error: %v", err)
+		return apperror.Wrap(apperror.ErrAlreadyExists, err)
+	}
+
+	// Keep the domain error returned by the model hooks
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
 	log.Errorf("database error: %v", err)
-	return fmt.Errorf("database error: %v", err)
+	return apperror.Wrap(apperror.ErrInternal, err)
+}
+
+// PingDB verifies the connection to the database through the gorm connection pool
+func PingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database pool: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
 }
@@ -1,21 +1,51 @@
 package database
 
 import (
+	"fmt"
+
 	"github.com/budgetin-app/user-service/app/domain/model"
 	"gorm.io/gorm"
 )
 
+// migrationModels list the models that should be migrated into the database
+var migrationModels = []interface{}{
+	&model.Account{},
+	&model.Role{},
+	&model.Permission{},
+	&model.Session{},
+	&model.LoginInfo{},
+	&model.HashAlgorithm{},
+	&model.EmailVerification{},
+	&model.PasswordRecovery{},
+	&model.ServiceAccount{},
+	&model.APIKey{},
+	&model.PersonalAccessToken{},
+	&model.ExternalProvider{},
+	&model.LoginExternal{},
+	&model.ExternalLoginState{},
+	&model.MagicLink{},
+	&model.WebAuthnCredential{},
+	&model.WebAuthnCeremony{},
+	&model.AuditEvent{},
+	&model.KnownDevice{},
+	&model.OutboxEvent{},
+	&model.DataExport{},
+	&model.Invitation{},
+	// .. add other db migration model here
+}
+
 // MigrateDB execute the database migration according to the models
 func MigrateDB(db *gorm.DB) {
-	db.AutoMigrate(
-		&model.Account{},
-		&model.Role{},
-		&model.Permission{},
-		&model.Session{},
-		&model.LoginInfo{},
-		&model.HashAlgorithm{},
-		&model.EmailVerification{},
-		&model.PasswordRecovery{},
-		// .. add other db migration model here
-	)
+	db.AutoMigrate(migrationModels...)
+}
+
+// CheckMigration ensure every migration model already has its table in the database
+func CheckMigration(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, m := range migrationModels {
+		if !migrator.HasTable(m) {
+			return fmt.Errorf("table for model %T is not migrated", m)
+		}
+	}
+	return nil
 }
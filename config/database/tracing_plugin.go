@@ -0,0 +1,79 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "tracing:span"
+
+// TracingPlugin is the gorm plugin that wraps every database operation into a span,
+// the parent span is taken from the context given through 'db.WithContext'
+type TracingPlugin struct{}
+
+func (TracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (p TracingPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+// before starts the span of the database operation
+func (TracingPlugin) before(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.Statement.Context == nil {
+			return
+		}
+		ctx, span := tracer.Start(tx.Statement.Context, "gorm."+operation,
+			semconv.DBSystemPostgreSQL,
+			attribute.String("db.operation", operation),
+		)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+// after ends the span of the database operation with the executed statement
+func (TracingPlugin) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		semconv.DBSQLTable(tx.Statement.Table),
+		semconv.DBStatement(tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+
+	// Record not found is an expected result, so it's not marked as a span error
+	err := tx.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	tracer.End(span, err)
+}
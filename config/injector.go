@@ -7,13 +7,27 @@ package config
 import (
 	"github.com/budgetin-app/user-management-service/config/database"
 	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/pkg/events"
+	"github.com/budgetin-app/user-service/app/pkg/identity"
+	"github.com/budgetin-app/user-service/app/pkg/passkey"
 	"github.com/budgetin-app/user-service/app/repository"
+	"github.com/budgetin-app/user-service/app/server/healthcheck"
+	"github.com/budgetin-app/user-service/app/server/maintenance"
 	"github.com/google/wire"
 )
 
 // Databases
 var db = wire.NewSet(database.ConnectDB)
 
+// External identity providers
+var identityRegistry = wire.NewSet(identity.NewRegistryFromEnv)
+
+// WebAuthn relying party
+var relyingParty = wire.NewSet(passkey.NewRelyingPartyFromEnv)
+
+// Event publisher of the other services
+var eventPublisher = wire.NewSet(events.NewPublisherFromEnv)
+
 // Repositories
 var accountRepository = wire.NewSet(
 	repository.NewAccountRepository,
@@ -40,23 +54,214 @@ var emailVerificationRepository = wire.NewSet(
 	wire.Bind(new(repository.EmailVerificationRepository), new(*repository.EmailVerificationRepositoryImpl)),
 )
 
+var serviceAccountRepository = wire.NewSet(
+	repository.NewServiceAccountRepository,
+	wire.Bind(new(repository.ServiceAccountRepository), new(*repository.ServiceAccountRepositoryImpl)),
+)
+
+var personalAccessTokenRepository = wire.NewSet(
+	repository.NewPersonalAccessTokenRepository,
+	wire.Bind(new(repository.PersonalAccessTokenRepository), new(*repository.PersonalAccessTokenRepositoryImpl)),
+)
+
+var externalLoginRepository = wire.NewSet(
+	repository.NewExternalLoginRepository,
+	wire.Bind(new(repository.ExternalLoginRepository), new(*repository.ExternalLoginRepositoryImpl)),
+)
+
+var magicLinkRepository = wire.NewSet(
+	repository.NewMagicLinkRepository,
+	wire.Bind(new(repository.MagicLinkRepository), new(*repository.MagicLinkRepositoryImpl)),
+)
+
+var webAuthnRepository = wire.NewSet(
+	repository.NewWebAuthnRepository,
+	wire.Bind(new(repository.WebAuthnRepository), new(*repository.WebAuthnRepositoryImpl)),
+)
+
+var userDirectoryRepository = wire.NewSet(
+	repository.NewUserDirectoryRepository,
+	wire.Bind(new(repository.UserDirectoryRepository), new(*repository.UserDirectoryRepositoryImpl)),
+)
+
+var invitationRepository = wire.NewSet(
+	repository.NewInvitationRepository,
+	wire.Bind(new(repository.InvitationRepository), new(*repository.InvitationRepositoryImpl)),
+)
+
+var auditRepository = wire.NewSet(
+	repository.NewAuditRepository,
+	wire.Bind(new(repository.AuditRepository), new(*repository.AuditRepositoryImpl)),
+)
+
+var knownDeviceRepository = wire.NewSet(
+	repository.NewKnownDeviceRepository,
+	wire.Bind(new(repository.KnownDeviceRepository), new(*repository.KnownDeviceRepositoryImpl)),
+)
+
+var maintenanceRepository = wire.NewSet(
+	repository.NewMaintenanceRepository,
+	wire.Bind(new(repository.MaintenanceRepository), new(*repository.MaintenanceRepositoryImpl)),
+)
+
+var accountDeletionRepository = wire.NewSet(
+	repository.NewAccountDeletionRepository,
+	wire.Bind(new(repository.AccountDeletionRepository), new(*repository.AccountDeletionRepositoryImpl)),
+)
+
+var dataExportRepository = wire.NewSet(
+	repository.NewDataExportRepository,
+	wire.Bind(new(repository.DataExportRepository), new(*repository.DataExportRepositoryImpl)),
+)
+
+var eventRepository = wire.NewSet(
+	repository.NewEventRepository,
+	wire.Bind(new(repository.EventRepository), new(*repository.EventRepositoryImpl)),
+)
+
 // Controllers
 var authController = wire.NewSet(
 	controller.NewAuthController,
 	wire.Bind(new(controller.AuthController), new(*controller.AuthControllerImpl)),
 )
 
+var serviceAccountController = wire.NewSet(
+	controller.NewServiceAccountController,
+	wire.Bind(new(controller.ServiceAccountController), new(*controller.ServiceAccountControllerImpl)),
+)
+
+var personalAccessTokenController = wire.NewSet(
+	controller.NewPersonalAccessTokenController,
+	wire.Bind(new(controller.PersonalAccessTokenController), new(*controller.PersonalAccessTokenControllerImpl)),
+)
+
+var externalAuthController = wire.NewSet(
+	controller.NewExternalAuthController,
+	wire.Bind(new(controller.ExternalAuthController), new(*controller.ExternalAuthControllerImpl)),
+)
+
+var magicLinkController = wire.NewSet(
+	controller.NewMagicLinkController,
+	wire.Bind(new(controller.MagicLinkController), new(*controller.MagicLinkControllerImpl)),
+)
+
+var passkeyController = wire.NewSet(
+	controller.NewPasskeyController,
+	wire.Bind(new(controller.PasskeyController), new(*controller.PasskeyControllerImpl)),
+)
+
+var auditController = wire.NewSet(
+	controller.NewAuditController,
+	wire.Bind(new(controller.AuditController), new(*controller.AuditControllerImpl)),
+)
+
+var deviceController = wire.NewSet(
+	controller.NewDeviceController,
+	wire.Bind(new(controller.DeviceController), new(*controller.DeviceControllerImpl)),
+)
+
+var passwordRecoveryController = wire.NewSet(
+	controller.NewPasswordRecoveryController,
+	wire.Bind(new(controller.PasswordRecoveryController), new(*controller.PasswordRecoveryControllerImpl)),
+)
+
+var sessionController = wire.NewSet(
+	controller.NewSessionController,
+	wire.Bind(new(controller.SessionController), new(*controller.SessionControllerImpl)),
+)
+
+var accountDeletionController = wire.NewSet(
+	controller.NewAccountDeletionController,
+	wire.Bind(new(controller.AccountDeletionController), new(*controller.AccountDeletionControllerImpl)),
+)
+
+var dataExportController = wire.NewSet(
+	controller.NewDataExportController,
+	wire.Bind(new(controller.DataExportController), new(*controller.DataExportControllerImpl)),
+)
+
+var accountStatusController = wire.NewSet(
+	controller.NewAccountStatusController,
+	wire.Bind(new(controller.AccountStatusController), new(*controller.AccountStatusControllerImpl)),
+)
+
+var userDirectoryController = wire.NewSet(
+	controller.NewUserDirectoryController,
+	wire.Bind(new(controller.UserDirectoryController), new(*controller.UserDirectoryControllerImpl)),
+)
+
+var impersonationController = wire.NewSet(
+	controller.NewImpersonationController,
+	wire.Bind(new(controller.ImpersonationController), new(*controller.ImpersonationControllerImpl)),
+)
+
+var invitationController = wire.NewSet(
+	controller.NewInvitationController,
+	wire.Bind(new(controller.InvitationController), new(*controller.InvitationControllerImpl)),
+)
+
+var eventController = wire.NewSet(
+	controller.NewEventController,
+	wire.Bind(new(controller.EventController), new(*controller.EventControllerImpl)),
+)
+
+var maintenanceController = wire.NewSet(
+	controller.NewMaintenanceController,
+	wire.Bind(new(controller.MaintenanceController), new(*controller.MaintenanceControllerImpl)),
+)
+
+// Health checks
+var healthChecker = wire.NewSet(healthcheck.NewHealthChecker)
+
+// Background maintenance jobs
+var maintenanceScheduler = wire.NewSet(maintenance.NewMaintenanceScheduler)
+
 // Configure initialized the dependency injection components
 func Configure() *Configuration {
 	wire.Build(
 		NewConfiguration,
 		db,
+		identityRegistry,
+		relyingParty,
+		eventPublisher,
 		accountRepository,
 		loginInfoRepository,
 		roleRepository,
 		sessionRepository,
 		emailVerificationRepository,
+		serviceAccountRepository,
+		personalAccessTokenRepository,
+		externalLoginRepository,
+		magicLinkRepository,
+		webAuthnRepository,
+		auditRepository,
+		userDirectoryRepository,
+		invitationRepository,
+		knownDeviceRepository,
+		maintenanceRepository,
+		accountDeletionRepository,
+		dataExportRepository,
+		eventRepository,
 		authController,
+		serviceAccountController,
+		personalAccessTokenController,
+		externalAuthController,
+		magicLinkController,
+		passkeyController,
+		auditController,
+		deviceController,
+		passwordRecoveryController,
+		sessionController,
+		accountDeletionController,
+		dataExportController,
+		accountStatusController,
+		userDirectoryController,
+		impersonationController,
+		invitationController,
+		eventController,
+		maintenanceController,
+		healthChecker,
+		maintenanceScheduler,
 	)
 	return nil
 }
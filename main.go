@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/budgetin-app/user-management-service/config"
 	"github.com/budgetin-app/user-service/app/pkg/helper/env"
 	"github.com/budgetin-app/user-service/app/pkg/logger"
+	"github.com/budgetin-app/user-service/app/pkg/metrics"
+	"github.com/budgetin-app/user-service/app/pkg/tlsconfig"
+	"github.com/budgetin-app/user-service/app/pkg/tracer"
 	"github.com/budgetin-app/user-service/app/server"
+	"github.com/budgetin-app/user-service/app/server/gateway"
 	"github.com/joho/godotenv"
 )
 
@@ -21,11 +33,24 @@ func init() {
 }
 
 func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Initialize the tracer provider with the configured exporter
+	shutdownTracer, err := tracer.InitTracer(ctx)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracer: %v", err)
+	}
+	defer shutdownTracer(context.Background())
+
 	// Initialize the configuration for dependency injection
 	cfg := config.Configure()
 
+	// Load the TLS credentials of the server and the gateway when enabled
+	serverOptions, gatewayDialOptions := initTLS(ctx)
+
 	// Initialize grpc server
-	server := server.InitServer(cfg)
+	server := server.InitServer(cfg, serverOptions...)
 
 	// Listener for incoming TCP connections on the specified ports
 	port := env.GetenvOrDefault("SERVER_PORT", "50051")
@@ -34,6 +59,37 @@ func main() {
 		log.WithFields(log.Fields{"port": port}).Fatal("Failed to listen")
 	}
 
+	// Expose the prometheus metrics on a separate HTTP port
+	go metrics.Serve(env.GetenvOrDefault("METRICS_PORT", "9090"))
+
+	// Start evaluating the health checks for liveness and readiness probes
+	cfg.HealthChecker.Start()
+
+	// Run the maintenance jobs when the instance is elected as the leader
+	cfg.MaintenanceScheduler.Start(ctx)
+
+	// Serve the REST/JSON gateway in front of the gRPC server
+	go func() {
+		gatewayPort := env.GetenvOrDefault("GATEWAY_PORT", "8081")
+		if err := gateway.Serve(ctx, gatewayPort, fmt.Sprintf("localhost:%s", port), gatewayDialOptions...); err != nil {
+			log.WithFields(log.Fields{"port": gatewayPort}).Errorf("Gateway failed to serve: %v", err)
+		}
+	}()
+
+	// Gracefully stop the server on termination signal, the health status is
+	// flipped to NOT_SERVING first so no new traffic is routed while draining
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+
+		log.Info("Shutting down server")
+		cfg.HealthChecker.Shutdown()
+		cfg.MaintenanceScheduler.Stop()
+		cancel()
+		server.GracefulStop()
+	}()
+
 	// Log the server address where it's listening
 	log.Infof("Server listening: %v", listen.Addr())
 
@@ -44,3 +100,26 @@ func main() {
 		}).Fatal("Server failed to serve")
 	}
 }
+
+// initTLS loads the server certificates and watches them for hot reload, it
+// returns the server options and the gateway dial options accordingly
+func initTLS(ctx context.Context) ([]grpc.ServerOption, []grpc.DialOption) {
+	if !tlsconfig.IsEnabled() {
+		log.Warn("TLS is disabled, the server accepts plaintext connections")
+		return nil, []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(tlsconfig.NewConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to load TLS certificates: %v", err)
+	}
+	go reloader.Watch(ctx, env.GetDurationOrDefault("TLS_RELOAD_INTERVAL", time.Minute))
+
+	gatewayConfig, err := tlsconfig.NewGatewayClientConfig()
+	if err != nil {
+		log.Fatalf("Failed to load gateway TLS configuration: %v", err)
+	}
+
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(reloader.ServerConfig()))},
+		[]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(gatewayConfig))}
+}
@@ -0,0 +1,116 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+)
+
+const (
+	statusAdminID = 1
+	statusUserID  = 5
+)
+
+func newAccountStatusController(account model.Account, sessions *fakeSessionRepository) (*controller.AccountStatusControllerImpl, *fakeAccountRepository) {
+	account.ID = statusUserID
+	account.CreatedAt = time.Now()
+	accounts := &fakeAccountRepository{accounts: []model.Account{account}}
+	return controller.NewAccountStatusController(accounts, sessions, &fakeAuditRepository{}), accounts
+}
+
+func TestChangeAccountStatus(t *testing.T) {
+	scheduledAt := time.Now()
+
+	tests := []struct {
+		name       string
+		account    model.Account
+		change     func(c *controller.AccountStatusControllerImpl, ctx context.Context, actorID uint, userID uint, reason string) (*model.Account, error)
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name:       "suspend active account",
+			account:    model.Account{Status: model.AccountActive},
+			change:     (*controller.AccountStatusControllerImpl).SuspendAccount,
+			wantStatus: model.AccountSuspended,
+		},
+		{
+			name:       "reinstate suspended account",
+			account:    model.Account{Status: model.AccountSuspended},
+			change:     (*controller.AccountStatusControllerImpl).ReinstateAccount,
+			wantStatus: model.AccountActive,
+		},
+		{
+			name:    "reinstate active account",
+			account: model.Account{Status: model.AccountActive},
+			change:  (*controller.AccountStatusControllerImpl).ReinstateAccount,
+			wantErr: apperror.ErrInvalidStatusTransition,
+		},
+		{
+			name:    "suspend suspended account",
+			account: model.Account{Status: model.AccountSuspended},
+			change:  (*controller.AccountStatusControllerImpl).SuspendAccount,
+			wantErr: apperror.ErrInvalidStatusTransition,
+		},
+		{
+			// The reinstated account keeps its scheduled deletion
+			name:       "reinstate account scheduled for deletion",
+			account:    model.Account{Status: model.AccountDeactivated, DeletionScheduledAt: &scheduledAt},
+			change:     (*controller.AccountStatusControllerImpl).ReinstateAccount,
+			wantStatus: model.AccountPendingDeletion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, accounts := newAccountStatusController(tt.account, &fakeSessionRepository{})
+
+			account, err := tt.change(c, context.Background(), statusAdminID, statusUserID, "review")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if stored := accounts.accounts[0].Status; stored != tt.account.Status {
+					t.Errorf("stored status = %q, want %q unchanged", stored, tt.account.Status)
+				}
+				return
+			}
+			if account.Status != tt.wantStatus || accounts.accounts[0].Status != tt.wantStatus {
+				t.Errorf("status = %q, stored %q, want %q", account.Status, accounts.accounts[0].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSuspendAccountRevokesSessions(t *testing.T) {
+	sessions := &fakeSessionRepository{sessions: []model.Session{
+		{ID: 1, UserID: statusUserID},
+		{ID: 2, UserID: statusUserID, SessionOptions: model.SessionOptions{RememberMe: true}},
+		{ID: 3, UserID: statusAdminID},
+	}}
+	c, _ := newAccountStatusController(model.Account{Status: model.AccountActive}, sessions)
+
+	if _, err := c.SuspendAccount(context.Background(), statusAdminID, statusUserID, "abuse"); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(sessions.sessions) != 1 || sessions.sessions[0].UserID != statusAdminID {
+		t.Errorf("sessions = %+v, want only the session of the other user", sessions.sessions)
+	}
+}
+
+func TestChangeOwnAccountStatus(t *testing.T) {
+	c, accounts := newAccountStatusController(model.Account{Status: model.AccountActive}, &fakeSessionRepository{})
+
+	// The admin shouldn't lock themselves out
+	if _, err := c.SuspendAccount(context.Background(), statusUserID, statusUserID, ""); !errors.Is(err, apperror.ErrOwnStatusChange) {
+		t.Errorf("error = %v, want %v", err, apperror.ErrOwnStatusChange)
+	}
+	if accounts.accounts[0].Status != model.AccountActive {
+		t.Errorf("status = %q, want %q", accounts.accounts[0].Status, model.AccountActive)
+	}
+}
@@ -0,0 +1,114 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+)
+
+const invitationCode = "invitation-code"
+
+func newRegisterController(t *testing.T, invitations *fakeInvitationRepository, audits *fakeAuditRepository) *controller.AuthControllerImpl {
+	return controller.NewAuthController(
+		&fakeAccountRepository{db: newFakeDB(t)},
+		&fakeLoginInfoRepository{},
+		&fakeRoleRepository{},
+		&fakeSessionRepository{},
+		&fakeEmailVerificationRepository{},
+		invitations,
+		&fakePasskeyController{},
+		&fakeDeviceController{},
+		&fakeAccountDeletionRepository{},
+		audits,
+	)
+}
+
+// newInvitation stores the invitation of the code
+func newInvitation(invitations *fakeInvitationRepository, invitation model.Invitation) {
+	invitation.CodeHash = token.HashToken(invitationCode)
+	invitations.CreateInvitation(context.Background(), &invitation)
+}
+
+func TestRegisterInviteOnly(t *testing.T) {
+	t.Setenv("REGISTRATION_MODE", controller.RegistrationInviteOnly)
+	validUntil := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		invitation model.Invitation
+		code       string
+		wantErr    error
+	}{
+		{name: "open invitation", invitation: model.Invitation{MaxUses: 1, ExpiredAt: validUntil}, code: invitationCode},
+		{name: "invitation of the email", invitation: model.Invitation{Email: "jane@example.com", MaxUses: 1, ExpiredAt: validUntil}, code: invitationCode},
+		{name: "no invitation code", invitation: model.Invitation{MaxUses: 1, ExpiredAt: validUntil}, wantErr: apperror.ErrInvitationRequired},
+		{name: "unknown invitation code", invitation: model.Invitation{MaxUses: 1, ExpiredAt: validUntil}, code: "other-code", wantErr: apperror.ErrInvalidInvitation},
+		{name: "invitation of another email", invitation: model.Invitation{Email: "john@example.com", MaxUses: 1, ExpiredAt: validUntil}, code: invitationCode, wantErr: apperror.ErrInvalidInvitation},
+		{name: "expired invitation", invitation: model.Invitation{MaxUses: 1, ExpiredAt: time.Now()}, code: invitationCode, wantErr: apperror.ErrInvalidInvitation},
+		{name: "used up invitation", invitation: model.Invitation{MaxUses: 1, Uses: 1, ExpiredAt: validUntil}, code: invitationCode, wantErr: apperror.ErrInvalidInvitation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invitations := &fakeInvitationRepository{}
+			newInvitation(invitations, tt.invitation)
+			c := newRegisterController(t, invitations, &fakeAuditRepository{})
+
+			_, err := c.Register(context.Background(), "jane", "jane@example.com", "Secret-Passw0rd", tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			wantUses := tt.invitation.Uses
+			if tt.wantErr == nil {
+				wantUses++
+			}
+			if uses := invitations.invitations[0].Uses; uses != wantUses {
+				t.Errorf("uses = %d, want %d", uses, wantUses)
+			}
+		})
+	}
+}
+
+func TestRegisterClosed(t *testing.T) {
+	t.Setenv("REGISTRATION_MODE", controller.RegistrationClosed)
+	invitations := &fakeInvitationRepository{}
+	newInvitation(invitations, model.Invitation{MaxUses: 1, ExpiredAt: time.Now().Add(time.Hour)})
+
+	// The invitation doesn't reopen the closed registration
+	_, err := newRegisterController(t, invitations, &fakeAuditRepository{}).Register(context.Background(), "jane", "jane@example.com", "Secret-Passw0rd", invitationCode)
+	if !errors.Is(err, apperror.ErrRegistrationClosed) {
+		t.Errorf("error = %v, want %v", err, apperror.ErrRegistrationClosed)
+	}
+}
+
+func TestRegisterInvitationRole(t *testing.T) {
+	t.Setenv("REGISTRATION_MODE", controller.RegistrationInviteOnly)
+	roleID := constant.AdminRoleID
+	invitations := &fakeInvitationRepository{}
+	newInvitation(invitations, model.Invitation{RoleID: &roleID, MaxUses: 1, CreatedBy: 1, ExpiredAt: time.Now().Add(time.Hour)})
+	audits := &fakeAuditRepository{}
+
+	info, err := newRegisterController(t, invitations, audits).Register(context.Background(), "jane", "jane@example.com", "Secret-Passw0rd", invitationCode)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	// The role granted by the invitation is recorded into the audit trail
+	for _, event := range audits.events {
+		if event.EventType != model.AuditRoleChange {
+			continue
+		}
+		if *event.UserID != info.ID || event.Metadata["role_id"] != "2" || event.Metadata["invitation_id"] != "1" || event.Metadata["granted_by"] != "1" {
+			t.Errorf("role change = %+v, want the admin role of invitation 1 granted to user %d", event, info.ID)
+		}
+		return
+	}
+	t.Errorf("no role change is recorded")
+}
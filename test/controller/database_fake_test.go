@@ -0,0 +1,111 @@
+package controller_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils/tests"
+)
+
+// fakeDriver is the database accepting every statement for the controllers running
+// their own transaction, the inserted rows are given increasing ids and the queries
+// find no rows. Nothing is stored, the tests check the repository fakes instead.
+type fakeDriver struct {
+	lastInsertID atomic.Int64
+}
+
+// newFakeDB opens the gorm database over the fake driver
+func newFakeDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{
+		ConnPool: sql.OpenDB(&fakeDriver{}),
+		Logger:   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open the fake database: %v", err)
+	}
+	return db
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	conn *fakeConn
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return fakeResult{id: s.conn.driver.lastInsertID.Add(1)}, nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeResult struct {
+	id int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return nil
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next([]driver.Value) error {
+	return io.EOF
+}
@@ -0,0 +1,49 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/events"
+)
+
+type recordingPublisher struct {
+	published []events.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event events.Event) error {
+	p.published = append(p.published, event)
+	return nil
+}
+
+func TestPublishPendingEvents(t *testing.T) {
+	repository := &fakeEventRepository{events: []model.OutboxEvent{{ID: 1, EventKey: "event-1", EventType: "user.deleted"}}}
+	publisher := &recordingPublisher{}
+
+	published, err := controller.NewEventController(repository, publisher).PublishPendingEvents(context.Background())
+	if err != nil || published != 1 {
+		t.Fatalf("published = %d, error = %v, want 1 event", published, err)
+	}
+	if len(publisher.published) != 1 || publisher.published[0].ID != "event-1" {
+		t.Errorf("delivered events = %+v, want event-1", publisher.published)
+	}
+	if repository.events[0].PublishedAt == nil {
+		t.Error("event is not marked as published")
+	}
+}
+
+func TestPublishPendingEventsWithoutPublisher(t *testing.T) {
+	t.Setenv("EVENT_WEBHOOK_URL", "")
+	repository := &fakeEventRepository{events: []model.OutboxEvent{{ID: 1, EventKey: "event-1", EventType: "user.deleted"}}}
+
+	// The events are kept pending until the publisher is configured
+	published, err := controller.NewEventController(repository, events.NewPublisherFromEnv()).PublishPendingEvents(context.Background())
+	if err != nil || published != 0 {
+		t.Fatalf("published = %d, error = %v, want no event", published, err)
+	}
+	if event := repository.events[0]; event.PublishedAt != nil || event.Attempts != 0 {
+		t.Errorf("event = %+v, want it kept pending", event)
+	}
+}
@@ -0,0 +1,165 @@
+package controller_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/identity"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	externalUserID  = 9
+	externalSubject = "fake-subject"
+)
+
+// fakeOIDCProvider is the in-process OIDC provider issuing the ID tokens of the
+// external subject for the authorization codes it issued
+type fakeOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	p := &fakeOIDCProvider{key: key, nonces: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &p.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize simulates the user consent, the authorization code is issued for the URL
+func (p *fakeOIDCProvider) authorize(t *testing.T, authorizationURL string) (code string, state string) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+	query := parsed.Query()
+	code = "code-" + query.Get("state")
+	p.mu.Lock()
+	p.nonces[code] = query.Get("nonce")
+	p.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	p.mu.Lock()
+	nonce := p.nonces[r.Form.Get("code")]
+	p.mu.Unlock()
+
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	idToken, _ := jwt.Signed(signer).Claims(map[string]interface{}{
+		"iss":   p.server.URL,
+		"sub":   externalSubject,
+		"aud":   "user-service",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": nonce,
+		"email": "jane@example.com",
+	}).CompactSerialize()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// login completes the external login of the user linked with the external subject
+func (p *fakeOIDCProvider) login(t *testing.T, passkeys *fakePasskeyController) (*controller.LoginResult, error) {
+	registry := identity.NewRegistry([]identity.ProviderConfig{{
+		Name:        "fake",
+		Type:        identity.TypeOIDC,
+		Issuer:      p.server.URL,
+		ClientID:    "user-service",
+		RedirectURL: "http://localhost:8081/v1/auth/external/fake/callback",
+		Scopes:      []string{"openid", "email"},
+	}})
+	c := controller.NewExternalAuthController(
+		registry,
+		&fakeExternalLoginRepository{logins: []model.LoginExternal{{UserID: externalUserID, ProviderID: 1, Subject: externalSubject}}},
+		&fakeLoginInfoRepository{},
+		&fakeSessionRepository{},
+		&fakeAccountRepository{accounts: []model.Account{{ID: externalUserID, Status: model.AccountActive}}},
+		&fakeAccountDeletionRepository{},
+		&fakeAuditRepository{},
+		passkeys,
+		&fakeDeviceController{},
+	)
+
+	ctx := context.Background()
+	authorization, err := c.StartExternalLogin(ctx, "fake", nil)
+	if err != nil {
+		t.Fatalf("StartExternalLogin failed: %v", err)
+	}
+	code, state := p.authorize(t, authorization.URL)
+	return c.CompleteExternalLogin(ctx, "fake", code, state)
+}
+
+func TestCompleteExternalLogin(t *testing.T) {
+	result, err := newFakeOIDCProvider(t).login(t, &fakePasskeyController{})
+	if err != nil {
+		t.Fatalf("CompleteExternalLogin failed: %v", err)
+	}
+	if result.Session == nil || result.Session.UserID != externalUserID {
+		t.Fatalf("result = %+v, want the session of user %d", result, externalUserID)
+	}
+	if want := []string{model.LoginMethodExternal}; !slices.Equal(result.Session.AuthMethods, want) {
+		t.Errorf("auth methods = %v, want %v", result.Session.AuthMethods, want)
+	}
+}
+
+func TestCompleteExternalLoginRequiresPasskey(t *testing.T) {
+	passkeys := &fakePasskeyController{users: map[uint]bool{externalUserID: true}}
+
+	// The user having a passkey asserts it as the second factor before the session is created
+	result, err := newFakeOIDCProvider(t).login(t, passkeys)
+	if err != nil {
+		t.Fatalf("CompleteExternalLogin failed: %v", err)
+	}
+	if result.Session != nil || result.MFAOptions == nil {
+		t.Errorf("result = %+v, want the second factor options without session", result)
+	}
+	if want := []string{model.LoginMethodExternal}; !slices.Equal(passkeys.firstFactors, want) {
+		t.Errorf("first factors = %v, want %v", passkeys.firstFactors, want)
+	}
+}
@@ -0,0 +1,103 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+)
+
+const (
+	impersonatorID     = 1
+	impersonatedUserID = 6
+)
+
+func newImpersonationController(account model.Account, sessions *fakeSessionRepository) *controller.ImpersonationControllerImpl {
+	account.ID = impersonatedUserID
+	account.CreatedAt = time.Now()
+	return controller.NewImpersonationController(
+		&fakeAccountRepository{accounts: []model.Account{account}},
+		sessions,
+		&fakeAuditRepository{},
+	)
+}
+
+func TestStartImpersonation(t *testing.T) {
+	tests := []struct {
+		name         string
+		impersonator uint
+		account      model.Account
+		wantErr      error
+	}{
+		{name: "user account", impersonator: impersonatorID, account: model.Account{RoleID: constant.UserRoleID, Status: model.AccountActive}},
+		{name: "own account", impersonator: impersonatedUserID, account: model.Account{RoleID: constant.UserRoleID, Status: model.AccountActive}, wantErr: apperror.ErrImpersonationNotAllowed},
+		{name: "admin account", impersonator: impersonatorID, account: model.Account{RoleID: constant.AdminRoleID, Status: model.AccountActive}, wantErr: apperror.ErrImpersonationNotAllowed},
+		{name: "deactivated account", impersonator: impersonatorID, account: model.Account{RoleID: constant.UserRoleID, Status: model.AccountDeactivated}, wantErr: apperror.ErrAccountDeactivated},
+		{name: "suspended account", impersonator: impersonatorID, account: model.Account{RoleID: constant.UserRoleID, Status: model.AccountSuspended}, wantErr: apperror.ErrAccountSuspended},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeSessionRepository{}
+			c := newImpersonationController(tt.account, sessions)
+
+			session, err := c.StartImpersonation(context.Background(), tt.impersonator, impersonatedUserID, "support ticket")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(sessions.sessions) != 0 {
+					t.Errorf("session is created for the rejected impersonation")
+				}
+				return
+			}
+			if session.ImpersonatorID == nil || *session.ImpersonatorID != tt.impersonator {
+				t.Errorf("impersonator = %v, want %d", session.ImpersonatorID, tt.impersonator)
+			}
+		})
+	}
+}
+
+func TestStartImpersonationFixedLifetime(t *testing.T) {
+	t.Setenv("IMPERSONATION_TTL", "10m")
+	c := newImpersonationController(model.Account{RoleID: constant.UserRoleID, Status: model.AccountActive}, &fakeSessionRepository{})
+
+	start := time.Now()
+	session, err := c.StartImpersonation(context.Background(), impersonatorID, impersonatedUserID, "")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	// The session isn't extended beyond its lifetime
+	if !session.MaxExpiredAt.Equal(session.ExpiredAt) {
+		t.Errorf("max expiration = %v, want the expiration %v", session.MaxExpiredAt, session.ExpiredAt)
+	}
+	if lifetime := session.MaxExpiredAt.Sub(start); lifetime < 10*time.Minute || lifetime > 10*time.Minute+time.Second {
+		t.Errorf("lifetime = %v, want 10m", lifetime)
+	}
+}
+
+func TestStopImpersonation(t *testing.T) {
+	impersonator := uint(impersonatorID)
+	sessions := &fakeSessionRepository{sessions: []model.Session{
+		{ID: 1, UserID: impersonatedUserID, Token: "own-token"},
+		{ID: 2, UserID: impersonatedUserID, Token: "impersonation-token", ImpersonatorID: &impersonator},
+	}}
+	c := newImpersonationController(model.Account{RoleID: constant.UserRoleID, Status: model.AccountActive}, sessions)
+
+	// The user's own session isn't ended as an impersonation
+	if err := c.StopImpersonation(context.Background(), impersonatedUserID, 1); !errors.Is(err, apperror.ErrNotImpersonating) {
+		t.Errorf("error = %v, want %v", err, apperror.ErrNotImpersonating)
+	}
+	if err := c.StopImpersonation(context.Background(), impersonatedUserID, 2); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(sessions.sessions) != 1 || sessions.sessions[0].ID != 1 {
+		t.Errorf("sessions = %+v, want only the user's own session", sessions.sessions)
+	}
+}
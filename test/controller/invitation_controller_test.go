@@ -0,0 +1,53 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+)
+
+func TestCreateInvitationRole(t *testing.T) {
+	// usersCaller manages the invitations without being an admin
+	usersCaller := &principal.Principal{Type: principal.TypeUser, ID: 3, Scopes: []string{constant.ScopeUsersWrite}}
+
+	tests := []struct {
+		name    string
+		caller  *principal.Principal
+		roleID  uint
+		wantErr error
+	}{
+		{name: "default role", caller: usersCaller},
+		{name: "user role", caller: usersCaller, roleID: constant.UserRoleID},
+		{name: "admin role by admin", caller: adminCaller, roleID: constant.AdminRoleID},
+		{name: "admin role by non-admin", caller: usersCaller, roleID: constant.AdminRoleID, wantErr: apperror.ErrRoleNotInvitable},
+		{name: "unknown role", caller: adminCaller, roleID: 99, wantErr: apperror.ErrRoleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invitations := &fakeInvitationRepository{}
+			c := controller.NewInvitationController(
+				invitations,
+				&fakeRoleRepository{roles: []model.Role{{ID: constant.UserRoleID}, {ID: constant.AdminRoleID}}},
+				&fakeAuditRepository{},
+			)
+
+			_, code, err := c.CreateInvitation(context.Background(), tt.caller, "", tt.roleID, 1, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(invitations.invitations) != 0 {
+				t.Errorf("invitation is created with the rejected role")
+			}
+			if tt.wantErr == nil && code == "" {
+				t.Errorf("no invitation code is returned")
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/pkg/helper/token"
+)
+
+const magicLinkUserID = 7
+
+func newMagicLinkController(links *fakeMagicLinkRepository, passkeys *fakePasskeyController) *controller.MagicLinkControllerImpl {
+	return newMagicLinkControllerWithDevices(links, passkeys, &fakeDeviceController{})
+}
+
+func newMagicLinkControllerWithDevices(links *fakeMagicLinkRepository, passkeys *fakePasskeyController, devices *fakeDeviceController) *controller.MagicLinkControllerImpl {
+	return controller.NewMagicLinkController(
+		&fakeLoginInfoRepository{users: []model.LoginInfo{{ID: magicLinkUserID, Username: "jane", Email: "jane@example.com"}}},
+		links,
+		&fakeSessionRepository{},
+		&fakeAccountRepository{accounts: []model.Account{{ID: magicLinkUserID, Status: model.AccountActive}}},
+		&fakeAccountDeletionRepository{},
+		&fakeAuditRepository{},
+		passkeys,
+		devices,
+	)
+}
+
+// newMagicLink stores the link sent to the user along with the nonce of the device
+func newMagicLink(links *fakeMagicLinkRepository, loginToken string, nonce string, expiredAt time.Time) {
+	links.CreateMagicLink(context.Background(), &model.MagicLink{
+		UserID:    magicLinkUserID,
+		TokenHash: token.HashToken(loginToken),
+		NonceHash: token.HashToken(nonce),
+		ExpiredAt: expiredAt,
+	})
+}
+
+func TestConsumeMagicLink(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+
+	tests := []struct {
+		name      string
+		expiredAt time.Time
+		nonce     string
+		wantErr   error
+	}{
+		{name: "valid link", expiredAt: time.Now().Add(time.Minute), nonce: "device-nonce"},
+		{name: "nonce of another device", expiredAt: time.Now().Add(time.Minute), nonce: "other-nonce", wantErr: apperror.ErrInvalidMagicLink},
+		{name: "expired link", expiredAt: time.Now().Add(-time.Second), nonce: "device-nonce", wantErr: apperror.ErrInvalidMagicLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := &fakeMagicLinkRepository{}
+			newMagicLink(links, "login-token", "device-nonce", tt.expiredAt)
+
+			result, err := newMagicLinkController(links, &fakePasskeyController{}).ConsumeMagicLink(context.Background(), "login-token", tt.nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && result.Session.UserID != magicLinkUserID {
+				t.Errorf("session user = %d, want %d", result.Session.UserID, magicLinkUserID)
+			}
+		})
+	}
+}
+
+func TestConsumeMagicLinkSingleUse(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+	links := &fakeMagicLinkRepository{}
+	newMagicLink(links, "login-token", "device-nonce", time.Now().Add(time.Minute))
+	c := newMagicLinkController(links, &fakePasskeyController{})
+
+	if _, err := c.ConsumeMagicLink(context.Background(), "login-token", "device-nonce"); err != nil {
+		t.Fatalf("first use error = %v", err)
+	}
+	if _, err := c.ConsumeMagicLink(context.Background(), "login-token", "device-nonce"); !errors.Is(err, apperror.ErrInvalidMagicLink) {
+		t.Errorf("second use error = %v, want %v", err, apperror.ErrInvalidMagicLink)
+	}
+}
+
+func TestConsumeMagicLinkRequiresPasskey(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+	links := &fakeMagicLinkRepository{}
+	newMagicLink(links, "login-token", "device-nonce", time.Now().Add(time.Minute))
+	passkeys := &fakePasskeyController{users: map[uint]bool{magicLinkUserID: true}}
+
+	// The user having a passkey asserts it as the second factor before the session is created
+	result, err := newMagicLinkController(links, passkeys).ConsumeMagicLink(context.Background(), "login-token", "device-nonce")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if result.Session != nil || result.MFAOptions == nil {
+		t.Errorf("result = %+v, want the second factor options without session", result)
+	}
+	if want := []string{model.LoginMethodMagicLink}; !slices.Equal(passkeys.firstFactors, want) {
+		t.Errorf("first factors = %v, want %v", passkeys.firstFactors, want)
+	}
+}
+
+func TestConsumeMagicLinkRecognizesDevice(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+
+	tests := []struct {
+		name    string
+		passkey bool
+		want    []uint
+	}{
+		{name: "session created", want: []uint{magicLinkUserID}},
+		// The device is recognized once the second factor creates the session
+		{name: "second factor pending", passkey: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := &fakeMagicLinkRepository{}
+			newMagicLink(links, "login-token", "device-nonce", time.Now().Add(time.Minute))
+			passkeys := &fakePasskeyController{users: map[uint]bool{magicLinkUserID: tt.passkey}}
+			devices := &fakeDeviceController{}
+
+			if _, err := newMagicLinkControllerWithDevices(links, passkeys, devices).ConsumeMagicLink(context.Background(), "login-token", "device-nonce"); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !slices.Equal(devices.recognized, tt.want) {
+				t.Errorf("recognized users = %v, want %v", devices.recognized, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestMagicLinkRateLimit(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+	links := &fakeMagicLinkRepository{}
+	c := newMagicLinkController(links, &fakePasskeyController{})
+
+	// The request over the limit looks the same as the accepted one
+	for i := 0; i <= controller.MaxMagicLinksPerTTL; i++ {
+		nonce, err := c.RequestMagicLink(context.Background(), "jane@example.com")
+		if err != nil || nonce == "" {
+			t.Fatalf("request %d: nonce = %q, error = %v", i+1, nonce, err)
+		}
+	}
+	if len(links.links) != controller.MaxMagicLinksPerTTL {
+		t.Errorf("links sent = %d, want %d", len(links.links), controller.MaxMagicLinksPerTTL)
+	}
+}
+
+func TestRequestMagicLinkUnregisteredEmail(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "true")
+	links := &fakeMagicLinkRepository{}
+
+	nonce, err := newMagicLinkController(links, &fakePasskeyController{}).RequestMagicLink(context.Background(), "john@example.com")
+	if err != nil || nonce == "" {
+		t.Fatalf("nonce = %q, error = %v", nonce, err)
+	}
+	if len(links.links) != 0 {
+		t.Errorf("links sent = %d, want 0", len(links.links))
+	}
+}
+
+func TestMagicLinkDisabled(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "false")
+	links := &fakeMagicLinkRepository{}
+	newMagicLink(links, "login-token", "device-nonce", time.Now().Add(time.Minute))
+	c := newMagicLinkController(links, &fakePasskeyController{})
+
+	if _, err := c.RequestMagicLink(context.Background(), "jane@example.com"); !errors.Is(err, apperror.ErrMagicLinkDisabled) {
+		t.Errorf("request error = %v, want %v", err, apperror.ErrMagicLinkDisabled)
+	}
+	if _, err := c.ConsumeMagicLink(context.Background(), "login-token", "device-nonce"); !errors.Is(err, apperror.ErrMagicLinkDisabled) {
+		t.Errorf("consume error = %v, want %v", err, apperror.ErrMagicLinkDisabled)
+	}
+}
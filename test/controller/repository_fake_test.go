@@ -0,0 +1,364 @@
+package controller_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/repository"
+	"gorm.io/gorm"
+)
+
+// The fakes keep the records in memory, the methods a test doesn't expect to be
+// called panic through the embedded nil interface
+
+type fakeLoginInfoRepository struct {
+	repository.LoginInfoRepository
+	users []model.LoginInfo
+}
+
+func (r *fakeLoginInfoRepository) FindLoginInfo(_ context.Context, info *model.LoginInfo) error {
+	for _, user := range r.users {
+		if (info.ID == 0 || info.ID == user.ID) && (info.Email == "" || info.Email == user.Email) {
+			*info = user
+			return nil
+		}
+	}
+	return apperror.ErrNotFound
+}
+
+type fakeMagicLinkRepository struct {
+	mu    sync.Mutex
+	links []model.MagicLink
+}
+
+func (r *fakeMagicLinkRepository) CreateMagicLink(_ context.Context, link *model.MagicLink) (model.MagicLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link.ID = uint(len(r.links) + 1)
+	link.CreatedAt = time.Now()
+	r.links = append(r.links, *link)
+	return *link, nil
+}
+
+func (r *fakeMagicLinkRepository) CountRecentMagicLinks(_ context.Context, userID uint, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, link := range r.links {
+		if link.UserID == userID && link.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeMagicLinkRepository) ConsumeMagicLink(_ context.Context, tokenHash string, nonceHash string) (model.MagicLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for i := range r.links {
+		if r.links[i].TokenHash != tokenHash {
+			continue
+		}
+		if !r.links[i].IsUsable(nonceHash, now) {
+			break
+		}
+		r.links[i].ConsumedAt = &now
+		return r.links[i], nil
+	}
+	return model.MagicLink{}, apperror.ErrNotFound
+}
+
+type fakeSessionRepository struct {
+	repository.SessionRepository
+	sessions []model.Session
+}
+
+func (r *fakeSessionRepository) FindActiveSession(_ context.Context, userID uint) (*model.Session, error) {
+	for i := range r.sessions {
+		if r.sessions[i].UserID == userID && !r.sessions[i].RememberMe && r.sessions[i].ImpersonatorID == nil {
+			return &r.sessions[i], nil
+		}
+	}
+	return nil, apperror.ErrNotFound
+}
+
+func (r *fakeSessionRepository) CreateSession(_ context.Context, session *model.Session) (model.Session, error) {
+	session.ID = uint(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, *session)
+	return *session, nil
+}
+
+func (r *fakeSessionRepository) FindUserSession(_ context.Context, userID uint, sessionID uint) (*model.Session, error) {
+	for i := range r.sessions {
+		if r.sessions[i].UserID == userID && r.sessions[i].ID == sessionID {
+			return &r.sessions[i], nil
+		}
+	}
+	return nil, apperror.ErrNotFound
+}
+
+func (r *fakeSessionRepository) DeleteSessionByToken(_ context.Context, authToken string) error {
+	for i := range r.sessions {
+		if r.sessions[i].Token == authToken {
+			r.sessions = append(r.sessions[:i], r.sessions[i+1:]...)
+			return nil
+		}
+	}
+	return apperror.ErrNotFound
+}
+
+func (r *fakeSessionRepository) DeleteSessionsByUser(_ context.Context, userID uint) (int64, error) {
+	var count int64
+	kept := r.sessions[:0]
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			count++
+			continue
+		}
+		kept = append(kept, session)
+	}
+	r.sessions = kept
+	return count, nil
+}
+
+func (r *fakeSessionRepository) CountActiveSessions(_ context.Context) (int64, error) {
+	return int64(len(r.sessions)), nil
+}
+
+type fakeAccountRepository struct {
+	repository.AccountRepository
+	accounts []model.Account
+	db       *gorm.DB
+}
+
+func (r *fakeAccountRepository) FindAccountByUserID(_ context.Context, userID uint) (model.Account, error) {
+	for _, account := range r.accounts {
+		if account.ID == userID {
+			return account, nil
+		}
+	}
+	return model.Account{}, nil
+}
+
+// BeginTransaction begins the transaction of the fake database, the records created
+// within the transaction aren't kept
+func (r *fakeAccountRepository) BeginTransaction(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Begin()
+}
+
+func (r *fakeAccountRepository) UpdateAccountStatus(_ context.Context, account *model.Account, fromStatuses []string) (bool, error) {
+	for i := range r.accounts {
+		if r.accounts[i].ID == account.ID && slices.Contains(fromStatuses, r.accounts[i].Status) {
+			r.accounts[i] = *account
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeAccountDeletionRepository struct {
+	repository.AccountDeletionRepository
+}
+
+func (r *fakeAccountDeletionRepository) CancelAccountDeletion(_ context.Context, userID uint) (bool, error) {
+	return false, nil
+}
+
+type fakeAuditRepository struct {
+	repository.AuditRepository
+	events []model.AuditEvent
+}
+
+func (r *fakeAuditRepository) CreateAuditEvent(_ context.Context, event *model.AuditEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+type fakeExternalLoginRepository struct {
+	repository.ExternalLoginRepository
+	states []model.ExternalLoginState
+	logins []model.LoginExternal
+}
+
+func (r *fakeExternalLoginRepository) FindOrCreateProvider(_ context.Context, name string, url string) (model.ExternalProvider, error) {
+	return model.ExternalProvider{ID: 1, Name: name, Url: url}, nil
+}
+
+func (r *fakeExternalLoginRepository) CreateLoginState(_ context.Context, state *model.ExternalLoginState) error {
+	r.states = append(r.states, *state)
+	return nil
+}
+
+func (r *fakeExternalLoginRepository) ConsumeLoginState(_ context.Context, state string) (model.ExternalLoginState, error) {
+	for i, stored := range r.states {
+		if stored.State == state {
+			r.states = append(r.states[:i], r.states[i+1:]...)
+			return stored, nil
+		}
+	}
+	return model.ExternalLoginState{}, apperror.ErrNotFound
+}
+
+func (r *fakeExternalLoginRepository) FindLoginExternal(_ context.Context, providerID uint, subject string) (model.LoginExternal, error) {
+	for _, login := range r.logins {
+		if login.ProviderID == providerID && login.Subject == subject {
+			return login, nil
+		}
+	}
+	return model.LoginExternal{}, apperror.ErrNotFound
+}
+
+// fakePasskeyController starts the second factor of the users having a passkey
+type fakePasskeyController struct {
+	controller.PasskeyController
+	users map[uint]bool
+	// firstFactors holds the first factor login method of the started second factors
+	firstFactors []string
+}
+
+func (c *fakePasskeyController) BeginMFA(_ context.Context, userID uint, _ model.SessionOptions, firstFactor string) ([]byte, error) {
+	if !c.users[userID] {
+		return nil, nil
+	}
+	c.firstFactors = append(c.firstFactors, firstFactor)
+	return []byte(`{"publicKey":{}}`), nil
+}
+
+// fakeDeviceController records the users whose login device is recognized
+type fakeDeviceController struct {
+	controller.DeviceController
+	recognized []uint
+}
+
+func (c *fakeDeviceController) RecognizeDevice(_ context.Context, credential *model.LoginInfo) error {
+	c.recognized = append(c.recognized, credential.ID)
+	return nil
+}
+
+type fakeEventRepository struct {
+	events []model.OutboxEvent
+}
+
+func (r *fakeEventRepository) FindPendingEvents(_ context.Context, limit int) ([]model.OutboxEvent, error) {
+	var pending []model.OutboxEvent
+	for _, event := range r.events {
+		if event.PublishedAt == nil && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeEventRepository) MarkEventPublished(_ context.Context, eventID uint, publishedAt time.Time) error {
+	for i := range r.events {
+		if r.events[i].ID == eventID {
+			r.events[i].PublishedAt = &publishedAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeEventRepository) MarkEventFailed(_ context.Context, eventID uint, reason string) error {
+	for i := range r.events {
+		if r.events[i].ID == eventID {
+			r.events[i].Attempts++
+			r.events[i].LastError = reason
+		}
+	}
+	return nil
+}
+
+type fakeServiceAccountRepository struct {
+	repository.ServiceAccountRepository
+	accounts []model.ServiceAccount
+	// rotated holds the keys issued by the rotation
+	rotated []model.APIKey
+}
+
+func (r *fakeServiceAccountRepository) CreateServiceAccount(_ context.Context, account *model.ServiceAccount) (model.ServiceAccount, error) {
+	account.ID = uint(len(r.accounts) + 1)
+	r.accounts = append(r.accounts, *account)
+	return *account, nil
+}
+
+func (r *fakeServiceAccountRepository) FindServiceAccountByID(_ context.Context, serviceAccountID uint) (model.ServiceAccount, error) {
+	for _, account := range r.accounts {
+		if account.ID == serviceAccountID {
+			return account, nil
+		}
+	}
+	return model.ServiceAccount{}, apperror.ErrNotFound
+}
+
+func (r *fakeServiceAccountRepository) RotateAPIKey(_ context.Context, key *model.APIKey, overlapUntil time.Time) (model.APIKey, error) {
+	r.rotated = append(r.rotated, *key)
+	return *key, nil
+}
+
+// fakeUserDirectoryRepository lists the users ordered from the latest registered
+type fakeUserDirectoryRepository struct {
+	users []model.UserSummary
+	// filters holds the filters the users are found with
+	filters []repository.UserFilter
+}
+
+func (r *fakeUserDirectoryRepository) FindUsers(_ context.Context, filter repository.UserFilter) ([]model.UserSummary, error) {
+	r.filters = append(r.filters, filter)
+	var users []model.UserSummary
+	for _, user := range r.users {
+		if (filter.AfterID == 0 || user.ID < filter.AfterID) && len(users) < filter.Limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+type fakeRoleRepository struct {
+	repository.RoleRepository
+	roles []model.Role
+}
+
+func (r *fakeRoleRepository) FindRoleByID(_ context.Context, roleID uint) (model.Role, error) {
+	for _, role := range r.roles {
+		if role.ID == roleID {
+			return role, nil
+		}
+	}
+	return model.Role{}, apperror.ErrNotFound
+}
+
+type fakeInvitationRepository struct {
+	repository.InvitationRepository
+	invitations []model.Invitation
+}
+
+func (r *fakeInvitationRepository) CreateInvitation(_ context.Context, invitation *model.Invitation) error {
+	invitation.ID = uint(len(r.invitations) + 1)
+	r.invitations = append(r.invitations, *invitation)
+	return nil
+}
+
+func (r *fakeInvitationRepository) ConsumeInvitation(_ context.Context, _ *gorm.DB, codeHash string, email string) (*model.Invitation, error) {
+	for i := range r.invitations {
+		if r.invitations[i].CodeHash == codeHash && r.invitations[i].IsUsableBy(email, time.Now()) {
+			r.invitations[i].Uses++
+			invitation := r.invitations[i]
+			return &invitation, nil
+		}
+	}
+	return nil, apperror.ErrInvalidInvitation
+}
+
+type fakeEmailVerificationRepository struct {
+	repository.EmailVerificationRepository
+}
+
+func (r *fakeEmailVerificationRepository) UpdateEmailVerification(_ context.Context, verification *model.EmailVerification) (model.EmailVerification, error) {
+	return *verification, nil
+}
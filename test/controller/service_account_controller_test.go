@@ -0,0 +1,129 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+)
+
+var (
+	adminCaller = &principal.Principal{Type: principal.TypeUser, ID: 1, Scopes: []string{constant.ScopeAll}}
+
+	// serviceAccountsCaller is the API key only managing the service accounts
+	serviceAccountsCaller = &principal.Principal{
+		Type:   principal.TypeServiceAccount,
+		ID:     2,
+		Scopes: []string{constant.ScopeServiceAccountsRead, constant.ScopeServiceAccountsWrite},
+	}
+)
+
+func TestCreateServiceAccountScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		caller  *principal.Principal
+		scopes  []string
+		wantErr error
+	}{
+		{name: "admin grants any grantable scope", caller: adminCaller, scopes: []string{constant.ScopeUsersWrite, constant.ScopeAuditRead}},
+		{name: "scope held by the caller", caller: serviceAccountsCaller, scopes: []string{constant.ScopeServiceAccountsRead}},
+		{name: "scope not held by the caller", caller: serviceAccountsCaller, scopes: []string{constant.ScopeUsersWrite}, wantErr: apperror.ErrScopeNotGrantable},
+		{name: "scope not grantable", caller: adminCaller, scopes: []string{constant.ScopeUsersImpersonate}, wantErr: apperror.ErrScopeNotGrantable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &fakeServiceAccountRepository{}
+			c := controller.NewServiceAccountController(accounts)
+
+			_, apiKey, err := c.CreateServiceAccount(context.Background(), tt.caller, "reporting", "", tt.scopes, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(accounts.accounts) != 0 {
+				t.Errorf("service account is created with the rejected scopes")
+			}
+			if tt.wantErr == nil && apiKey == "" {
+				t.Errorf("no API key is issued")
+			}
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		caller     *principal.Principal
+		keys       []model.APIKey
+		scopes     []string
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name:   "inherits the newest active key",
+			caller: adminCaller,
+			keys: []model.APIKey{
+				{Scopes: []string{constant.ScopeAuditRead}, ExpiredAt: &expired},
+				{Scopes: []string{constant.ScopeUsersRead}},
+				{Scopes: []string{constant.ScopeUsersWrite}},
+			},
+			wantScopes: []string{constant.ScopeUsersRead},
+		},
+		{
+			name:   "requested scopes",
+			caller: adminCaller,
+			keys:   []model.APIKey{{Scopes: []string{constant.ScopeUsersRead}}},
+			scopes: []string{constant.ScopeAuditRead}, wantScopes: []string{constant.ScopeAuditRead},
+		},
+		{
+			name:    "no active key to inherit",
+			caller:  adminCaller,
+			keys:    []model.APIKey{{Scopes: []string{constant.ScopeUsersRead}, ExpiredAt: &expired}},
+			wantErr: apperror.ErrNoActiveAPIKey,
+		},
+		{
+			name:    "account with the scopes the caller doesn't have",
+			caller:  serviceAccountsCaller,
+			keys:    []model.APIKey{{Scopes: []string{constant.ScopeUsersWrite, constant.ScopeAuditRead}}},
+			wantErr: apperror.ErrInsufficientScope,
+		},
+		{
+			name:    "requested scope the caller doesn't have",
+			caller:  serviceAccountsCaller,
+			keys:    []model.APIKey{{Scopes: []string{constant.ScopeServiceAccountsRead}}},
+			scopes:  []string{constant.ScopeUsersWrite},
+			wantErr: apperror.ErrScopeNotGrantable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &fakeServiceAccountRepository{accounts: []model.ServiceAccount{{ID: 1, APIKeys: tt.keys}}}
+			c := controller.NewServiceAccountController(accounts)
+
+			key, _, err := c.RotateAPIKey(context.Background(), tt.caller, 1, tt.scopes, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(accounts.rotated) != 0 {
+					t.Errorf("key is rotated on the rejected rotation")
+				}
+				return
+			}
+			if !slices.Equal(key.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", key.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/controller"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/model"
+	"github.com/budgetin-app/user-service/app/repository"
+)
+
+// newUserDirectory stores the users with the ids from the count down to 1
+func newUserDirectory(count int) *fakeUserDirectoryRepository {
+	directory := &fakeUserDirectoryRepository{}
+	for id := count; id > 0; id-- {
+		directory.users = append(directory.users, model.UserSummary{ID: uint(id)})
+	}
+	return directory
+}
+
+func TestListUsersPagination(t *testing.T) {
+	directory := newUserDirectory(5)
+	c := controller.NewUserDirectoryController(directory)
+
+	users, nextPageToken, err := c.ListUsers(context.Background(), repository.UserFilter{}, 2, "")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	// One more user is found to know whether there is a next page
+	if limit := directory.filters[0].Limit; limit != 3 {
+		t.Errorf("limit = %d, want 3", limit)
+	}
+	if len(users) != 2 || nextPageToken != "4" {
+		t.Fatalf("users = %d, next page token = %q, want 2 users and %q", len(users), nextPageToken, "4")
+	}
+
+	users, nextPageToken, err = c.ListUsers(context.Background(), repository.UserFilter{}, 2, nextPageToken)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if afterID := directory.filters[1].AfterID; afterID != 4 {
+		t.Errorf("after id = %d, want 4", afterID)
+	}
+	if len(users) != 2 || users[0].ID != 3 || nextPageToken != "2" {
+		t.Fatalf("users = %+v, next page token = %q, want users 3 and 2 with %q", users, nextPageToken, "2")
+	}
+
+	// The last page has no next page token
+	users, nextPageToken, err = c.ListUsers(context.Background(), repository.UserFilter{}, 2, nextPageToken)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(users) != 1 || nextPageToken != "" {
+		t.Errorf("users = %d, next page token = %q, want the last user without token", len(users), nextPageToken)
+	}
+}
+
+func TestListUsersExactPage(t *testing.T) {
+	c := controller.NewUserDirectoryController(newUserDirectory(2))
+
+	// The page filled up by the last users has no next page
+	users, nextPageToken, err := c.ListUsers(context.Background(), repository.UserFilter{}, 2, "")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(users) != 2 || nextPageToken != "" {
+		t.Errorf("users = %d, next page token = %q, want 2 users without token", len(users), nextPageToken)
+	}
+}
+
+func TestListUsersPageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageSize  int
+		wantLimit int
+	}{
+		{name: "default page size", pageSize: 0, wantLimit: controller.DefaultUserPageSize + 1},
+		{name: "negative page size", pageSize: -1, wantLimit: controller.DefaultUserPageSize + 1},
+		{name: "page size over the maximum", pageSize: controller.MaxUserPageSize + 1, wantLimit: controller.MaxUserPageSize + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := newUserDirectory(controller.MaxUserPageSize + 10)
+			c := controller.NewUserDirectoryController(directory)
+
+			users, _, err := c.SearchUsers(context.Background(), repository.UserFilter{Query: "jane"}, tt.pageSize, "")
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if limit := directory.filters[0].Limit; limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", limit, tt.wantLimit)
+			}
+			if len(users) != tt.wantLimit-1 {
+				t.Errorf("users = %d, want %d", len(users), tt.wantLimit-1)
+			}
+		})
+	}
+}
+
+func TestListUsersInvalidPageToken(t *testing.T) {
+	for _, pageToken := range []string{"0", "abc", "-1", "1.5"} {
+		t.Run(pageToken, func(t *testing.T) {
+			directory := newUserDirectory(3)
+			c := controller.NewUserDirectoryController(directory)
+
+			if _, _, err := c.ListUsers(context.Background(), repository.UserFilter{}, 2, pageToken); !errors.Is(err, apperror.ErrInvalidPageToken) {
+				t.Errorf("error = %v, want %v", err, apperror.ErrInvalidPageToken)
+			}
+			if len(directory.filters) != 0 {
+				t.Errorf("users are found with the invalid page token")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+)
+
+func TestAccountCanLogin(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "", want: true},
+		{status: model.AccountActive, want: true},
+		{status: model.AccountPendingDeletion, want: true},
+		{status: model.AccountSuspended, want: false},
+		{status: model.AccountDeactivated, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			account := model.Account{Status: tt.status}
+			if got := account.CanLogin(); got != tt.want {
+				t.Errorf("CanLogin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
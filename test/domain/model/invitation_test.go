@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+)
+
+func TestInvitationStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		invitation model.Invitation
+		want       string
+	}{
+		{name: "active", invitation: model.Invitation{MaxUses: 2, Uses: 1, ExpiredAt: now.Add(time.Hour)}, want: model.InvitationActive},
+		{name: "used up", invitation: model.Invitation{MaxUses: 2, Uses: 2, ExpiredAt: now.Add(time.Hour)}, want: model.InvitationUsedUp},
+		{name: "expired", invitation: model.Invitation{MaxUses: 1, ExpiredAt: now}, want: model.InvitationExpired},
+		{name: "revoked", invitation: model.Invitation{MaxUses: 1, ExpiredAt: now.Add(time.Hour), RevokedAt: &revokedAt}, want: model.InvitationRevoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invitation.Status(now); got != tt.want {
+				t.Errorf("Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvitationIsUsableBy(t *testing.T) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		invitation model.Invitation
+		email      string
+		want       bool
+	}{
+		{name: "open invitation", invitation: model.Invitation{MaxUses: 1, ExpiredAt: now.Add(time.Hour)}, email: "jane@example.com", want: true},
+		{name: "invitation of the email", invitation: model.Invitation{Email: "Jane@Example.com", MaxUses: 1, ExpiredAt: now.Add(time.Hour)}, email: "jane@example.com", want: true},
+		{name: "invitation of another email", invitation: model.Invitation{Email: "john@example.com", MaxUses: 1, ExpiredAt: now.Add(time.Hour)}, email: "jane@example.com"},
+		{name: "expired", invitation: model.Invitation{MaxUses: 1, ExpiredAt: now}, email: "jane@example.com"},
+		{name: "used up", invitation: model.Invitation{MaxUses: 1, Uses: 1, ExpiredAt: now.Add(time.Hour)}, email: "jane@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invitation.IsUsableBy(tt.email, now); got != tt.want {
+				t.Errorf("IsUsableBy(%q) = %v, want %v", tt.email, got, tt.want)
+			}
+		})
+	}
+}
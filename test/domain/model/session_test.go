@@ -0,0 +1,52 @@
+package model_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/domain/model"
+)
+
+func TestSessionExtend(t *testing.T) {
+	created := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	session := model.Session{MaxExpiredAt: created.Add(3 * time.Hour)}
+
+	tests := []struct {
+		name   string
+		seenAt time.Time
+		want   time.Time
+	}{
+		{name: "slides with the use", seenAt: created.Add(30 * time.Minute), want: created.Add(90 * time.Minute)},
+		{name: "slides again", seenAt: created.Add(80 * time.Minute), want: created.Add(140 * time.Minute)},
+		{name: "capped at the max lifetime", seenAt: created.Add(150 * time.Minute), want: created.Add(3 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session.Extend(tt.seenAt, time.Hour)
+			if !session.ExpiredAt.Equal(tt.want) {
+				t.Errorf("expiration = %v, want %v", session.ExpiredAt, tt.want)
+			}
+			if !session.LastSeenAt.Equal(tt.seenAt) {
+				t.Errorf("last seen = %v, want %v", session.LastSeenAt, tt.seenAt)
+			}
+		})
+	}
+}
+
+func TestSessionReauthenticate(t *testing.T) {
+	login := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	session := model.Session{AuthenticatedAt: login, AuthMethods: []string{model.LoginMethodPassword}}
+
+	reauth := login.Add(2 * time.Hour)
+	session.Reauthenticate(reauth, model.LoginMethodPasskey)
+	session.Reauthenticate(reauth, model.LoginMethodPassword)
+
+	if !session.AuthenticatedAt.Equal(reauth) {
+		t.Errorf("authenticated at = %v, want %v", session.AuthenticatedAt, reauth)
+	}
+	if want := []string{model.LoginMethodPassword, model.LoginMethodPasskey}; !slices.Equal(session.AuthMethods, want) {
+		t.Errorf("auth methods = %v, want %v", session.AuthMethods, want)
+	}
+}
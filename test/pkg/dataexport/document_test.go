@@ -0,0 +1,85 @@
+package dataexport_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/dataexport"
+)
+
+func TestEncode(t *testing.T) {
+	document := &dataexport.Document{
+		SchemaVersion: dataexport.SchemaVersion,
+		GeneratedAt:   time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+		Account:       dataexport.Account{UserID: 1},
+		Login:         &dataexport.Login{Username: "john", Email: "john@example.com"},
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		unpack      func(t *testing.T, content []byte) []byte
+	}{
+		{
+			name:        "json",
+			format:      dataexport.FormatJSON,
+			contentType: "application/json",
+			unpack:      func(t *testing.T, content []byte) []byte { return content },
+		},
+		{
+			name:        "zip",
+			format:      dataexport.FormatZip,
+			contentType: "application/zip",
+			unpack: func(t *testing.T, content []byte) []byte {
+				archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+				if err != nil {
+					t.Fatalf("failed to read archive: %v", err)
+				}
+				if len(archive.File) != 1 || archive.File[0].Name != dataexport.DocumentFileName {
+					t.Fatalf("archive should only contain %s", dataexport.DocumentFileName)
+				}
+				file, err := archive.File[0].Open()
+				if err != nil {
+					t.Fatalf("failed to open archive file: %v", err)
+				}
+				defer file.Close()
+				data, err := io.ReadAll(file)
+				if err != nil {
+					t.Fatalf("failed to read archive file: %v", err)
+				}
+				return data
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, _, contentType, err := dataexport.Encode(document, tt.format)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("content type = %s, want %s", contentType, tt.contentType)
+			}
+
+			var decoded dataexport.Document
+			if err := json.Unmarshal(tt.unpack(t, content), &decoded); err != nil {
+				t.Fatalf("failed to unmarshal document: %v", err)
+			}
+			if decoded.SchemaVersion != dataexport.SchemaVersion || decoded.Login.Email != "john@example.com" {
+				t.Errorf("decoded document = %+v, want %+v", decoded, document)
+			}
+		})
+	}
+}
+
+func TestEncodeUnsupportedFormat(t *testing.T) {
+	if _, _, _, err := dataexport.Encode(&dataexport.Document{}, "xml"); err == nil {
+		t.Error("Encode should fail with the unsupported format")
+	}
+}
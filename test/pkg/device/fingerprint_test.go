@@ -0,0 +1,47 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/pkg/device"
+)
+
+const userAgent = "Mozilla/5.0 (X11; Linux x86_64) Firefox/118.0"
+
+func TestIPPrefix(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "203.0.113.45", want: "203.0.113.0/24"},
+		{ip: "::ffff:203.0.113.45", want: "203.0.113.0/24"},
+		{ip: "2001:db8:abcd:12::1", want: "2001:db8:abcd::/48"},
+		{ip: "unknown", want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := device.IPPrefix(tt.ip); got != tt.want {
+				t.Errorf("IPPrefix(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := device.Fingerprint(userAgent, "203.0.113.45")
+	if len(fingerprint) != 64 {
+		t.Fatalf("fingerprint length = %d, want 64", len(fingerprint))
+	}
+
+	// The address change within the same network keeps the device known
+	if got := device.Fingerprint(userAgent, "203.0.113.200"); got != fingerprint {
+		t.Errorf("fingerprint of the same network = %s, want %s", got, fingerprint)
+	}
+	if got := device.Fingerprint(userAgent, "198.51.100.45"); got == fingerprint {
+		t.Error("fingerprint of another network matches")
+	}
+	if got := device.Fingerprint("curl/8.0.1", "203.0.113.45"); got == fingerprint {
+		t.Error("fingerprint of another user agent matches")
+	}
+}
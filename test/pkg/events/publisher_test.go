@@ -0,0 +1,51 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/events"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	secret := "webhook-secret"
+	event := events.Event{ID: "event-1", Type: "user.deleted", OccurredAt: time.Now().UTC(), Data: map[string]string{"user_id": "1"}}
+
+	var received events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(events.SignatureHeader), events.Sign([]byte(secret), body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("failed to unmarshal event: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	publisher := events.NewWebhookPublisher(server.URL, secret, time.Second)
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if received.ID != event.ID || received.Type != event.Type || received.Data["user_id"] != "1" {
+		t.Errorf("received event = %+v, want %+v", received, event)
+	}
+}
+
+func TestWebhookPublisherFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	publisher := events.NewWebhookPublisher(server.URL, "", time.Second)
+	if err := publisher.Publish(context.Background(), events.Event{ID: "event-1"}); err == nil {
+		t.Error("Publish should fail when the webhook doesn't accept the event")
+	}
+}
@@ -0,0 +1,214 @@
+package identity_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/identity"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	clientID     = "user-service"
+	clientSecret = "client-secret"
+	redirectURL  = "http://localhost:8081/v1/auth/external/fake/callback"
+)
+
+// fakeProvider is the in-process OIDC provider issuing the ID tokens signed with its own key
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu sync.Mutex
+	// codes holds the code challenge and nonce of the issued authorization codes
+	codes map[string]authorizationRequest
+	// audience overrides the audience of the issued ID tokens
+	audience string
+}
+
+type authorizationRequest struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	p := &fakeProvider{key: key, codes: make(map[string]authorizationRequest), audience: clientID}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *fakeProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &p.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+	}})
+}
+
+// authorize simulates the user consent, the authorization code is issued for the URL
+func (p *fakeProvider) authorize(t *testing.T, authorizationURL string) (code string, state string) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected the S256 code challenge, got %q", query.Get("code_challenge_method"))
+	}
+
+	code = "code-" + query.Get("state")
+	p.mu.Lock()
+	p.codes[code] = authorizationRequest{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	p.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	p.mu.Lock()
+	request, ok := p.codes[r.Form.Get("code")]
+	delete(p.codes, r.Form.Get("code"))
+	audience := p.audience
+	p.mu.Unlock()
+
+	// The code verifier should match the challenge of the authorization request
+	challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != request.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	idToken, _ := jwt.Signed(signer).Claims(map[string]interface{}{
+		"iss":            p.server.URL,
+		"sub":            "fake-subject",
+		"aud":            audience,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          request.nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "Fake User",
+	}).CompactSerialize()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *fakeProvider) setAudience(audience string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.audience = audience
+}
+
+func (p *fakeProvider) config() identity.ProviderConfig {
+	return identity.ProviderConfig{
+		Name:         "fake",
+		Type:         identity.TypeOIDC,
+		Issuer:       p.server.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	fake := newFakeProvider(t)
+	registry := identity.NewRegistry([]identity.ProviderConfig{fake.config()})
+	provider, ok := registry.Provider("fake")
+	if !ok {
+		t.Fatal("provider is not registered")
+	}
+
+	ctx := context.Background()
+	authorization, err := provider.Authorize(ctx)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	code, state := fake.authorize(t, authorization.URL)
+	if state != authorization.State {
+		t.Errorf("Authorize failed: expected state %q, got %q", authorization.State, state)
+	}
+
+	externalIdentity, err := provider.Exchange(ctx, code, authorization.Verifier, authorization.Nonce)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if externalIdentity.Subject != "fake-subject" || externalIdentity.Email != "user@example.com" || !externalIdentity.EmailVerified {
+		t.Errorf("Exchange failed: unexpected identity %+v", externalIdentity)
+	}
+}
+
+func TestProviderRejectsInvalidExchange(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := identity.NewProvider(fake.config())
+	ctx := context.Background()
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		authorization, _ := provider.Authorize(ctx)
+		code, _ := fake.authorize(t, authorization.URL)
+		if _, err := provider.Exchange(ctx, code, "wrong-verifier", authorization.Nonce); err == nil {
+			t.Error("Exchange should fail with the wrong code verifier")
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		authorization, _ := provider.Authorize(ctx)
+		code, _ := fake.authorize(t, authorization.URL)
+		_, err := provider.Exchange(ctx, code, authorization.Verifier, "other-nonce")
+		if !errors.Is(err, identity.ErrInvalidIDToken) {
+			t.Errorf("Exchange failed: expected %v, got %v", identity.ErrInvalidIDToken, err)
+		}
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		fake.setAudience("other-client")
+		defer fake.setAudience(clientID)
+
+		authorization, _ := provider.Authorize(ctx)
+		code, _ := fake.authorize(t, authorization.URL)
+		_, err := provider.Exchange(ctx, code, authorization.Verifier, authorization.Nonce)
+		if !errors.Is(err, identity.ErrInvalidIDToken) {
+			t.Errorf("Exchange failed: expected %v, got %v", identity.ErrInvalidIDToken, err)
+		}
+	})
+}
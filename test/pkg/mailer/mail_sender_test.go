@@ -0,0 +1,43 @@
+package mailer_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/mailer"
+)
+
+func TestCheckConnectionHonorsDeadline(t *testing.T) {
+	// The hung SMTP server accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	t.Setenv("SMTP_HOST", "127.0.0.1")
+	t.Setenv("SMTP_PORT", strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := mailer.CheckConnection(ctx); err == nil {
+		t.Fatal("expected the check of the hung server to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %v, want it bounded by the context deadline", elapsed)
+	}
+}
@@ -0,0 +1,318 @@
+package passkey_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/passkey"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	rpID   = "localhost"
+	origin = "http://localhost:3000"
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is the in-process authenticator holding a single ES256
+// discoverable credential, it signs the assertions with its own key
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("failed to generate credential id: %v", err)
+	}
+	return &softAuthenticator{key: key, credentialID: credentialID, origin: origin}
+}
+
+// options decodes the 'publicKey' options of the ceremony
+func options(t *testing.T, ceremony *passkey.Ceremony) map[string]interface{} {
+	var wrapper struct {
+		PublicKey map[string]interface{} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(ceremony.Options, &wrapper); err != nil {
+		t.Fatalf("failed to decode options: %v", err)
+	}
+	return wrapper.PublicKey
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremonyType string, challenge string) []byte {
+	clientData, err := json.Marshal(map[string]string{"type": ceremonyType, "challenge": challenge, "origin": a.origin})
+	if err != nil {
+		t.Fatalf("failed to encode client data: %v", err)
+	}
+	return clientData
+}
+
+// authenticatorData builds the authenticator data with the user present and verified
+// flags, the attested credential data is only appended for the registration
+func (a *softAuthenticator) authenticatorData(t *testing.T, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	flags := protocol.FlagUserPresent | protocol.FlagUserVerified
+	if attested {
+		flags |= protocol.FlagAttestedCredentialData
+	}
+
+	data := append(rpIDHash[:], byte(flags))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+
+	data = append(data, make([]byte, 16)...) // AAGUID
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	return append(data, publicKey...)
+}
+
+// create responds to the registration options with the 'none' attestation
+func (a *softAuthenticator) create(t *testing.T, ceremony *passkey.Ceremony) string {
+	publicKey := options(t, ceremony)
+	user := publicKey["user"].(map[string]interface{})
+	userHandle, err := b64.DecodeString(user["id"].(string))
+	if err != nil {
+		t.Fatalf("failed to decode user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(t, true),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode attestation object: %v", err)
+	}
+
+	return a.encode(t, map[string]string{
+		"clientDataJSON":    b64.EncodeToString(a.clientData(t, "webauthn.create", publicKey["challenge"].(string))),
+		"attestationObject": b64.EncodeToString(attestationObject),
+	})
+}
+
+// get responds to the assertion options with the signed assertion
+func (a *softAuthenticator) get(t *testing.T, ceremony *passkey.Ceremony) string {
+	a.signCount++
+	authData := a.authenticatorData(t, false)
+	clientData := a.clientData(t, "webauthn.get", options(t, ceremony)["challenge"].(string))
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign assertion: %v", err)
+	}
+
+	return a.encode(t, map[string]string{
+		"clientDataJSON":    b64.EncodeToString(clientData),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) encode(t *testing.T, response map[string]string) string {
+	encoded, err := json.Marshal(map[string]interface{}{
+		"id":       b64.EncodeToString(a.credentialID),
+		"rawId":    b64.EncodeToString(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode credential: %v", err)
+	}
+	return string(encoded)
+}
+
+func newRelyingParty(t *testing.T) *passkey.RelyingParty {
+	rp, err := passkey.NewRelyingParty(passkey.Config{
+		RPID:          rpID,
+		RPDisplayName: "Budgetin",
+		RPOrigins:     []string{origin},
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("failed to create relying party: %v", err)
+	}
+	return rp
+}
+
+// register runs the registration ceremony and returns the user with the new credential
+func register(t *testing.T, rp *passkey.RelyingParty, authenticator *softAuthenticator) *passkey.User {
+	user := &passkey.User{ID: 42, Name: "budi@example.com", DisplayName: "budi"}
+	ceremony, err := rp.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("failed to begin registration: %v", err)
+	}
+
+	response, err := passkey.ParseCreationResponse(authenticator.create(t, ceremony))
+	if err != nil {
+		t.Fatalf("failed to parse creation response: %v", err)
+	}
+	credential, err := rp.FinishRegistration(user, ceremony.Session, response)
+	if err != nil {
+		t.Fatalf("failed to finish registration: %v", err)
+	}
+
+	user.Credentials = append(user.Credentials, *credential)
+	return user
+}
+
+func TestPasskeyRegistrationAndDiscoverableLogin(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := newSoftAuthenticator(t)
+	user := register(t, rp, authenticator)
+
+	if got := b64.EncodeToString(user.Credentials[0].ID); got != b64.EncodeToString(authenticator.credentialID) {
+		t.Fatalf("credential id = %s, want %s", got, b64.EncodeToString(authenticator.credentialID))
+	}
+	if userID, ok := passkey.ParseUserHandle(authenticator.userHandle); !ok || userID != user.ID {
+		t.Fatalf("user handle resolves to %d, want %d", userID, user.ID)
+	}
+
+	ceremony, err := rp.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatalf("failed to begin login: %v", err)
+	}
+	response, err := passkey.ParseAssertionResponse(authenticator.get(t, ceremony))
+	if err != nil {
+		t.Fatalf("failed to parse assertion response: %v", err)
+	}
+
+	found, credential, err := rp.FinishDiscoverableLogin(func(userID uint) (*passkey.User, error) {
+		if userID != user.ID {
+			return nil, errors.New("user not found")
+		}
+		return user, nil
+	}, ceremony.Session, response)
+	if err != nil {
+		t.Fatalf("failed to finish login: %v", err)
+	}
+	if found.ID != user.ID {
+		t.Errorf("logged in user = %d, want %d", found.ID, user.ID)
+	}
+	if credential.Authenticator.SignCount != authenticator.signCount {
+		t.Errorf("sign count = %d, want %d", credential.Authenticator.SignCount, authenticator.signCount)
+	}
+}
+
+func TestPasskeySecondFactorLogin(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := newSoftAuthenticator(t)
+	user := register(t, rp, authenticator)
+
+	login := func() (*webauthn.Credential, error) {
+		ceremony, err := rp.BeginLogin(user)
+		if err != nil {
+			t.Fatalf("failed to begin login: %v", err)
+		}
+		response, err := passkey.ParseAssertionResponse(authenticator.get(t, ceremony))
+		if err != nil {
+			t.Fatalf("failed to parse assertion response: %v", err)
+		}
+		return rp.FinishLogin(user, ceremony.Session, response)
+	}
+
+	credential, err := login()
+	if err != nil {
+		t.Fatalf("failed to finish login: %v", err)
+	}
+	user.Credentials[0].Authenticator.SignCount = credential.Authenticator.SignCount
+
+	// The cloned authenticator responds with the counter that was already seen
+	authenticator.signCount--
+	if _, err := login(); !errors.Is(err, passkey.ErrCloneDetected) {
+		t.Errorf("login with regressed counter error = %v, want %v", err, passkey.ErrCloneDetected)
+	}
+}
+
+func TestPasskeyRejectsInvalidResponse(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := newSoftAuthenticator(t)
+	user := register(t, rp, authenticator)
+
+	tests := []struct {
+		name    string
+		respond func(ceremony *passkey.Ceremony) string
+		wantErr error
+	}{
+		{
+			name:    "malformed response",
+			respond: func(*passkey.Ceremony) string { return `{"id":"invalid"}` },
+			wantErr: passkey.ErrInvalidResponse,
+		},
+		{
+			name: "foreign origin",
+			respond: func(ceremony *passkey.Ceremony) string {
+				authenticator.origin = "https://phishing.example.com"
+				defer func() { authenticator.origin = origin }()
+				return authenticator.get(t, ceremony)
+			},
+			wantErr: passkey.ErrVerificationFailed,
+		},
+		{
+			name: "other ceremony challenge",
+			respond: func(*passkey.Ceremony) string {
+				other, err := rp.BeginLogin(user)
+				if err != nil {
+					t.Fatalf("failed to begin login: %v", err)
+				}
+				return authenticator.get(t, other)
+			},
+			wantErr: passkey.ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ceremony, err := rp.BeginLogin(user)
+			if err != nil {
+				t.Fatalf("failed to begin login: %v", err)
+			}
+
+			response, err := passkey.ParseAssertionResponse(tt.respond(ceremony))
+			if err == nil {
+				_, err = rp.FinishLogin(user, ceremony.Session, response)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package redactor_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/pkg/redactor"
+	pb "github.com/budgetin-app/user-service/app/proto"
+)
+
+func TestRedactPassword(t *testing.T) {
+	req := &pb.AuthenticationRequest{Username: "john", Email: "john@email.com", Password: "secretpassword123!"}
+
+	redacted := redactor.Redact(req).(*pb.AuthenticationRequest)
+
+	if redacted.Password != redactor.RedactedValue {
+		t.Errorf("Redact failed: expected password %q, got %q", redactor.RedactedValue, redacted.Password)
+	}
+	if redacted.Username != req.Username || redacted.Email != req.Email {
+		t.Error("Redact failed: non sensitive fields should not be changed")
+	}
+	if req.Password != "secretpassword123!" {
+		t.Error("Redact failed: original message should not be modified")
+	}
+}
+
+func TestRedactTokens(t *testing.T) {
+	verify := &pb.VerifyEmailRequest{Email: "john@email.com", VerificationToken: "verification-token"}
+	logout := &pb.LogoutRequest{AuthToken: "auth-token"}
+
+	for _, value := range []string{redactor.String(verify), redactor.String(logout)} {
+		if strings.Contains(value, "-token") {
+			t.Errorf("String failed: token leaked in %q", value)
+		}
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	cases := map[string]bool{
+		"password":     true,
+		"new_password": true,
+		"auth_token":   true,
+		"username":     false,
+		"email":        false,
+		"tokens_count": false,
+	}
+
+	for name, expected := range cases {
+		if actual := redactor.IsSensitive(name); actual != expected {
+			t.Errorf("IsSensitive(%q) failed: expected %v, got %v", name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/scheduler"
+)
+
+// fakeElector elects the instance according to the leader flag
+type fakeElector struct {
+	leader   atomic.Bool
+	resigned atomic.Bool
+}
+
+func (e *fakeElector) Elect(context.Context) (bool, error) {
+	return e.leader.Load(), nil
+}
+
+func (e *fakeElector) Resign(context.Context) error {
+	e.resigned.Store(true)
+	return nil
+}
+
+func countingJob(runs *atomic.Int64) scheduler.Job {
+	return scheduler.Job{
+		Name:     "counting",
+		Interval: 5 * time.Millisecond,
+		Run: func(context.Context) (int64, error) {
+			runs.Add(1)
+			return 1, nil
+		},
+	}
+}
+
+func TestSchedulerRunsJobsOnlyOnLeader(t *testing.T) {
+	elector := &fakeElector{}
+	var runs atomic.Int64
+	s := scheduler.New(elector, 5*time.Millisecond, countingJob(&runs))
+
+	s.Start(context.Background())
+	defer s.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if got := runs.Load(); got != 0 {
+		t.Fatalf("follower ran the job %d times, want 0", got)
+	}
+
+	elector.leader.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !s.IsLeader() || runs.Load() == 0 {
+		t.Fatalf("leader = %v, runs = %d, want the elected leader running the job", s.IsLeader(), runs.Load())
+	}
+}
+
+func TestSchedulerStopResignsLeadership(t *testing.T) {
+	elector := &fakeElector{}
+	elector.leader.Store(true)
+	var runs atomic.Int64
+	s := scheduler.New(elector, time.Hour, countingJob(&runs))
+
+	s.Start(context.Background())
+	s.Stop()
+
+	if !elector.resigned.Load() {
+		t.Error("leadership is not resigned after stop")
+	}
+	if s.IsLeader() {
+		t.Error("scheduler is still the leader after stop")
+	}
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if got := runs.Load(); got != stopped {
+		t.Errorf("job ran %d times after stop", got-stopped)
+	}
+}
+
+func TestSchedulerSkipsDisabledJobs(t *testing.T) {
+	elector := &fakeElector{}
+	elector.leader.Store(true)
+	s := scheduler.New(elector, time.Hour, scheduler.Job{Name: "disabled", Interval: 0})
+
+	// Without any enabled job the instance doesn't try to become the leader
+	s.Start(context.Background())
+	defer s.Stop()
+	if s.IsLeader() {
+		t.Error("scheduler without jobs is elected as the leader")
+	}
+}
@@ -0,0 +1,241 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/pkg/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// fixture is a self-signed certificate authority issuing the test certificates
+type fixture struct {
+	dir    string
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	f := &fixture{dir: t.TempDir(), caCert: cert, caKey: key, serial: 1}
+	f.write(t, "ca.crt", "CERTIFICATE", der)
+	return f
+}
+
+// issue creates the certificate signed by the CA and writes the pair into the files
+func (f *fixture) issue(t *testing.T, name string, commonName string, usage x509.ExtKeyUsage) (string, string) {
+	f.serial++
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(f.serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.caCert, &key.PublicKey, f.caKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	return f.write(t, name+".crt", "CERTIFICATE", der), f.write(t, name+".key", "EC PRIVATE KEY", keyDer)
+}
+
+func (f *fixture) write(t *testing.T, name string, blockType string, der []byte) string {
+	path := filepath.Join(f.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// serve accepts the TLS connections and writes a greeting after the handshake
+func serve(t *testing.T, config *tls.Config) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// dial connects to the server and returns the served certificate serial number
+func dial(address string, caFile string, certFile string, keyFile string) (*big.Int, error) {
+	config, err := tlsconfig.NewClientConfig(caFile, "localhost", certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := tls.Dial("tcp", address, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// The client certificate is verified by the server after the client handshake
+	buf := make([]byte, 2)
+	if _, err := conn.Read(buf); err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+}
+
+func TestMutualTLSAllowedClient(t *testing.T) {
+	f := newFixture(t)
+	serverCert, serverKey := f.issue(t, "server", "localhost", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := f.issue(t, "client", "budget-service", x509.ExtKeyUsageClientAuth)
+	otherCert, otherKey := f.issue(t, "other", "unknown-service", x509.ExtKeyUsageClientAuth)
+	caFile := filepath.Join(f.dir, "ca.crt")
+
+	reloader, err := tlsconfig.NewCertReloader(tlsconfig.Config{
+		CertFile:           serverCert,
+		KeyFile:            serverKey,
+		ClientCAFile:       caFile,
+		ClientAuth:         tlsconfig.ClientAuthRequire,
+		AllowedClientNames: []string{"budget-service"},
+	})
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+	address := serve(t, reloader.ServerConfig())
+
+	if _, err := dial(address, caFile, clientCert, clientKey); err != nil {
+		t.Errorf("allowed client failed to connect: %v", err)
+	}
+	if _, err := dial(address, caFile, otherCert, otherKey); err == nil {
+		t.Error("client not in the allowlist should be rejected")
+	}
+	if _, err := dial(address, caFile, "", ""); err == nil {
+		t.Error("client without certificate should be rejected")
+	}
+}
+
+func TestCertReloaderReload(t *testing.T) {
+	f := newFixture(t)
+	serverCert, serverKey := f.issue(t, "server", "localhost", x509.ExtKeyUsageServerAuth)
+	caFile := filepath.Join(f.dir, "ca.crt")
+
+	reloader, err := tlsconfig.NewCertReloader(tlsconfig.Config{CertFile: serverCert, KeyFile: serverKey})
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+	address := serve(t, reloader.ServerConfig())
+
+	before, err := dial(address, caFile, "", "")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	// Rotate the certificate on the disk and reload it without restarting the listener
+	f.issue(t, "server", "localhost", x509.ExtKeyUsageServerAuth)
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	after, err := dial(address, caFile, "", "")
+	if err != nil {
+		t.Fatalf("failed to connect after reload: %v", err)
+	}
+	if before.Cmp(after) == 0 {
+		t.Errorf("Reload failed: expected a new certificate, got the same serial %v", after)
+	}
+}
+
+func TestServerConfigNegotiatesGRPC(t *testing.T) {
+	f := newFixture(t)
+	serverCert, serverKey := f.issue(t, "server", "localhost", x509.ExtKeyUsageServerAuth)
+	caFile := filepath.Join(f.dir, "ca.crt")
+
+	reloader, err := tlsconfig.NewCertReloader(tlsconfig.Config{CertFile: serverCert, KeyFile: serverKey})
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+
+	// Serve gRPC over the reloaded TLS configuration
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	clientConfig, err := tlsconfig.NewClientConfig(caFile, "localhost", "", "")
+	if err != nil {
+		t.Fatalf("NewClientConfig failed: %v", err)
+	}
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var p peer.Peer
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Peer(&p)); err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+
+	// The clients enforcing the ALPN reject the server not negotiating "h2"
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		t.Fatalf("expected the TLS auth info, got %T", p.AuthInfo)
+	}
+	if protocol := info.State.NegotiatedProtocol; protocol != "h2" {
+		t.Errorf("negotiated protocol = %q, want \"h2\"", protocol)
+	}
+}
@@ -0,0 +1,29 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/repository"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "jane", want: "jane"},
+		{value: "100%", want: `100\%`},
+		{value: "jane_doe", want: `jane\_doe`},
+		{value: `domain\jane`, want: `domain\\jane`},
+		// The escape character is escaped first, so the added escapes aren't doubled
+		{value: `\%_`, want: `\\\%\_`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := repository.EscapeLike(tt.value); got != tt.want {
+				t.Errorf("EscapeLike(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package gateway_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/server/gateway"
+)
+
+func TestEmailVerificationPage(t *testing.T) {
+	handler, err := gateway.NewGateway(context.Background(), "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"link opens the confirmation page", http.MethodGet, "/email-verification/token-123", http.StatusOK},
+		{"link doesn't accept the state change", http.MethodPost, "/email-verification/token-123", http.StatusMethodNotAllowed},
+		{"link without the token", http.MethodGet, "/email-verification/", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(recorder.Body.String(), `"token-123"`) {
+				t.Error("expected the page to POST the verification token")
+			}
+		})
+	}
+}
+
+func TestCORSIsOptIn(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	if gateway.NewCORSConfig().IsOriginAllowed("https://evil.example") {
+		t.Error("expected no origin to be allowed by default")
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example")
+	config := gateway.NewCORSConfig()
+	if !config.IsOriginAllowed("https://app.example") || config.IsOriginAllowed("https://evil.example") {
+		t.Error("expected only the configured origin to be allowed")
+	}
+}
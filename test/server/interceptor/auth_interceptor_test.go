@@ -0,0 +1,91 @@
+package interceptor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/constant"
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/domain/principal"
+	"github.com/budgetin-app/user-service/app/server/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeAuthenticator resolves the principals of the known credentials
+type fakeAuthenticator map[string]*principal.Principal
+
+func (a fakeAuthenticator) Authenticate(ctx context.Context, credential string) (*principal.Principal, error) {
+	if p, ok := a[credential]; ok {
+		return p, nil
+	}
+	return nil, apperror.ErrInvalidAPIKey
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	sessions := fakeAuthenticator{
+		"user-token":  {Type: principal.TypeUser, ID: 1, Scopes: constant.GetRoleScopes(constant.UserRoleID)},
+		"admin-token": {Type: principal.TypeUser, ID: 2, Scopes: []string{constant.ScopeAll}},
+		"recent-token": {Type: principal.TypeUser, ID: 3, Scopes: constant.GetRoleScopes(constant.UserRoleID),
+			AuthenticatedAt: time.Now()},
+		"stale-token": {Type: principal.TypeUser, ID: 4, Scopes: constant.GetRoleScopes(constant.UserRoleID),
+			AuthenticatedAt: time.Now().Add(-24 * time.Hour)},
+		"impersonation-token": {Type: principal.TypeUser, ID: 5, Scopes: constant.GetRoleScopes(constant.UserRoleID),
+			AuthenticatedAt: time.Now(), ImpersonatorID: 2},
+	}
+	personalAccessTokens := fakeAuthenticator{
+		"bgp_abcdefgh_secret": {Type: principal.TypeUser, ID: 1, Scopes: []string{constant.ScopeAccountRead}},
+		"bgp_abcdefgh_write":  {Type: principal.TypeUser, ID: 1, Scopes: []string{constant.ScopeAccountWrite}},
+	}
+	apiKeys := fakeAuthenticator{
+		"reader-key": {Type: principal.TypeServiceAccount, ID: 1, Scopes: []string{constant.ScopeServiceAccountsRead}},
+	}
+	authInterceptor := interceptor.NewAuthInterceptor(sessions, personalAccessTokens, apiKeys)
+
+	tests := []struct {
+		name     string
+		method   string
+		metadata metadata.MD
+		wantErr  error
+	}{
+		{"public method without credentials", "/userservice.User/LoginUser", nil, nil},
+		{"protected method without credentials", "/userservice.User/ListServiceAccounts", nil, apperror.ErrMissingCredentials},
+		{"invalid api key", "/userservice.User/ListServiceAccounts", metadata.Pairs("x-api-key", "unknown"), apperror.ErrInvalidAPIKey},
+		{"api key with the scope", "/userservice.User/ListServiceAccounts", metadata.Pairs("x-api-key", "reader-key"), nil},
+		{"api key without the scope", "/userservice.User/RevokeApiKey", metadata.Pairs("x-api-key", "reader-key"), apperror.ErrInsufficientScope},
+		{"user session without the scope", "/userservice.User/ListServiceAccounts", metadata.Pairs("authorization", "Bearer user-token"), apperror.ErrInsufficientScope},
+		{"admin session", "/userservice.User/CreateServiceAccount", metadata.Pairs("authorization", "Bearer admin-token"), nil},
+		{"user session", "/userservice.User/ListPersonalAccessTokens", metadata.Pairs("authorization", "Bearer user-token"), nil},
+		{"personal access token without the scope", "/userservice.User/CreatePersonalAccessToken", metadata.Pairs("authorization", "Bearer bgp_abcdefgh_secret"), apperror.ErrInsufficientScope},
+		{"recently authenticated session", "/userservice.User/DeletePasskey", metadata.Pairs("authorization", "Bearer recent-token"), nil},
+		{"stale session requires step up", "/userservice.User/DeletePasskey", metadata.Pairs("authorization", "Bearer stale-token"), apperror.ErrStepUpRequired},
+		{"stale session reauthenticates", "/userservice.User/Reauthenticate", metadata.Pairs("authorization", "Bearer stale-token"), nil},
+		{"personal access token requires step up", "/userservice.User/LinkExternalAccount", metadata.Pairs("authorization", "Bearer bgp_abcdefgh_write"), apperror.ErrStepUpRequired},
+		{"user session can't search users", "/userservice.User/SearchUsers", metadata.Pairs("authorization", "Bearer user-token"), apperror.ErrInsufficientScope},
+		{"admin session searches users", "/userservice.User/SearchUsers", metadata.Pairs("authorization", "Bearer admin-token"), nil},
+		{"impersonated session reads", "/userservice.User/ListSessions", metadata.Pairs("authorization", "Bearer impersonation-token"), nil},
+		{"impersonated session can't do sensitive actions", "/userservice.User/DeletePasskey", metadata.Pairs("authorization", "Bearer impersonation-token"), apperror.ErrImpersonationRestricted},
+		{"impersonated session can't reauthenticate", "/userservice.User/Reauthenticate", metadata.Pairs("authorization", "Bearer impersonation-token"), apperror.ErrImpersonationRestricted},
+		{"malformed authorization", "/userservice.User/CreateServiceAccount", metadata.Pairs("authorization", "admin-token"), apperror.ErrMissingCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.metadata)
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if _, ok := principal.FromContext(ctx); !ok && tt.metadata != nil {
+					t.Error("AuthInterceptor failed: expected the principal in the context")
+				}
+				return "ok", nil
+			}
+
+			_, err := authInterceptor(ctx, nil, info, handler)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthInterceptor failed: expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
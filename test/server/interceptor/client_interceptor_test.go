@@ -0,0 +1,89 @@
+package interceptor_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/domain/client"
+	"github.com/budgetin-app/user-service/app/server/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientInterceptor(t *testing.T) {
+	gateway := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50000}
+	remote := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 50000}
+
+	tests := []struct {
+		name      string
+		peer      net.Addr
+		proxyHops string
+		metadata  metadata.MD
+		want      client.Info
+	}{
+		{
+			name:     "direct gRPC client",
+			peer:     remote,
+			metadata: metadata.Pairs("user-agent", "grpc-go/1.60.0"),
+			want:     client.Info{IP: "203.0.113.7", UserAgent: "grpc-go/1.60.0"},
+		},
+		{
+			name: "forwarded by the gateway",
+			peer: gateway,
+			metadata: metadata.Pairs(
+				"x-forwarded-for", "198.51.100.20",
+				"grpcgateway-user-agent", "Mozilla/5.0",
+				"user-agent", "grpc-go/1.60.0",
+			),
+			want: client.Info{IP: "198.51.100.20", UserAgent: "Mozilla/5.0"},
+		},
+		{
+			name:     "forwarded address prepended by the client",
+			peer:     gateway,
+			metadata: metadata.Pairs("x-forwarded-for", "192.0.2.1, 198.51.100.20"),
+			want:     client.Info{IP: "198.51.100.20"},
+		},
+		{
+			name:      "forwarded by the trusted proxy",
+			peer:      gateway,
+			proxyHops: "1",
+			metadata:  metadata.Pairs("x-forwarded-for", "192.0.2.1, 198.51.100.20, 10.0.0.1"),
+			want:      client.Info{IP: "198.51.100.20"},
+		},
+		{
+			name:      "more trusted proxies than forwarded addresses",
+			peer:      gateway,
+			proxyHops: "3",
+			metadata:  metadata.Pairs("x-forwarded-for", "198.51.100.20, 10.0.0.1"),
+			want:      client.Info{IP: "198.51.100.20"},
+		},
+		{
+			name:     "spoofed forwarded address",
+			peer:     remote,
+			metadata: metadata.Pairs("x-forwarded-for", "198.51.100.20"),
+			want:     client.Info{IP: "203.0.113.7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXY_HOPS", tt.proxyHops)
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tt.peer})
+			ctx = metadata.NewIncomingContext(ctx, tt.metadata)
+
+			var got client.Info
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = client.FromContext(ctx)
+				return nil, nil
+			}
+			if _, err := interceptor.ClientInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("client info = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package interceptor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/budgetin-app/user-service/app/domain/apperror"
+	"github.com/budgetin-app/user-service/app/server/interceptor"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatusErrorDomainErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{apperror.ErrUsernameExists, codes.AlreadyExists, "USERNAME_ALREADY_EXISTS"},
+		{apperror.ErrCredentialsMismatch, codes.Unauthenticated, "INVALID_CREDENTIALS"},
+		{apperror.ErrAlreadyLoggedOn, codes.FailedPrecondition, "ALREADY_LOGGED_ON"},
+		{apperror.Wrap(apperror.ErrNotFound, errors.New("record not found")), codes.NotFound, "NOT_FOUND"},
+		{fmt.Errorf("failed to logout user: %w", apperror.ErrSessionNotFound), codes.NotFound, "SESSION_NOT_FOUND"},
+	}
+
+	for _, c := range cases {
+		st := status.Convert(interceptor.ToStatusError(context.Background(), "/test", c.err))
+		if st.Code() != c.code {
+			t.Errorf("ToStatusError(%v) failed: expected code %v, got %v", c.err, c.code, st.Code())
+		}
+
+		details := st.Details()
+		if len(details) != 1 {
+			t.Fatalf("ToStatusError(%v) failed: expected 1 detail, got %d", c.err, len(details))
+		}
+		if info, ok := details[0].(*errdetails.ErrorInfo); !ok || info.Reason != c.reason {
+			t.Errorf("ToStatusError(%v) failed: expected reason %s, got %v", c.err, c.reason, details[0])
+		}
+	}
+}
+
+func TestToStatusErrorHidesInternalErrors(t *testing.T) {
+	errs := []error{
+		errors.New(`pq: relation "user_accounts" does not exist`),
+		apperror.Wrap(apperror.ErrInternal, errors.New("connection refused")),
+	}
+
+	for _, err := range errs {
+		st := status.Convert(interceptor.ToStatusError(context.Background(), "/test", err))
+		if st.Code() != codes.Internal {
+			t.Errorf("ToStatusError(%v) failed: expected code %v, got %v", err, codes.Internal, st.Code())
+		}
+		if st.Message() != "internal server error" {
+			t.Errorf("ToStatusError(%v) failed: internal message leaked %q", err, st.Message())
+		}
+	}
+}
+
+func TestToStatusErrorKeepsStatus(t *testing.T) {
+	err := status.Error(codes.InvalidArgument, "invalid email")
+
+	st := status.Convert(interceptor.ToStatusError(context.Background(), "/test", err))
+	if st.Code() != codes.InvalidArgument || st.Message() != "invalid email" {
+		t.Errorf("ToStatusError failed: expected status to be kept, got %v", st)
+	}
+}
@@ -0,0 +1,86 @@
+package interceptor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/budgetin-app/user-service/app/server/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/userservice.User/LoginUser"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		var session *struct{ Token string }
+		return session.Token, nil // nil dereference
+	}
+
+	resp, err := interceptor.RecoveryInterceptor(context.Background(), nil, info, handler)
+	if resp != nil {
+		t.Errorf("RecoveryInterceptor failed: expected nil response, got %v", resp)
+	}
+	if status.Code(err) != codes.Internal {
+		t.Errorf("RecoveryInterceptor failed: expected code %v, got %v", codes.Internal, status.Code(err))
+	}
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/userservice.User/LoginUser"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("DeadlineInterceptor failed: expected the default deadline to be set")
+		}
+		return nil, nil
+	}
+
+	interceptor.DeadlineInterceptor(context.Background(), nil, info, handler)
+}
+
+func TestDeadlineInterceptorMethodTimeouts(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "3s")
+
+	tests := []struct {
+		name   string
+		method string
+		ctx    func() (context.Context, context.CancelFunc)
+		want   time.Duration
+	}{
+		{"listed method", "/userservice.User/LogoutUser", noDeadline, 5 * time.Second},
+		{"outbound method", "/userservice.User/CompleteExternalLogin", noDeadline, 20 * time.Second},
+		{"unlisted method gets the default", "/userservice.User/ListUsers", noDeadline, 3 * time.Second},
+		{"client deadline is kept", "/userservice.User/ListUsers", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), time.Minute)
+		}, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			var remaining time.Duration
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				deadline, ok := ctx.Deadline()
+				if !ok {
+					t.Fatal("expected the request to have a deadline")
+				}
+				remaining = time.Until(deadline)
+				return nil, nil
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			if _, err := interceptor.DeadlineInterceptor(ctx, nil, info, handler); err != nil {
+				t.Fatal(err)
+			}
+			if remaining > tt.want || remaining < tt.want-time.Second {
+				t.Errorf("deadline in %v, want %v", remaining, tt.want)
+			}
+		})
+	}
+}
+
+func noDeadline() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}